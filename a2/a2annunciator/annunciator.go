@@ -0,0 +1,113 @@
+// Package a2annunciator handles the annunciators, which are four output lines
+// (AN0 through AN3) on the game I/O connector. Software turns them on and off
+// by reading or writing a soft switch; on the //e, AN3 doubles as the switch
+// for double hi-res.
+package a2annunciator
+
+import (
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/internal/metrics"
+	"github.com/pevans/erc/memory"
+)
+
+// Count is the number of annunciators.
+const Count = 4
+
+const (
+	offAN0 = int(0xC058) // R/W
+	onAN0  = int(0xC059) // R/W
+	offAN1 = int(0xC05A) // R/W
+	onAN1  = int(0xC05B) // R/W
+	offAN2 = int(0xC05C) // R/W
+	onAN2  = int(0xC05D) // R/W
+
+	// AN3 is at $C05E (off) and $C05F (on), but those switches are handled
+	// by the display, since they also control double hi-res. The display
+	// uses Set to keep AN3 up to date.
+)
+
+// keys are the state map keys for each annunciator.
+var keys = [Count]int{
+	a2state.Annunciator0,
+	a2state.Annunciator1,
+	a2state.Annunciator2,
+	a2state.Annunciator3,
+}
+
+// metricNames are the metrics we count when an annunciator is turned off
+// (the first of each pair) or on (the second).
+var metricNames = [Count][2]string{
+	{"soft_annunciator_0_off", "soft_annunciator_0_on"},
+	{"soft_annunciator_1_off", "soft_annunciator_1_on"},
+	{"soft_annunciator_2_off", "soft_annunciator_2_on"},
+	{"soft_annunciator_3_off", "soft_annunciator_3_on"},
+}
+
+// ReadSwitches returns the list of annunciator switch addresses that support
+// reads.
+func ReadSwitches() []int {
+	return []int{offAN0, onAN0, offAN1, onAN1, offAN2, onAN2}
+}
+
+// WriteSwitches returns the list of annunciator switch addresses that support
+// writes.
+func WriteSwitches() []int {
+	return []int{offAN0, onAN0, offAN1, onAN1, offAN2, onAN2}
+}
+
+// UseDefaults sets the annunciators to the state they have after a reset,
+// which is that AN0 and AN1 are off and AN2 and AN3 are on.
+func UseDefaults(stm *memory.StateMap) {
+	stm.SetBool(a2state.Annunciator0, false)
+	stm.SetBool(a2state.Annunciator1, false)
+	stm.SetBool(a2state.Annunciator2, true)
+	stm.SetBool(a2state.Annunciator3, true)
+}
+
+// On returns true if the given annunciator (0-3) is on. This is how a slot
+// card that uses an annunciator as an output line can see its state.
+func On(n int, stm *memory.StateMap) bool {
+	if n < 0 || n >= Count {
+		return false
+	}
+
+	return stm.Bool(keys[n])
+}
+
+// Set turns the given annunciator (0-3) on or off.
+func Set(n int, on bool, stm *memory.StateMap) {
+	if n < 0 || n >= Count {
+		return
+	}
+
+	if on {
+		metrics.Increment(metricNames[n][1], 1)
+	} else {
+		metrics.Increment(metricNames[n][0], 1)
+	}
+
+	stm.SetBool(keys[n], on)
+}
+
+// SwitchRead handles reads from annunciator soft switches. A read has the
+// same effect as a write, and what we return is meaningless.
+func SwitchRead(addr int, stm *memory.StateMap) uint8 {
+	toggle(addr, stm)
+	return 0
+}
+
+// SwitchWrite handles writes to annunciator soft switches.
+func SwitchWrite(addr int, val uint8, stm *memory.StateMap) {
+	toggle(addr, stm)
+}
+
+// toggle sets an annunciator based on the switch address. The switches are
+// laid out in pairs, with the even address turning an annunciator off and
+// the odd address turning it on.
+func toggle(addr int, stm *memory.StateMap) {
+	if addr < offAN0 || addr > onAN2 {
+		return
+	}
+
+	Set((addr-offAN0)/2, addr&1 == 1, stm)
+}
@@ -0,0 +1,74 @@
+package a2annunciator
+
+import (
+	"testing"
+
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/memory"
+	"github.com/stretchr/testify/suite"
+)
+
+type annunciatorSuite struct {
+	suite.Suite
+
+	state *memory.StateMap
+}
+
+func (s *annunciatorSuite) SetupTest() {
+	s.state = memory.NewStateMap()
+	UseDefaults(s.state)
+}
+
+func TestAnnunciatorSuite(t *testing.T) {
+	suite.Run(t, new(annunciatorSuite))
+}
+
+func (s *annunciatorSuite) TestUseDefaults() {
+	s.False(On(0, s.state))
+	s.False(On(1, s.state))
+	s.True(On(2, s.state))
+	s.True(On(3, s.state))
+}
+
+func (s *annunciatorSuite) TestSwitchRead() {
+	cases := []struct {
+		addr int
+		n    int
+		on   bool
+	}{
+		{onAN0, 0, true},
+		{offAN0, 0, false},
+		{onAN1, 1, true},
+		{offAN1, 1, false},
+		{offAN2, 2, false},
+		{onAN2, 2, true},
+	}
+
+	for _, c := range cases {
+		s.Equal(uint8(0), SwitchRead(c.addr, s.state))
+		s.Equal(c.on, On(c.n, s.state), "address %04X", c.addr)
+	}
+}
+
+func (s *annunciatorSuite) TestSwitchWrite() {
+	SwitchWrite(onAN1, 0, s.state)
+	s.True(s.state.Bool(a2state.Annunciator1))
+
+	// Other annunciators are left alone
+	s.False(s.state.Bool(a2state.Annunciator0))
+	s.True(s.state.Bool(a2state.Annunciator2))
+
+	SwitchWrite(offAN1, 0, s.state)
+	s.False(s.state.Bool(a2state.Annunciator1))
+}
+
+func (s *annunciatorSuite) TestSet() {
+	Set(3, false, s.state)
+	s.False(On(3, s.state))
+
+	s.Run("out of range", func() {
+		Set(4, true, s.state)
+		s.False(On(4, s.state))
+		s.False(On(-1, s.state))
+	})
+}
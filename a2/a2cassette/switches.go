@@ -0,0 +1,82 @@
+package a2cassette
+
+import (
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/internal/metrics"
+	"github.com/pevans/erc/memory"
+)
+
+const (
+	// Reading or writing cassetteOut toggles the level of cassette output,
+	// much like the speaker does.
+	cassetteOut int = 0xC020
+
+	// Bit 7 of cassetteIn is the level of cassette input.
+	cassetteIn int = 0xC060
+)
+
+// Computer is an interface for the parts of the computer that the cassette
+// port needs to reach.
+type Computer interface {
+	CycleCounter() uint64
+	Tape() *Tape
+}
+
+// ReadSwitches returns the list of cassette switch addresses that support
+// reads.
+func ReadSwitches() []int {
+	return []int{cassetteOut, cassetteIn}
+}
+
+// WriteSwitches returns the list of cassette switch addresses that support
+// writes.
+func WriteSwitches() []int {
+	return []int{cassetteOut}
+}
+
+// UseDefaults sets up the default state for the cassette port.
+func UseDefaults(stm *memory.StateMap) {
+	stm.SetBool(a2state.CassetteOut, false)
+}
+
+// SwitchRead handles reads from cassette soft switches.
+func SwitchRead(addr int, stm *memory.StateMap) uint8 {
+	// Neither toggling output nor playing the tape is something we should
+	// do if the debugger is just looking ahead
+	if stm.Bool(a2state.DebuggerLookAhead) {
+		return 0
+	}
+
+	switch addr {
+	case cassetteOut:
+		toggle(stm)
+
+	case cassetteIn:
+		metrics.Increment("soft_read_cassette_in", 1)
+
+		comp := stm.Any(a2state.Computer).(Computer)
+		if comp.Tape().Input(comp.CycleCounter()) {
+			return 0x80
+		}
+	}
+
+	return 0
+}
+
+// SwitchWrite handles writes to cassette soft switches.
+func SwitchWrite(addr int, val uint8, stm *memory.StateMap) {
+	if addr != cassetteOut || stm.Bool(a2state.DebuggerLookAhead) {
+		return
+	}
+
+	toggle(stm)
+}
+
+func toggle(stm *memory.StateMap) {
+	metrics.Increment("soft_cassette_out_toggle", 1)
+
+	comp := stm.Any(a2state.Computer).(Computer)
+
+	stm.SetBool(a2state.CassetteOut, !stm.Bool(a2state.CassetteOut))
+	comp.Tape().Toggle(comp.CycleCounter())
+}
@@ -0,0 +1,88 @@
+package a2cassette
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/memory"
+	"github.com/stretchr/testify/suite"
+)
+
+type cassetteSuite struct {
+	suite.Suite
+
+	state *memory.StateMap
+	comp  *mockComputer
+}
+
+type mockComputer struct {
+	cycle uint64
+	tape  *Tape
+}
+
+func (m *mockComputer) CycleCounter() uint64 { return m.cycle }
+func (m *mockComputer) Tape() *Tape          { return m.tape }
+
+func (s *cassetteSuite) SetupTest() {
+	s.comp = &mockComputer{tape: NewTape(clockRate)}
+	s.comp.tape.Record()
+
+	s.state = memory.NewStateMap()
+	s.state.SetAny(a2state.Computer, s.comp)
+	UseDefaults(s.state)
+}
+
+func TestCassetteSuite(t *testing.T) {
+	suite.Run(t, new(cassetteSuite))
+}
+
+func (s *cassetteSuite) TestOutput() {
+	s.Run("read toggles", func() {
+		SwitchRead(cassetteOut, s.state)
+		s.True(s.state.Bool(a2state.CassetteOut))
+		s.Equal(1, s.comp.tape.Toggles())
+	})
+
+	s.Run("write toggles", func() {
+		s.comp.cycle = 10
+		SwitchWrite(cassetteOut, 0, s.state)
+		s.False(s.state.Bool(a2state.CassetteOut))
+		s.Equal(2, s.comp.tape.Toggles())
+	})
+
+	s.Run("look-ahead does nothing", func() {
+		s.state.SetBool(a2state.DebuggerLookAhead, true)
+		defer s.state.SetBool(a2state.DebuggerLookAhead, false)
+
+		SwitchRead(cassetteOut, s.state)
+		SwitchWrite(cassetteOut, 0, s.state)
+		s.False(s.state.Bool(a2state.CassetteOut))
+		s.Equal(2, s.comp.tape.Toggles())
+	})
+}
+
+func (s *cassetteSuite) TestInput() {
+	s.Run("nothing loaded", func() {
+		s.Equal(uint8(0), SwitchRead(cassetteIn, s.state))
+	})
+
+	wav := makeWAV(1000, 1, 8, []int{-50, 50, 50})
+	s.Require().NoError(s.comp.tape.LoadWAV(bytes.NewReader(wav)))
+
+	s.Run("look-ahead doesn't start the tape", func() {
+		s.state.SetBool(a2state.DebuggerLookAhead, true)
+		defer s.state.SetBool(a2state.DebuggerLookAhead, false)
+
+		s.comp.cycle = 100
+		s.Equal(uint8(0), SwitchRead(cassetteIn, s.state))
+	})
+
+	s.Run("bit 7 follows the input", func() {
+		s.comp.cycle = 200
+		s.Equal(uint8(0x00), SwitchRead(cassetteIn, s.state))
+
+		s.comp.cycle = 201
+		s.Equal(uint8(0x80), SwitchRead(cassetteIn, s.state))
+	})
+}
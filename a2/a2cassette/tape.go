@@ -0,0 +1,268 @@
+package a2cassette
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	// SampleRate is the rate at which we write cassette output.
+	SampleRate = 44100
+
+	// amplitude is the level of a sample in the output we write, which is
+	// a bit below the maximum so as not to clip.
+	amplitude = 0x6000
+)
+
+// A Tape is the cassette that's in the (virtual) tape recorder. Anything the
+// computer sends to cassette output is recorded onto it as a series of
+// toggles, and anything it reads from cassette input comes from a WAV file
+// that's been loaded into it.
+type Tape struct {
+	// clockRate is the number of CPU cycles per second, which we need to
+	// line the timing of the tape up with the CPU.
+	clockRate int64
+
+	// recording is true if we're keeping track of output toggles, and
+	// toggles holds the CPU cycles at which they happened.
+	recording bool
+	toggles   []uint64
+
+	// crossings are the times (in seconds from the start of the tape) at
+	// which the input signal crosses zero, and firstHigh is the level of
+	// the input before the first crossing.
+	crossings []float64
+	firstHigh bool
+	loaded    bool
+
+	// playing is true once the computer has started to read input, which
+	// is when we "press play"; startCycle is the cycle at which that
+	// happened.
+	playing    bool
+	startCycle uint64
+}
+
+// NewTape returns a blank tape whose timing is based on the given clock rate
+// (in cycles per second).
+func NewTape(clockRate int64) *Tape {
+	return &Tape{clockRate: clockRate}
+}
+
+// Record tells the tape to start keeping track of cassette output.
+func (t *Tape) Record() {
+	t.recording = true
+}
+
+// Toggle records a toggle of cassette output at the given cycle, if we're
+// recording.
+func (t *Tape) Toggle(cycle uint64) {
+	if t.recording {
+		t.toggles = append(t.toggles, cycle)
+	}
+}
+
+// Toggles returns the number of output toggles that have been recorded.
+func (t *Tape) Toggles() int {
+	return len(t.toggles)
+}
+
+// Input returns the level of cassette input at the given cycle. The tape
+// starts playing the first time this is called after it is loaded.
+func (t *Tape) Input(cycle uint64) bool {
+	if !t.loaded {
+		return false
+	}
+
+	if !t.playing {
+		t.playing = true
+		t.startCycle = cycle
+	}
+
+	seconds := float64(cycle-t.startCycle) / float64(t.clockRate)
+
+	// Each crossing we've passed flips the level
+	passed := sort.SearchFloat64s(t.crossings, seconds)
+	if seconds >= 0 && passed < len(t.crossings) && t.crossings[passed] == seconds {
+		passed++
+	}
+
+	return t.firstHigh != (passed%2 == 1)
+}
+
+// Rewind stops the tape and moves it back to the beginning, so that the next
+// read of cassette input will start playing it again.
+func (t *Tape) Rewind() {
+	t.playing = false
+	t.startCycle = 0
+}
+
+// LoadWAV reads a WAV file from r and makes it the input for the tape. We
+// support uncompressed 8- and 16-bit PCM; if there is more than one channel,
+// we only listen to the first.
+func (t *Tape) LoadWAV(r io.Reader) error {
+	bytes, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("could not read wav: %w", err)
+	}
+
+	samples, rate, err := decodeWAV(bytes)
+	if err != nil {
+		return err
+	}
+
+	t.crossings = zeroCrossings(samples, rate)
+	t.firstHigh = len(samples) > 0 && samples[0] >= 0
+	t.loaded = true
+	t.Rewind()
+
+	return nil
+}
+
+// zeroCrossings returns the times at which the signal in samples crosses
+// zero. We interpolate between samples so that the timing is as close as we
+// can get to the original signal.
+func zeroCrossings(samples []int, rate int) []float64 {
+	var crossings []float64
+
+	for i := 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		if (prev >= 0) == (cur >= 0) {
+			continue
+		}
+
+		frac := float64(prev) / float64(prev-cur)
+		crossings = append(crossings, (float64(i-1)+frac)/float64(rate))
+	}
+
+	return crossings
+}
+
+// decodeWAV returns the samples of the first channel of a PCM WAV file,
+// centered on zero, along with the sample rate.
+func decodeWAV(bytes []uint8) ([]int, int, error) {
+	if len(bytes) < 12 || string(bytes[0:4]) != "RIFF" || string(bytes[8:12]) != "WAVE" {
+		return nil, 0, errors.New("not a wav file")
+	}
+
+	var (
+		channels, bits, rate int
+		data                 []uint8
+		haveFormat           bool
+	)
+
+	// The file is a series of chunks, each with an ID and a size
+	for pos := 12; pos+8 <= len(bytes); {
+		id := string(bytes[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(bytes[pos+4:]))
+		body := bytes[pos+8 : min(pos+8+size, len(bytes))]
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, 0, errors.New("wav format chunk is too short")
+			}
+
+			if format := binary.LittleEndian.Uint16(body[0:]); format != 1 {
+				return nil, 0, fmt.Errorf("unsupported wav format %d (only PCM is supported)", format)
+			}
+
+			channels = int(binary.LittleEndian.Uint16(body[2:]))
+			rate = int(binary.LittleEndian.Uint32(body[4:]))
+			bits = int(binary.LittleEndian.Uint16(body[14:]))
+			haveFormat = true
+
+		case "data":
+			data = body
+		}
+
+		// Chunks are padded to an even length
+		pos += 8 + size + size%2
+	}
+
+	if !haveFormat || data == nil {
+		return nil, 0, errors.New("wav file is missing its format or data")
+	}
+
+	if channels < 1 || rate < 1 || (bits != 8 && bits != 16) {
+		return nil, 0, fmt.Errorf("unsupported wav file: %d channels, %d Hz, %d bits", channels, rate, bits)
+	}
+
+	frame := channels * bits / 8
+	samples := make([]int, 0, len(data)/frame)
+
+	for i := 0; i+frame <= len(data); i += frame {
+		if bits == 8 {
+			// 8-bit samples are unsigned
+			samples = append(samples, int(data[i])-0x80)
+			continue
+		}
+
+		samples = append(samples, int(int16(binary.LittleEndian.Uint16(data[i:]))))
+	}
+
+	return samples, rate, nil
+}
+
+// WriteWAV writes everything that has been recorded to cassette output as a
+// 16-bit mono WAV file. The output begins at the first toggle.
+func (t *Tape) WriteWAV(w io.Writer) error {
+	var samples []int16
+
+	if len(t.toggles) > 0 {
+		var (
+			start = t.toggles[0]
+			end   = t.toggles[len(t.toggles)-1]
+			total = t.sampleAt(end-start) + 1
+			next  = 0
+			high  = false
+		)
+
+		samples = make([]int16, total)
+		for i := range samples {
+			// Apply every toggle that has happened by this sample
+			for next < len(t.toggles) && t.sampleAt(t.toggles[next]-start) <= i {
+				high = !high
+				next++
+			}
+
+			samples[i] = -amplitude
+			if high {
+				samples[i] = amplitude
+			}
+		}
+	}
+
+	header := make([]uint8, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+len(samples)*2))
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:], 1) // mono
+	binary.LittleEndian.PutUint32(header[24:], SampleRate)
+	binary.LittleEndian.PutUint32(header[28:], SampleRate*2)
+	binary.LittleEndian.PutUint16(header[32:], 2)
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(len(samples)*2))
+
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("could not write wav header: %w", err)
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, samples); err != nil {
+		return fmt.Errorf("could not write wav data: %w", err)
+	}
+
+	return nil
+}
+
+// sampleAt returns the index of the output sample for the given number of
+// cycles since the start of the output.
+func (t *Tape) sampleAt(cycles uint64) int {
+	return int(cycles * SampleRate / uint64(t.clockRate))
+}
@@ -0,0 +1,153 @@
+package a2cassette
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clockRate is a convenient clock rate for tests, since it lines up with the
+// sample rate of the WAV files we build.
+const clockRate = 1000
+
+// makeWAV returns a PCM WAV file with the given format. Samples are given for
+// the first channel; any other channels are filled with the inverse, so that
+// we can tell if the wrong channel is read.
+func makeWAV(rate, channels, bits int, samples []int) []uint8 {
+	var data bytes.Buffer
+
+	for _, smp := range samples {
+		for ch := range channels {
+			val := smp
+			if ch > 0 {
+				val = -smp
+			}
+
+			if bits == 8 {
+				data.WriteByte(uint8(val + 0x80))
+				continue
+			}
+
+			_ = binary.Write(&data, binary.LittleEndian, int16(val))
+		}
+	}
+
+	var buf bytes.Buffer
+	frame := channels * bits / 8
+
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+data.Len()))
+	buf.WriteString("WAVEfmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(16))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(rate))
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(rate*frame))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(frame))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(bits))
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(data.Len()))
+	buf.Write(data.Bytes())
+
+	return buf.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	samples := []int{100, -100, 50, -50}
+
+	t.Run("16-bit stereo", func(t *testing.T) {
+		got, rate, err := decodeWAV(makeWAV(8000, 2, 16, samples))
+		require.NoError(t, err)
+		assert.Equal(t, 8000, rate)
+		assert.Equal(t, samples, got)
+	})
+
+	t.Run("8-bit mono", func(t *testing.T) {
+		got, rate, err := decodeWAV(makeWAV(22050, 1, 8, samples))
+		require.NoError(t, err)
+		assert.Equal(t, 22050, rate)
+		assert.Equal(t, samples, got)
+	})
+
+	t.Run("not a wav file", func(t *testing.T) {
+		_, _, err := decodeWAV([]uint8("this is not a wav file"))
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported bits", func(t *testing.T) {
+		_, _, err := decodeWAV(makeWAV(8000, 1, 24, nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestZeroCrossings(t *testing.T) {
+	// We cross zero halfway between the first two samples, and a quarter
+	// of the way between the third and fourth
+	got := zeroCrossings([]int{10, -10, -30, 10}, 10)
+	assert.Equal(t, []float64{0.05, 0.275}, got)
+}
+
+func TestInput(t *testing.T) {
+	tape := NewTape(clockRate)
+
+	t.Run("nothing loaded", func(t *testing.T) {
+		assert.False(t, tape.Input(0))
+	})
+
+	// At 1000 Hz, each sample is one cycle
+	wav := makeWAV(1000, 1, 16, []int{1000, 1000, -1000, -1000, 1000})
+	require.NoError(t, tape.LoadWAV(bytes.NewReader(wav)))
+
+	t.Run("starts playing at the first read", func(t *testing.T) {
+		assert.True(t, tape.Input(500))
+		assert.True(t, tape.Input(501))
+		assert.False(t, tape.Input(502))
+		assert.False(t, tape.Input(503))
+		assert.True(t, tape.Input(504))
+	})
+
+	t.Run("rewind", func(t *testing.T) {
+		tape.Rewind()
+		assert.True(t, tape.Input(900))
+		assert.False(t, tape.Input(902))
+	})
+}
+
+func TestWriteWAV(t *testing.T) {
+	tape := NewTape(SampleRate)
+
+	// Nothing is kept until we record
+	tape.Toggle(1)
+	assert.Zero(t, tape.Toggles())
+
+	tape.Record()
+	for _, cycle := range []uint64{100, 110, 130, 160} {
+		tape.Toggle(cycle)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, tape.WriteWAV(&buf))
+
+	samples, rate, err := decodeWAV(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, SampleRate, rate)
+	assert.Len(t, samples, 61)
+
+	// The output is high from the first toggle to the second, and from the
+	// third to the fourth
+	assert.Positive(t, samples[0])
+	assert.Negative(t, samples[10])
+	assert.Positive(t, samples[30])
+	assert.Negative(t, samples[60])
+
+	// What we write should play back with the same timing
+	played := NewTape(SampleRate)
+	require.NoError(t, played.LoadWAV(&buf))
+	assert.True(t, played.Input(0))
+	assert.True(t, played.Input(9))
+	assert.False(t, played.Input(10))
+	assert.True(t, played.Input(30))
+}
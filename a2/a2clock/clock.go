@@ -0,0 +1,170 @@
+package a2clock
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/internal/metrics"
+	"github.com/pevans/erc/memory"
+)
+
+const (
+	// Slot is the expansion slot where our clock card is installed. ProDOS
+	// will scan every slot for a clock, but slot 1 is where you'd
+	// traditionally find a Thunderclock.
+	Slot = 1
+
+	// ioBase is the first address of the device I/O space for our slot,
+	// which is $C080 + $10 * slot.
+	ioBase = 0xC080 + (Slot << 4)
+
+	// Reading latchTime captures the current time and rewinds the output
+	// string; reading readChar returns the next character of that string.
+	latchTime = ioBase + 0x0
+	readChar  = ioBase + 0x1
+
+	// Writing to setMode tells the card which format to use the next time
+	// the time is latched.
+	setMode = ioBase + 0x2
+
+	// inputBuffer is where the firmware copies the time string, which is
+	// also where a real Thunderclock would leave it.
+	inputBuffer = 0x0200
+
+	// charReturn is the character (with the high bit set) that ends the
+	// time string.
+	charReturn = uint8(0x8D)
+)
+
+// Computer is an interface for the parts of the computer that the clock card
+// needs in order to know what time it is.
+type Computer interface {
+	ClockTime() time.Time
+}
+
+// ReadSwitches returns the list of clock switch addresses that support reads.
+func ReadSwitches() []int {
+	return []int{
+		latchTime,
+		readChar,
+	}
+}
+
+// WriteSwitches returns the list of clock switch addresses that support
+// writes.
+func WriteSwitches() []int {
+	return []int{
+		setMode,
+	}
+}
+
+// UseDefaults sets the clock card to the state it would have on power-up.
+func UseDefaults(stm *memory.StateMap) {
+	stm.SetUint8(a2state.ClockMode, '#'|0x80)
+	stm.SetAny(a2state.ClockLatch, "")
+	stm.SetInt(a2state.ClockIndex, 0)
+}
+
+// SwitchRead handles reads from the clock card's I/O space.
+func SwitchRead(addr int, stm *memory.StateMap) uint8 {
+	switch addr {
+	case latchTime:
+		metrics.Increment("soft_clock_latch", 1)
+
+		// If we're merely looking ahead in the debugger, we shouldn't change
+		// the string the card is in the middle of returning.
+		if stm.Bool(a2state.DebuggerLookAhead) {
+			return 0
+		}
+
+		c := stm.Any(a2state.Computer).(Computer)
+		stm.SetAny(a2state.ClockLatch, Format(c.ClockTime()))
+		stm.SetInt(a2state.ClockIndex, 0)
+
+	case readChar:
+		metrics.Increment("soft_clock_read_char", 1)
+
+		latch, _ := stm.Any(a2state.ClockLatch).(string)
+		index := stm.Int(a2state.ClockIndex)
+
+		if index >= len(latch) {
+			return charReturn
+		}
+
+		if !stm.Bool(a2state.DebuggerLookAhead) {
+			stm.SetInt(a2state.ClockIndex, index+1)
+		}
+
+		return latch[index] | 0x80
+	}
+
+	return 0
+}
+
+// SwitchWrite handles writes to the clock card's I/O space.
+func SwitchWrite(addr int, val uint8, stm *memory.StateMap) {
+	if addr == setMode {
+		metrics.Increment("soft_clock_set_mode", 1)
+		stm.SetUint8(a2state.ClockMode, val|0x80)
+	}
+}
+
+// Format returns the string that the card would produce for the given time.
+// We only implement the numeric mode ('#') that ProDOS asks for, which
+// returns "mo,dw,dt,hr,mn,sc" with the day of week counted from Sunday; the
+// card will accept other modes, but it will still use this format.
+func Format(t time.Time) string {
+	return fmt.Sprintf(
+		"%02d,%02d,%02d,%02d,%02d,%02d",
+		int(t.Month()), int(t.Weekday()), t.Day(),
+		t.Hour(), t.Minute(), t.Second(),
+	)
+}
+
+// Firmware returns the 256 bytes of slot ROM for the clock card. The first
+// bytes match those that ProDOS checks to identify a Thunderclock, and the
+// entry points at $Cn08 (read time) and $Cn0B (set mode) are where ProDOS
+// expects to find them.
+func Firmware() []uint8 {
+	var (
+		rom  = make([]uint8, 0x100)
+		page = uint8(0xC0 + Slot)
+		lo   = func(addr int) uint8 { return uint8(addr & 0xFF) }
+		hi   = func(addr int) uint8 { return uint8(addr >> 8) }
+	)
+
+	copy(rom, []uint8{
+		// $00: signature, which is also the entry point for PR# and IN#
+		0x08,             // PHP
+		0x78,             // SEI
+		0x28,             // PLP
+		0x2C, 0x58, 0xFF, // BIT $FF58 (an RTS, so this sets V)
+		0x70, 0x06, // BVS $0E
+
+		// $08: read time entry point
+		0x4C, 0x0F, page, // JMP $Cn0F
+
+		// $0B: set mode entry point
+		0x4C, 0x20, page, // JMP $Cn20
+
+		// $0E: we have nothing to do for PR# or IN#
+		0x60, // RTS
+
+		// $0F: latch the time and copy it into the input buffer
+		0x2C, lo(latchTime), hi(latchTime), // BIT latchTime
+		0xA2, 0x00, // LDX #$00
+		0xAD, lo(readChar), hi(readChar), // LDA readChar
+		0x9D, lo(inputBuffer), hi(inputBuffer), // STA inputBuffer,X
+		0xE8,             // INX
+		0xC9, charReturn, // CMP #$8D
+		0xD0, 0xF5, // BNE $14
+		0x60, // RTS
+
+		// $20: the accumulator holds the mode we should use
+		0x8D, lo(setMode), hi(setMode), // STA setMode
+		0x60, // RTS
+	})
+
+	return rom
+}
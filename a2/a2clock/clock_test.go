@@ -0,0 +1,114 @@
+package a2clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/memory"
+	"github.com/stretchr/testify/suite"
+)
+
+type clockSuite struct {
+	suite.Suite
+
+	state *memory.StateMap
+	comp  *mockComputer
+}
+
+type mockComputer struct {
+	now time.Time
+}
+
+func (m *mockComputer) ClockTime() time.Time { return m.now }
+
+func (s *clockSuite) SetupTest() {
+	s.comp = &mockComputer{
+		now: time.Date(2024, time.March, 5, 14, 7, 9, 0, time.UTC),
+	}
+
+	s.state = memory.NewStateMap()
+	s.state.SetAny(a2state.Computer, s.comp)
+	UseDefaults(s.state)
+}
+
+func TestClockSuite(t *testing.T) {
+	suite.Run(t, new(clockSuite))
+}
+
+// readString reads characters from the card until it returns a carriage
+// return, which is what the firmware does.
+func (s *clockSuite) readString() string {
+	var out []byte
+
+	for range 32 {
+		ch := SwitchRead(readChar, s.state)
+		if ch == charReturn {
+			break
+		}
+
+		out = append(out, ch&0x7F)
+	}
+
+	return string(out)
+}
+
+func (s *clockSuite) TestFormat() {
+	s.Equal("03,02,05,14,07,09", Format(s.comp.now))
+}
+
+func (s *clockSuite) TestSwitchRead() {
+	s.Run("nothing latched returns a carriage return", func() {
+		s.Equal(charReturn, SwitchRead(readChar, s.state))
+	})
+
+	s.Run("latch captures the time", func() {
+		SwitchRead(latchTime, s.state)
+		s.Equal("03,02,05,14,07,09", s.readString())
+
+		// Once we're done, we keep returning carriage returns
+		s.Equal(charReturn, SwitchRead(readChar, s.state))
+	})
+
+	s.Run("latch rewinds the string", func() {
+		SwitchRead(latchTime, s.state)
+		SwitchRead(readChar, s.state)
+
+		s.comp.now = s.comp.now.Add(time.Minute)
+		SwitchRead(latchTime, s.state)
+		s.Equal("03,02,05,14,08,09", s.readString())
+	})
+
+	s.Run("look-ahead does not advance", func() {
+		SwitchRead(latchTime, s.state)
+		s.state.SetBool(a2state.DebuggerLookAhead, true)
+		defer s.state.SetBool(a2state.DebuggerLookAhead, false)
+
+		s.Equal(uint8('0'|0x80), SwitchRead(readChar, s.state))
+		s.Equal(uint8('0'|0x80), SwitchRead(readChar, s.state))
+	})
+}
+
+func (s *clockSuite) TestSwitchWrite() {
+	SwitchWrite(setMode, '%', s.state)
+	s.Equal(uint8('%'|0x80), s.state.Uint8(a2state.ClockMode))
+}
+
+func (s *clockSuite) TestFirmware() {
+	rom := Firmware()
+
+	s.Len(rom, 0x100)
+
+	// These are the bytes ProDOS looks at to decide if a slot has a
+	// Thunderclock
+	s.Equal(uint8(0x08), rom[0x00])
+	s.Equal(uint8(0x28), rom[0x02])
+	s.Equal(uint8(0x58), rom[0x04])
+	s.Equal(uint8(0x70), rom[0x06])
+
+	// Entry points should jump into our own page
+	s.Equal(uint8(0x4C), rom[0x08])
+	s.Equal(uint8(0xC0+Slot), rom[0x0A])
+	s.Equal(uint8(0x4C), rom[0x0B])
+	s.Equal(uint8(0xC0+Slot), rom[0x0D])
+}
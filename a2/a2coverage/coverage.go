@@ -0,0 +1,135 @@
+// Package a2coverage keeps track of which parts of memory were executed,
+// read and written while the computer ran. Memory is divided into regions
+// (main and auxiliary memory, the language card banks of each, and ROM), so
+// that code which runs from one bank isn't confused with code that runs from
+// another at the same address.
+package a2coverage
+
+import "sort"
+
+// A Kind is the kind of access that we count in a region.
+type Kind int
+
+const (
+	// Exec counts the bytes of each instruction that was executed.
+	Exec Kind = iota
+
+	// Read counts the reads that instructions made of their data.
+	Read
+
+	// Write counts the writes that instructions made.
+	Write
+
+	numKinds
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case Exec:
+		return "exec"
+	case Read:
+		return "read"
+	case Write:
+		return "write"
+	}
+
+	return "unknown"
+}
+
+// A Region is a span of addresses that live in one bank of memory. Its
+// addresses are those the CPU would use to get at it.
+type Region struct {
+	Name string
+
+	// Start and End are the first address in the region, and the address
+	// just past the last.
+	Start, End int
+
+	counts [numKinds][]uint32
+}
+
+// Count returns the number of times that addr was accessed in the given way.
+func (r *Region) Count(addr int, kind Kind) uint32 {
+	if addr < r.Start || addr >= r.End {
+		return 0
+	}
+
+	return r.counts[kind][addr-r.Start]
+}
+
+// Record counts an access of addr in the given way.
+func (r *Region) Record(addr int, kind Kind) {
+	if addr < r.Start || addr >= r.End {
+		return
+	}
+
+	r.counts[kind][addr-r.Start]++
+}
+
+// Covered returns the number of addresses in the region that were accessed
+// in the given way at least once.
+func (r *Region) Covered(kind Kind) int {
+	covered := 0
+
+	for _, n := range r.counts[kind] {
+		if n > 0 {
+			covered++
+		}
+	}
+
+	return covered
+}
+
+// A Map is the coverage of every region that's been accessed.
+type Map struct {
+	regions map[string]*Region
+}
+
+// NewMap returns a new, empty coverage map.
+func NewMap() *Map {
+	return &Map{
+		regions: make(map[string]*Region),
+	}
+}
+
+// Region returns the region with the given name, which is created with the
+// given span of addresses if we haven't seen it before.
+func (m *Map) Region(name string, start, end int) *Region {
+	if r, ok := m.regions[name]; ok {
+		return r
+	}
+
+	r := &Region{
+		Name:  name,
+		Start: start,
+		End:   end,
+	}
+
+	for k := range r.counts {
+		r.counts[k] = make([]uint32, end-start)
+	}
+
+	m.regions[name] = r
+
+	return r
+}
+
+// Regions returns each region in the map, ordered by their first address and
+// then by name.
+func (m *Map) Regions() []*Region {
+	regions := make([]*Region, 0, len(m.regions))
+	for _, r := range m.regions {
+		regions = append(regions, r)
+	}
+
+	sort.Slice(regions, func(i, j int) bool {
+		if regions[i].Start != regions[j].Start {
+			return regions[i].Start < regions[j].Start
+		}
+
+		return regions[i].Name < regions[j].Name
+	})
+
+	return regions
+}
@@ -0,0 +1,91 @@
+package a2coverage
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type coverageSuite struct {
+	suite.Suite
+
+	cov *Map
+}
+
+func (s *coverageSuite) SetupTest() {
+	s.cov = NewMap()
+}
+
+func TestCoverageSuite(t *testing.T) {
+	suite.Run(t, new(coverageSuite))
+}
+
+func (s *coverageSuite) TestRegion() {
+	r := s.cov.Region("main-lc1", 0xD000, 0xE000)
+	s.Same(r, s.cov.Region("main-lc1", 0, 0))
+
+	r.Record(0xD000, Exec)
+	r.Record(0xD000, Exec)
+	r.Record(0xD001, Read)
+	r.Record(0xE000, Write) // out of the region's span
+
+	s.Equal(uint32(2), r.Count(0xD000, Exec))
+	s.Equal(uint32(1), r.Count(0xD001, Read))
+	s.Equal(uint32(0), r.Count(0xE000, Write))
+	s.Equal(1, r.Covered(Exec))
+	s.Equal(0, r.Covered(Write))
+}
+
+func (s *coverageSuite) TestRegions() {
+	s.cov.Region("rom", 0xC100, 0x10000)
+	s.cov.Region("main", 0, 0xC000)
+	s.cov.Region("aux", 0, 0xC000)
+
+	var names []string
+	for _, r := range s.cov.Regions() {
+		names = append(names, r.Name)
+	}
+
+	s.Equal([]string{"aux", "main", "rom"}, names)
+}
+
+func (s *coverageSuite) TestWriteReport() {
+	r := s.cov.Region("main", 0, 0xC000)
+	for addr := 0x800; addr < 0x803; addr++ {
+		r.Record(addr, Exec)
+	}
+	r.Record(0xBFFF, Write)
+
+	var buf bytes.Buffer
+	s.NoError(s.cov.WriteReport(&buf))
+	s.Equal(
+		"# main $0000-$BFFF: 3 exec, 0 read, 1 write of 49152 bytes\n"+
+			"exec main $0800-$0802\n"+
+			"write main $BFFF-$BFFF\n",
+		buf.String(),
+	)
+}
+
+func (s *coverageSuite) TestWritePNG() {
+	main := s.cov.Region("main", 0, 0xC000)
+	main.Record(0x0801, Exec)
+	s.cov.Region("rom", 0xC100, 0x10000)
+
+	var buf bytes.Buffer
+	s.NoError(s.cov.WritePNG(&buf))
+
+	img, err := png.Decode(&buf)
+	s.NoError(err)
+
+	// 0xC0 pages of main, then 0x3F of rom, with a gap after each
+	s.Equal(pageSize, img.Bounds().Dx())
+	s.Equal(0xC0+0x3F+2*gapRows, img.Bounds().Dy())
+
+	_, g, _, _ := img.At(0x01, 0x08).RGBA()
+	s.NotZero(g)
+
+	_, g, _, _ = img.At(0x02, 0x08).RGBA()
+	s.Zero(g)
+}
@@ -0,0 +1,75 @@
+package a2coverage
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// pageSize is the number of bytes we show in each row of the heatmap.
+const pageSize = 256
+
+// gapRows is the number of blank rows we leave between regions.
+const gapRows = 2
+
+// WritePNG writes a heatmap of the map to w as a PNG image. Each row of the
+// image is a page of memory (256 bytes), and the regions are stacked from
+// top to bottom, in the order that Regions gives them. Executed bytes are
+// shown in green, read bytes in blue and written bytes in red; the brighter
+// the color, the more often the byte was accessed.
+func (m *Map) WritePNG(w io.Writer) error {
+	regions := m.Regions()
+
+	height := 0
+	for _, r := range regions {
+		height += pages(r) + gapRows
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, pageSize, max(height, 1)))
+
+	row := 0
+	for _, r := range regions {
+		// The brightness of each byte is relative to the busiest byte in
+		// the region
+		var most [numKinds]uint32
+		for k := range numKinds {
+			for _, n := range r.counts[k] {
+				most[k] = max(most[k], n)
+			}
+		}
+
+		for i := range r.End - r.Start {
+			img.Set(i%pageSize, row+i/pageSize, color.RGBA{
+				R: brightness(r.counts[Write][i], most[Write]),
+				G: brightness(r.counts[Exec][i], most[Exec]),
+				B: brightness(r.counts[Read][i], most[Read]),
+				A: 0xFF,
+			})
+		}
+
+		row += pages(r) + gapRows
+	}
+
+	return png.Encode(w, img)
+}
+
+// pages returns the number of rows that a region needs in the heatmap.
+func pages(r *Region) int {
+	return (r.End - r.Start + pageSize - 1) / pageSize
+}
+
+// brightness returns how bright a byte accessed n times should be, where the
+// busiest byte was accessed most times. We use a log scale, since a loop can
+// easily run thousands of times more than the code around it; anything that
+// was accessed at all is at least a little bright.
+func brightness(n, most uint32) uint8 {
+	if n == 0 || most == 0 {
+		return 0
+	}
+
+	scale := math.Log1p(float64(n)) / math.Log1p(float64(most))
+
+	return uint8(64 + scale*191)
+}
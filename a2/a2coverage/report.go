@@ -0,0 +1,65 @@
+package a2coverage
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteReport writes a summary of the map to w. For each region, we write
+// how much of it was covered, followed by each range of addresses that were
+// executed, read or written. For example:
+//
+//	# main $0000-$BFFF: 812 exec, 1530 read, 2011 write of 49152 bytes
+//	exec main $0800-$08FF
+//	read main $0300-$0301
+func (m *Map) WriteReport(w io.Writer) error {
+	for _, r := range m.Regions() {
+		_, err := fmt.Fprintf(
+			w, "# %s $%04X-$%04X: %d exec, %d read, %d write of %d bytes\n",
+			r.Name, r.Start, r.End-1,
+			r.Covered(Exec), r.Covered(Read), r.Covered(Write),
+			r.End-r.Start,
+		)
+		if err != nil {
+			return err
+		}
+
+		for kind := range numKinds {
+			for _, span := range r.spans(kind) {
+				_, err := fmt.Fprintf(
+					w, "%s %s $%04X-$%04X\n", kind, r.Name, span[0], span[1],
+				)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// spans returns the first and last address of each run of addresses that
+// were accessed in the given way.
+func (r *Region) spans(kind Kind) [][2]int {
+	var (
+		spans [][2]int
+		start = -1
+	)
+
+	for i, n := range r.counts[kind] {
+		switch {
+		case n > 0 && start < 0:
+			start = i
+		case n == 0 && start >= 0:
+			spans = append(spans, [2]int{r.Start + start, r.Start + i - 1})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		spans = append(spans, [2]int{r.Start + start, r.End - 1})
+	}
+
+	return spans
+}
@@ -61,7 +61,10 @@ func readWrite(addr int, val *uint8, stm *memory.StateMap) {
 	case 0x0, 0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7:
 		if !debugging {
 			c.SelectedDrive().SwitchPhase(int(nib))
-			metrics.Increment(fmt.Sprintf("disk_switch_phase_%01x", nib), 1)
+
+			if metrics.Enabled() {
+				metrics.Increment(fmt.Sprintf("disk_switch_phase_%01x", nib), 1)
+			}
 		}
 
 		*val = c.SelectedDrive().RandomByte()
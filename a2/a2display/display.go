@@ -1,6 +1,7 @@
 package a2display
 
 import (
+	"github.com/pevans/erc/a2/a2annunciator"
 	"github.com/pevans/erc/a2/a2dhires"
 	"github.com/pevans/erc/a2/a2hires"
 	"github.com/pevans/erc/a2/a2lores"
@@ -159,6 +160,10 @@ func onOrOffReadWrite(addr int, stm *memory.StateMap) bool {
 	case OnDHires:
 		metrics.Increment("soft_display_dhires_on", 1)
 		stm.SetBool(a2state.DisplayDoubleHigh, true)
+
+		// Double hi-res is what you get when AN3 is off
+		a2annunciator.Set(3, false, stm)
+
 		comp := stm.Any(a2state.Computer).(ComputerState)
 		gfx.Screen = comp.GetScreen()
 		stm.SetBool(a2state.DisplayRedraw, true)
@@ -186,6 +191,7 @@ func onOrOffReadWrite(addr int, stm *memory.StateMap) bool {
 	case OffDHires:
 		metrics.Increment("soft_display_dhires_off", 1)
 		stm.SetBool(a2state.DisplayDoubleHigh, false)
+		a2annunciator.Set(3, true, stm)
 		stm.SetBool(a2state.DisplayRedraw, true)
 		return true
 	}
@@ -295,7 +301,9 @@ func SwitchWrite(addr int, val uint8, stm *memory.StateMap) {
 }
 
 // Segment returns the appropriate memory segment for the given address based
-// on display state (80STORE, page switching, etc).
+// on display state (80STORE, page switching, etc). Note that the display
+// pages in auxiliary memory only live in the first bank, so that is what
+// DisplayAuxSegment holds no matter which aux bank is currently selected.
 func Segment(
 	addr int,
 	stm *memory.StateMap,
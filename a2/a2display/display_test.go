@@ -153,6 +153,14 @@ func (s *displaySuite) TestDisplaySwitcherSwitchWrite() {
 		// doubleHigh is cleared regardless of IOUDIS state
 		off(a2state.DisplayDoubleHigh, OffDHires)
 	})
+
+	s.Run("double hi-res follows AN3", func() {
+		SwitchWrite(OnDHires, 0x0, s.state)
+		s.False(s.state.Bool(a2state.Annunciator3))
+
+		SwitchWrite(OffDHires, 0x0, s.state)
+		s.True(s.state.Bool(a2state.Annunciator3))
+	})
 }
 
 func (s *displaySuite) TestDisplaySegment() {
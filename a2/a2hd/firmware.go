@@ -0,0 +1,97 @@
+package a2hd
+
+const (
+	// driverEntry is the offset into the slot page of the ProDOS driver. The
+	// SmartPort entry point always follows three bytes later.
+	driverEntry = 0x40
+
+	// statusByte describes the device to ProDOS: it has two volumes, isn't
+	// removable, doesn't use interrupts, and supports status, read, write
+	// and format calls.
+	statusByte = 0x1F
+
+	// bootBuffer is where the boot block is loaded, and bootEntry is where
+	// we jump after it's loaded.
+	bootBuffer = 0x0800
+	bootEntry  = 0x0801
+
+	// slotScan is the point in the autostart ROM that resumes the search
+	// for a bootable slot (starting from the one below ours).
+	slotScan = 0xFABA
+)
+
+// Firmware returns the 256 bytes of slot ROM for the block device. The first
+// part boots from the first volume; the rest hands off calls to the driver
+// (and the SmartPort interface) to the card's I/O space.
+func Firmware() []uint8 {
+	var (
+		rom  = make([]uint8, 0x100)
+		page = uint8(0xC0 + Slot)
+		unit = uint8(Slot << 4)
+		lo   = func(addr int) uint8 { return uint8(addr & 0xFF) }
+		hi   = func(addr int) uint8 { return uint8(addr >> 8) }
+	)
+
+	copy(rom, []uint8{
+		// $00: the odd bytes here are what the autostart ROM and ProDOS look
+		// for to identify a block device; $Cn07 being zero marks it as
+		// SmartPort
+		0xA2, 0x20, // LDX #$20
+		0xA0, 0x00, // LDY #$00
+		0xA2, 0x03, // LDX #$03
+		0xC9, 0x00, // CMP #$00
+
+		// $08: read block 0 of the first volume into the boot buffer
+		0xA9, cmdRead, // LDA #$01
+		0x85, zpCommand, // STA $42
+		0xA9, unit, // LDA #$n0
+		0x85, zpUnit, // STA $43
+		0xA9, 0x00, // LDA #$00
+		0x85, zpBuffer, // STA $44
+		0x85, zpBlock, // STA $46
+		0x85, zpBlock + 1, // STA $47
+		0xA9, hi(bootBuffer), // LDA #$08
+		0x85, zpBuffer + 1, // STA $45
+		0x20, driverEntry, page, // JSR $Cn40
+		0xB0, 0x05, // BCS $26
+
+		// $21: the boot block expects the slot number (times 16) in X
+		0xA2, unit, // LDX #$n0
+		0x4C, lo(bootEntry), hi(bootEntry), // JMP $0801
+
+		// $26: we couldn't boot, so let another slot try
+		0x4C, lo(slotScan), hi(slotScan), // JMP $FABA
+	})
+
+	copy(rom[driverEntry:], []uint8{
+		// $40: ProDOS driver entry point
+		0x4C, 0x50, page, // JMP $Cn50
+
+		// $43: SmartPort entry point
+		0xBA,                                     // TSX
+		0x8E, lo(stackPointer), hi(stackPointer), // STX stackPointer
+		0x2C, lo(execSmartPort), hi(execSmartPort), // BIT execSmartPort
+		0x4C, 0x53, page, // JMP $Cn53
+	})
+
+	copy(rom[0x50:], []uint8{
+		// $50: carry out a ProDOS call
+		0x2C, lo(execProDOS), hi(execProDOS), // BIT execProDOS
+
+		// $53: return the result, with the carry set if there was an error
+		0xAE, lo(resultX), hi(resultX), // LDX resultX
+		0xAC, lo(resultY), hi(resultY), // LDY resultY
+		0xAD, lo(resultCode), hi(resultCode), // LDA resultCode
+		0xC9, 0x01, // CMP #$01
+		0x60, // RTS
+	})
+
+	// $CnFC-$CnFD is the number of blocks, but zero tells ProDOS to make a
+	// status call to find out
+	rom[0xFC] = 0x00
+	rom[0xFD] = 0x00
+	rom[0xFE] = statusByte
+	rom[0xFF] = driverEntry
+
+	return rom
+}
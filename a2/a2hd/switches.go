@@ -0,0 +1,406 @@
+package a2hd
+
+import (
+	"errors"
+
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/internal/metrics"
+	"github.com/pevans/erc/memory"
+)
+
+const (
+	// Slot is the expansion slot where our block device card is installed.
+	// The autostart ROM scans for a bootable device starting from slot 7,
+	// which means we'll boot from the hard disk before any floppy in slot 6.
+	Slot = 7
+
+	// Volumes is the number of volumes the card can hold.
+	Volumes = 2
+
+	// ioBase is the first address of the device I/O space for our slot,
+	// which is $C080 + $10 * slot.
+	ioBase = 0xC080 + (Slot << 4)
+
+	// Reading execProDOS or execSmartPort carries out a call to the driver;
+	// the outcome of the call can then be read from resultCode, resultX and
+	// resultY. Before a SmartPort call, the firmware writes the stack
+	// pointer to stackPointer so we can find the call's parameters.
+	execProDOS    = ioBase + 0x0
+	resultCode    = ioBase + 0x1
+	resultX       = ioBase + 0x2
+	resultY       = ioBase + 0x3
+	execSmartPort = ioBase + 0x4
+	stackPointer  = ioBase + 0x5
+)
+
+// These are the locations in the zero page where ProDOS puts the parameters
+// for a call to a block device driver.
+const (
+	zpCommand = 0x42
+	zpUnit    = 0x43
+	zpBuffer  = 0x44
+	zpBlock   = 0x46
+)
+
+// Commands that may be given to a block device driver. The SmartPort
+// commands we support share their numbers with the ProDOS commands.
+const (
+	cmdStatus  = 0x00
+	cmdRead    = 0x01
+	cmdWrite   = 0x02
+	cmdFormat  = 0x03
+	cmdControl = 0x04
+	cmdInit    = 0x05
+)
+
+// Error codes that we can return from a call.
+const (
+	errNone       = 0x00
+	errBadCommand = 0x01
+	errBadPCount  = 0x04
+	errBadUnit    = 0x11
+	errBadControl = 0x21
+	errIO         = 0x27
+	errNoDevice   = 0x28
+	errNoWrite    = 0x2B
+	errBadBlock   = 0x2D
+	errOffline    = 0x2F
+)
+
+// Computer is an interface for the parts of the computer that the block
+// device needs to carry out a call.
+type Computer interface {
+	Get(addr int) uint8
+	Set(addr int, val uint8)
+	HardDisk(n int) *Volume
+}
+
+// ReadSwitches returns the list of block device switch addresses that
+// support reads.
+func ReadSwitches() []int {
+	return []int{
+		execProDOS,
+		resultCode,
+		resultX,
+		resultY,
+		execSmartPort,
+	}
+}
+
+// WriteSwitches returns the list of block device switch addresses that
+// support writes.
+func WriteSwitches() []int {
+	return []int{
+		stackPointer,
+	}
+}
+
+// UseDefaults sets the block device to the state it would have on power-up.
+func UseDefaults(stm *memory.StateMap) {
+	stm.SetUint8(a2state.HDResult, errNone)
+	stm.SetUint8(a2state.HDResultX, 0)
+	stm.SetUint8(a2state.HDResultY, 0)
+	stm.SetUint8(a2state.HDStackPointer, 0)
+}
+
+// SwitchRead handles reads from the block device's I/O space.
+func SwitchRead(addr int, stm *memory.StateMap) uint8 {
+	switch addr {
+	case resultCode:
+		return stm.Uint8(a2state.HDResult)
+	case resultX:
+		return stm.Uint8(a2state.HDResultX)
+	case resultY:
+		return stm.Uint8(a2state.HDResultY)
+	}
+
+	// We definitely don't want to read or write any blocks if the debugger
+	// is just looking ahead
+	if stm.Bool(a2state.DebuggerLookAhead) {
+		return 0
+	}
+
+	c := stm.Any(a2state.Computer).(Computer)
+
+	switch addr {
+	case execProDOS:
+		metrics.Increment("soft_hd_prodos_call", 1)
+		prodosCall(c, stm)
+	case execSmartPort:
+		metrics.Increment("soft_hd_smartport_call", 1)
+		smartPortCall(c, stm)
+	}
+
+	return 0
+}
+
+// SwitchWrite handles writes to the block device's I/O space.
+func SwitchWrite(addr int, val uint8, stm *memory.StateMap) {
+	if addr == stackPointer {
+		stm.SetUint8(a2state.HDStackPointer, val)
+	}
+}
+
+// setResult records the outcome of a call, which the firmware will return to
+// the caller in the A, X and Y registers.
+func setResult(stm *memory.StateMap, code uint8, count int) {
+	stm.SetUint8(a2state.HDResult, code)
+	stm.SetUint8(a2state.HDResultX, uint8(count))
+	stm.SetUint8(a2state.HDResultY, uint8(count>>8))
+}
+
+func get16(c Computer, addr int) int {
+	return int(c.Get(addr)) | int(c.Get(addr+1))<<8
+}
+
+func get24(c Computer, addr int) int {
+	return get16(c, addr) | int(c.Get(addr+2))<<16
+}
+
+// prodosCall carries out a call made through the ProDOS block driver
+// interface, with parameters in the zero page.
+func prodosCall(c Computer, stm *memory.StateMap) {
+	var (
+		unit   = c.Get(zpUnit)
+		buffer = get16(c, zpBuffer)
+		block  = get16(c, zpBlock)
+
+		// Bit 7 of the unit number is the drive, which is 0 for the first
+		// and 1 for the second.
+		vol = c.HardDisk(int(unit>>7) + 1)
+	)
+
+	if !vol.Loaded() {
+		setResult(stm, errNoDevice, 0)
+		return
+	}
+
+	switch c.Get(zpCommand) {
+	case cmdStatus:
+		if vol.WriteProtected() {
+			setResult(stm, errNoWrite, vol.Blocks())
+			return
+		}
+
+		setResult(stm, errNone, vol.Blocks())
+
+	case cmdRead:
+		setResult(stm, readBlock(c, vol, block, buffer, errIO), 0)
+
+	case cmdWrite:
+		setResult(stm, writeBlock(c, vol, block, buffer, errIO), 0)
+
+	case cmdFormat:
+		if vol.WriteProtected() {
+			setResult(stm, errNoWrite, 0)
+			return
+		}
+
+		setResult(stm, errNone, 0)
+
+	default:
+		setResult(stm, errIO, 0)
+	}
+}
+
+// smartPortCall carries out a call made through the SmartPort interface.
+// The caller follows its JSR with a command byte and a pointer to a
+// parameter list, and we have to adjust the return address so that the
+// caller will resume after those.
+func smartPortCall(c Computer, stm *memory.StateMap) {
+	var (
+		sp      = 0x100 + int(stm.Uint8(a2state.HDStackPointer))
+		retAddr = get16(c, sp+1)
+		command = c.Get(retAddr + 1)
+		params  = get16(c, retAddr+2)
+		skip    = 3
+	)
+
+	// Extended calls use a 4-byte parameter list pointer, which we don't
+	// support, but we still need to skip past it.
+	if command&0x40 > 0 {
+		skip = 5
+	}
+
+	retAddr += skip
+	c.Set(sp+1, uint8(retAddr))
+	c.Set(sp+2, uint8(retAddr>>8))
+
+	if command&0x40 > 0 {
+		setResult(stm, errBadCommand, 0)
+		return
+	}
+
+	var (
+		pcount = c.Get(params)
+		unit   = int(c.Get(params + 1))
+	)
+
+	expected := map[uint8]uint8{
+		cmdStatus:  3,
+		cmdRead:    3,
+		cmdWrite:   3,
+		cmdFormat:  1,
+		cmdControl: 3,
+		cmdInit:    1,
+	}
+
+	want, ok := expected[command]
+	if !ok {
+		setResult(stm, errBadCommand, 0)
+		return
+	}
+
+	if pcount != want {
+		setResult(stm, errBadPCount, 0)
+		return
+	}
+
+	switch command {
+	case cmdStatus:
+		smartPortStatus(c, stm, unit, get16(c, params+2), c.Get(params+4))
+		return
+	case cmdInit:
+		setResult(stm, errNone, 0)
+		return
+	}
+
+	if unit < 1 || unit > Volumes {
+		setResult(stm, errBadUnit, 0)
+		return
+	}
+
+	vol := c.HardDisk(unit)
+	if !vol.Loaded() {
+		setResult(stm, errOffline, 0)
+		return
+	}
+
+	switch command {
+	case cmdRead:
+		code := readBlock(c, vol, get24(c, params+4), get16(c, params+2), errBadBlock)
+		setResult(stm, code, 0)
+
+	case cmdWrite:
+		code := writeBlock(c, vol, get24(c, params+4), get16(c, params+2), errBadBlock)
+		setResult(stm, code, 0)
+
+	case cmdFormat:
+		if vol.WriteProtected() {
+			setResult(stm, errNoWrite, 0)
+			return
+		}
+
+		setResult(stm, errNone, 0)
+
+	case cmdControl:
+		setResult(stm, errNone, 0)
+	}
+}
+
+// smartPortStatus returns status information for the given unit into the
+// status list. Unit 0 refers to the SmartPort interface itself.
+func smartPortStatus(c Computer, stm *memory.StateMap, unit, list int, code uint8) {
+	var out []uint8
+
+	switch {
+	case unit == 0 && code == 0x00:
+		// Number of devices, then no interrupt, then vendor and version
+		// fields that we leave empty
+		out = []uint8{Volumes, 0x40, 0, 0, 0, 0, 0, 0}
+
+	case unit < 1 || unit > Volumes:
+		setResult(stm, errBadUnit, 0)
+		return
+
+	case code == 0x00:
+		out = unitStatus(c.HardDisk(unit))
+
+	case code == 0x03:
+		out = unitStatus(c.HardDisk(unit))
+
+		name := []uint8("ERC HARD DISK   ")
+		out = append(out, uint8(len("ERC HARD DISK")))
+		out = append(out, name...)
+
+		// Device type ($02 is a hard disk), subtype, and firmware version
+		out = append(out, 0x02, 0x00, 0x01, 0x00)
+
+	default:
+		setResult(stm, errBadControl, 0)
+		return
+	}
+
+	for i, b := range out {
+		c.Set(list+i, b)
+	}
+
+	setResult(stm, errNone, len(out))
+}
+
+// unitStatus returns the general status bytes for a volume, which are a
+// status byte followed by the number of blocks in three bytes.
+func unitStatus(vol *Volume) []uint8 {
+	// We are a block device, which can read and format
+	status := uint8(0x80 | 0x20 | 0x08)
+
+	if vol.Loaded() {
+		status |= 0x10
+	}
+
+	if vol.WriteProtected() {
+		status |= 0x04
+	} else {
+		status |= 0x40
+	}
+
+	blocks := vol.Blocks()
+
+	return []uint8{status, uint8(blocks), uint8(blocks >> 8), uint8(blocks >> 16)}
+}
+
+// readBlock reads a block from the volume into memory at buffer, returning
+// an error code (or errNone). badBlock is the code to use if the block
+// number is out of range, since ProDOS and SmartPort disagree on that.
+func readBlock(c Computer, vol *Volume, block, buffer int, badBlock uint8) uint8 {
+	buf := make([]uint8, BlockSize)
+
+	if err := vol.ReadBlock(block, buf); err != nil {
+		return errorCode(err, badBlock)
+	}
+
+	for i, b := range buf {
+		c.Set(buffer+i, b)
+	}
+
+	return errNone
+}
+
+// writeBlock writes the BlockSize bytes at buffer into a block on the
+// volume, returning an error code (or errNone).
+func writeBlock(c Computer, vol *Volume, block, buffer int, badBlock uint8) uint8 {
+	buf := make([]uint8, BlockSize)
+
+	for i := range buf {
+		buf[i] = c.Get(buffer + i)
+	}
+
+	if err := vol.WriteBlock(block, buf); err != nil {
+		return errorCode(err, badBlock)
+	}
+
+	return errNone
+}
+
+func errorCode(err error, badBlock uint8) uint8 {
+	switch {
+	case errors.Is(err, ErrNoVolume):
+		return errNoDevice
+	case errors.Is(err, ErrWriteProtected):
+		return errNoWrite
+	case errors.Is(err, ErrBadBlock):
+		return badBlock
+	}
+
+	return errIO
+}
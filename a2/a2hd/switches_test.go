@@ -0,0 +1,211 @@
+package a2hd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/memory"
+	"github.com/stretchr/testify/suite"
+)
+
+type hdSuite struct {
+	suite.Suite
+
+	state *memory.StateMap
+	comp  *mockComputer
+}
+
+type mockComputer struct {
+	mem [0x10000]uint8
+	hd1 *Volume
+	hd2 *Volume
+}
+
+func (m *mockComputer) Get(addr int) uint8      { return m.mem[addr] }
+func (m *mockComputer) Set(addr int, val uint8) { m.mem[addr] = val }
+
+func (m *mockComputer) HardDisk(n int) *Volume {
+	if n == 1 {
+		return m.hd1
+	}
+
+	return m.hd2
+}
+
+func (s *hdSuite) SetupTest() {
+	s.comp = &mockComputer{hd1: NewVolume(), hd2: NewVolume()}
+	s.Require().NoError(s.comp.hd1.Load(bytes.NewReader(blocks(16)), "hd.hdv"))
+
+	s.state = memory.NewStateMap()
+	s.state.SetAny(a2state.Computer, s.comp)
+	UseDefaults(s.state)
+}
+
+func TestHDSuite(t *testing.T) {
+	suite.Run(t, new(hdSuite))
+}
+
+// prodos makes a ProDOS driver call and returns the result code.
+func (s *hdSuite) prodos(command, unit uint8, buffer, block int) uint8 {
+	s.comp.mem[zpCommand] = command
+	s.comp.mem[zpUnit] = unit
+	s.comp.mem[zpBuffer] = uint8(buffer)
+	s.comp.mem[zpBuffer+1] = uint8(buffer >> 8)
+	s.comp.mem[zpBlock] = uint8(block)
+	s.comp.mem[zpBlock+1] = uint8(block >> 8)
+
+	SwitchRead(execProDOS, s.state)
+
+	return SwitchRead(resultCode, s.state)
+}
+
+// smartPort makes a SmartPort call, as if from a JSR at $0300, and returns
+// the result code.
+func (s *hdSuite) smartPort(command uint8, params ...uint8) uint8 {
+	const (
+		caller    = 0x0300
+		paramList = 0x0380
+	)
+
+	// JSR pushes the address of its last byte
+	s.comp.mem[0x1FE] = uint8((caller + 2) & 0xFF)
+	s.comp.mem[0x1FF] = uint8((caller + 2) >> 8)
+	SwitchWrite(stackPointer, 0xFD, s.state)
+
+	s.comp.mem[caller+3] = command
+	s.comp.mem[caller+4] = uint8(paramList & 0xFF)
+	s.comp.mem[caller+5] = uint8(paramList >> 8)
+	copy(s.comp.mem[paramList:], params)
+
+	SwitchRead(execSmartPort, s.state)
+
+	// The return address should now skip over the command and parameter
+	// list pointer
+	s.Equal(uint8((caller+5)&0xFF), s.comp.mem[0x1FE])
+
+	return SwitchRead(resultCode, s.state)
+}
+
+func (s *hdSuite) result() int {
+	return int(SwitchRead(resultX, s.state)) | int(SwitchRead(resultY, s.state))<<8
+}
+
+func (s *hdSuite) TestProDOSStatus() {
+	s.Equal(uint8(errNone), s.prodos(cmdStatus, Slot<<4, 0, 0))
+	s.Equal(16, s.result())
+
+	s.comp.hd1.SetWriteProtect(true)
+	s.Equal(uint8(errNoWrite), s.prodos(cmdStatus, Slot<<4, 0, 0))
+
+	// Drive 2 has nothing in it
+	s.Equal(uint8(errNoDevice), s.prodos(cmdStatus, 0x80|Slot<<4, 0, 0))
+}
+
+func (s *hdSuite) TestProDOSReadWrite() {
+	s.Equal(uint8(errNone), s.prodos(cmdRead, Slot<<4, 0x2000, 5))
+	s.Equal(uint8(5), s.comp.mem[0x2000])
+	s.Equal(uint8(5), s.comp.mem[0x21FF])
+
+	s.comp.mem[0x4000] = 0xEE
+	s.Equal(uint8(errNone), s.prodos(cmdWrite, Slot<<4, 0x4000, 3))
+
+	buf := make([]uint8, BlockSize)
+	s.NoError(s.comp.hd1.ReadBlock(3, buf))
+	s.Equal(uint8(0xEE), buf[0])
+
+	s.Equal(uint8(errIO), s.prodos(cmdRead, Slot<<4, 0x2000, 16))
+
+	s.comp.hd1.SetWriteProtect(true)
+	s.Equal(uint8(errNoWrite), s.prodos(cmdWrite, Slot<<4, 0x4000, 3))
+}
+
+func (s *hdSuite) TestSmartPortStatus() {
+	s.Run("interface status", func() {
+		s.Equal(uint8(errNone), s.smartPort(cmdStatus, 3, 0, 0x00, 0x20, 0x00))
+		s.Equal(uint8(Volumes), s.comp.mem[0x2000])
+		s.Equal(8, s.result())
+	})
+
+	s.Run("unit status", func() {
+		s.Equal(uint8(errNone), s.smartPort(cmdStatus, 3, 1, 0x00, 0x20, 0x00))
+		s.Equal(uint8(0xF8), s.comp.mem[0x2000])
+		s.Equal(uint8(16), s.comp.mem[0x2001])
+		s.Equal(4, s.result())
+	})
+
+	s.Run("device information block", func() {
+		s.Equal(uint8(errNone), s.smartPort(cmdStatus, 3, 1, 0x00, 0x20, 0x03))
+		s.Equal(25, s.result())
+		s.Equal(uint8(0x02), s.comp.mem[0x2000+21])
+	})
+
+	s.Run("offline unit", func() {
+		s.Equal(uint8(errNone), s.smartPort(cmdStatus, 3, 2, 0x00, 0x20, 0x00))
+		s.Equal(uint8(0xE8), s.comp.mem[0x2000])
+	})
+
+	s.Run("bad unit", func() {
+		s.Equal(uint8(errBadUnit), s.smartPort(cmdStatus, 3, 3, 0x00, 0x20, 0x00))
+	})
+
+	s.Run("bad status code", func() {
+		s.Equal(uint8(errBadControl), s.smartPort(cmdStatus, 3, 1, 0x00, 0x20, 0x09))
+	})
+}
+
+func (s *hdSuite) TestSmartPortReadWrite() {
+	s.Equal(uint8(errNone), s.smartPort(cmdRead, 3, 1, 0x00, 0x20, 9, 0, 0))
+	s.Equal(uint8(9), s.comp.mem[0x2000])
+
+	s.comp.mem[0x4000] = 0xEE
+	s.Equal(uint8(errNone), s.smartPort(cmdWrite, 3, 1, 0x00, 0x40, 2, 0, 0))
+
+	buf := make([]uint8, BlockSize)
+	s.NoError(s.comp.hd1.ReadBlock(2, buf))
+	s.Equal(uint8(0xEE), buf[0])
+
+	s.Equal(uint8(errBadBlock), s.smartPort(cmdRead, 3, 1, 0x00, 0x20, 0, 0, 1))
+	s.Equal(uint8(errOffline), s.smartPort(cmdRead, 3, 2, 0x00, 0x20, 0, 0, 0))
+	s.Equal(uint8(errBadPCount), s.smartPort(cmdRead, 2, 1, 0x00, 0x20, 0, 0, 0))
+	s.Equal(uint8(errBadCommand), s.smartPort(0x08, 4, 1, 0x00, 0x20, 0, 0, 0))
+}
+
+func (s *hdSuite) TestSmartPortExtended() {
+	s.comp.mem[0x1FE] = 0x02
+	s.comp.mem[0x1FF] = 0x03
+	SwitchWrite(stackPointer, 0xFD, s.state)
+	s.comp.mem[0x303] = cmdRead | 0x40
+
+	SwitchRead(execSmartPort, s.state)
+
+	s.Equal(uint8(0x07), s.comp.mem[0x1FE])
+	s.Equal(uint8(errBadCommand), SwitchRead(resultCode, s.state))
+}
+
+func (s *hdSuite) TestLookAhead() {
+	s.state.SetBool(a2state.DebuggerLookAhead, true)
+	defer s.state.SetBool(a2state.DebuggerLookAhead, false)
+
+	s.comp.mem[zpCommand] = cmdRead
+	s.comp.mem[zpBuffer+1] = 0x20
+	s.comp.mem[zpBlock] = 1
+
+	SwitchRead(execProDOS, s.state)
+	s.Equal(uint8(0), s.comp.mem[0x2000])
+}
+
+func (s *hdSuite) TestFirmware() {
+	rom := Firmware()
+
+	s.Len(rom, 0x100)
+
+	// These identify a ProDOS block device with a SmartPort interface
+	s.Equal(uint8(0x20), rom[0x01])
+	s.Equal(uint8(0x00), rom[0x03])
+	s.Equal(uint8(0x03), rom[0x05])
+	s.Equal(uint8(0x00), rom[0x07])
+
+	s.Equal(uint8(statusByte), rom[0xFE])
+	s.Equal(uint8(driverEntry), rom[0xFF])
+}
@@ -0,0 +1,261 @@
+package a2hd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pevans/erc/memory"
+)
+
+const (
+	// BlockSize is the number of bytes in a ProDOS block.
+	BlockSize = 512
+
+	// MaxBlocks is the largest number of blocks that a ProDOS volume may
+	// have, which makes for a volume just shy of 32MB.
+	MaxBlocks = 0xFFFF
+
+	// The 2IMG format has a 64-byte header in front of the data. We only
+	// pay attention to the handful of fields that we need.
+	twoIMGHeaderSize   = 64
+	twoIMGMagic        = "2IMG"
+	twoIMGFormatProDOS = 1
+	twoIMGFlagLocked   = 0x80000000
+)
+
+var (
+	// ErrNoVolume is returned when there is no image loaded.
+	ErrNoVolume = errors.New("no volume loaded")
+
+	// ErrWriteProtected is returned when writing to a volume that is
+	// write-protected.
+	ErrWriteProtected = errors.New("volume is write-protected")
+
+	// ErrBadBlock is returned for a block number that is past the end of
+	// the volume.
+	ErrBadBlock = errors.New("block number out of range")
+)
+
+// A Volume is a ProDOS block device, like a hard disk, that is backed by an
+// image file.
+type Volume struct {
+	// data holds the blocks of the volume, in ProDOS order.
+	data *memory.Segment
+
+	// prefix and suffix are whatever bytes surrounded the block data in the
+	// image file (e.g. a 2IMG header). We hold onto them so that we can
+	// write the file back the way we found it.
+	prefix []uint8
+	suffix []uint8
+
+	// imageName is the name of the image file loaded in the volume.
+	imageName string
+
+	// writeProtect is true when the volume may not be written to.
+	writeProtect bool
+
+	// dirty is true if any block has been written since the volume was
+	// loaded or last saved.
+	dirty bool
+}
+
+// NewVolume returns a new volume with nothing loaded.
+func NewVolume() *Volume {
+	return new(Volume)
+}
+
+// IsImage returns true if the suffix of the given filename is one we would
+// load as a volume.
+func IsImage(file string) bool {
+	lower := strings.ToLower(file)
+
+	return strings.HasSuffix(lower, ".po") ||
+		strings.HasSuffix(lower, ".hdv") ||
+		strings.HasSuffix(lower, ".2mg")
+}
+
+// Load reads the image in r and makes it the contents of the volume. The
+// filename is used to decide what kind of image it is, and is where we will
+// write the image back to when the volume is saved.
+func (v *Volume) Load(r io.Reader, file string) error {
+	if !IsImage(file) {
+		return fmt.Errorf("unrecognized suffix for file %s", file)
+	}
+
+	bytes, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", file, err)
+	}
+
+	var (
+		start  = 0
+		end    = len(bytes)
+		locked = false
+	)
+
+	if strings.HasSuffix(strings.ToLower(file), ".2mg") {
+		start, end, locked, err = parse2IMG(bytes)
+		if err != nil {
+			return fmt.Errorf("invalid 2mg file %s: %w", file, err)
+		}
+	}
+
+	size := end - start
+	if size == 0 || size%BlockSize != 0 || size/BlockSize > MaxBlocks {
+		return fmt.Errorf(
+			"invalid %s volume size: got %d bytes, expected a multiple of %d up to %d bytes",
+			file, size, BlockSize, MaxBlocks*BlockSize,
+		)
+	}
+
+	v.data = memory.NewSegment(size)
+	if _, err := v.data.CopySlice(0, bytes[start:end]); err != nil {
+		v.data = nil
+		return fmt.Errorf("failed to copy bytes into volume: %w", err)
+	}
+
+	v.prefix = bytes[:start]
+	v.suffix = bytes[end:]
+	v.imageName = file
+	v.writeProtect = locked
+	v.dirty = false
+
+	return nil
+}
+
+// parse2IMG returns the start and end of the block data within the given
+// 2IMG file, and whether the image is marked as locked.
+func parse2IMG(bytes []uint8) (start, end int, locked bool, err error) {
+	if len(bytes) < twoIMGHeaderSize || string(bytes[0:4]) != twoIMGMagic {
+		return 0, 0, false, errors.New("missing 2IMG header")
+	}
+
+	var (
+		format     = binary.LittleEndian.Uint32(bytes[12:])
+		flags      = binary.LittleEndian.Uint32(bytes[16:])
+		blocks     = binary.LittleEndian.Uint32(bytes[20:])
+		dataOffset = binary.LittleEndian.Uint32(bytes[24:])
+		dataLength = binary.LittleEndian.Uint32(bytes[28:])
+	)
+
+	if format != twoIMGFormatProDOS {
+		return 0, 0, false, fmt.Errorf("unsupported image format %d", format)
+	}
+
+	// Some tools leave the data length empty and only fill in the number
+	// of blocks.
+	if dataLength == 0 {
+		dataLength = blocks * BlockSize
+	}
+
+	start = int(dataOffset)
+	end = start + int(dataLength)
+
+	if start < twoIMGHeaderSize || end > len(bytes) {
+		return 0, 0, false, errors.New("data extends past the end of the file")
+	}
+
+	return start, end, flags&twoIMGFlagLocked > 0, nil
+}
+
+// Save writes the volume back to the file it was loaded from, if any of its
+// blocks have changed.
+func (v *Volume) Save() error {
+	if v.imageName == "" || v.data == nil || !v.dirty {
+		return nil
+	}
+
+	bytes := make([]uint8, 0, len(v.prefix)+v.data.Size()+len(v.suffix))
+	bytes = append(bytes, v.prefix...)
+	bytes = append(bytes, v.data.Bytes()...)
+	bytes = append(bytes, v.suffix...)
+
+	if err := os.WriteFile(v.imageName, bytes, 0o644); err != nil {
+		return fmt.Errorf("could not write volume %s: %w", v.imageName, err)
+	}
+
+	v.dirty = false
+
+	return nil
+}
+
+// Loaded returns true if an image is loaded in the volume.
+func (v *Volume) Loaded() bool {
+	return v.data != nil
+}
+
+// ImageName returns the name of the image file loaded in the volume.
+func (v *Volume) ImageName() string {
+	return v.imageName
+}
+
+// Blocks returns the number of blocks in the volume.
+func (v *Volume) Blocks() int {
+	if v.data == nil {
+		return 0
+	}
+
+	return v.data.Size() / BlockSize
+}
+
+// ReadBlock copies the given block into buf, which must be at least
+// BlockSize bytes long.
+func (v *Volume) ReadBlock(block int, buf []uint8) error {
+	if v.data == nil {
+		return ErrNoVolume
+	}
+
+	if block < 0 || block >= v.Blocks() {
+		return ErrBadBlock
+	}
+
+	for i := range BlockSize {
+		buf[i] = v.data.DirectGet(block*BlockSize + i)
+	}
+
+	return nil
+}
+
+// WriteBlock copies buf, which must be at least BlockSize bytes long, into
+// the given block.
+func (v *Volume) WriteBlock(block int, buf []uint8) error {
+	if v.data == nil {
+		return ErrNoVolume
+	}
+
+	if v.writeProtect {
+		return ErrWriteProtected
+	}
+
+	if block < 0 || block >= v.Blocks() {
+		return ErrBadBlock
+	}
+
+	for i := range BlockSize {
+		v.data.DirectSet(block*BlockSize+i, buf[i])
+	}
+
+	v.dirty = true
+
+	return nil
+}
+
+// SetWriteProtect will change the writeProtect status of the volume to the
+// given status.
+func (v *Volume) SetWriteProtect(status bool) {
+	v.writeProtect = status
+}
+
+// ToggleWriteProtect flips the status of write protection for the volume.
+func (v *Volume) ToggleWriteProtect() {
+	v.writeProtect = !v.writeProtect
+}
+
+// WriteProtected returns true if the volume is write-protected.
+func (v *Volume) WriteProtected() bool {
+	return v.writeProtect
+}
@@ -0,0 +1,161 @@
+package a2hd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blocks returns the bytes of a volume with n blocks, where each byte of a
+// block is the block number.
+func blocks(n int) []uint8 {
+	data := make([]uint8, n*BlockSize)
+	for i := range data {
+		data[i] = uint8(i / BlockSize)
+	}
+
+	return data
+}
+
+// twoIMG wraps data in a 2IMG header (with a trailing comment).
+func twoIMG(data []uint8, locked bool) []uint8 {
+	header := make([]uint8, twoIMGHeaderSize)
+	copy(header, twoIMGMagic)
+	copy(header[4:], "TEST")
+	binary.LittleEndian.PutUint16(header[8:], twoIMGHeaderSize)
+	binary.LittleEndian.PutUint16(header[10:], 1)
+	binary.LittleEndian.PutUint32(header[12:], twoIMGFormatProDOS)
+	binary.LittleEndian.PutUint32(header[20:], uint32(len(data)/BlockSize))
+	binary.LittleEndian.PutUint32(header[24:], twoIMGHeaderSize)
+	binary.LittleEndian.PutUint32(header[28:], uint32(len(data)))
+
+	if locked {
+		binary.LittleEndian.PutUint32(header[16:], twoIMGFlagLocked)
+	}
+
+	out := append(header, data...)
+	return append(out, []uint8("comment")...)
+}
+
+func TestIsImage(t *testing.T) {
+	assert.True(t, IsImage("a.po"))
+	assert.True(t, IsImage("a.HDV"))
+	assert.True(t, IsImage("a.2mg"))
+	assert.False(t, IsImage("a.dsk"))
+}
+
+func TestVolumeLoad(t *testing.T) {
+	t.Run("raw image", func(t *testing.T) {
+		v := NewVolume()
+		require.NoError(t, v.Load(bytes.NewReader(blocks(16)), "hd.hdv"))
+
+		assert.True(t, v.Loaded())
+		assert.Equal(t, 16, v.Blocks())
+		assert.False(t, v.WriteProtected())
+		assert.Equal(t, "hd.hdv", v.ImageName())
+	})
+
+	t.Run("2mg image", func(t *testing.T) {
+		v := NewVolume()
+		require.NoError(t, v.Load(bytes.NewReader(twoIMG(blocks(8), false)), "hd.2mg"))
+
+		assert.Equal(t, 8, v.Blocks())
+		assert.False(t, v.WriteProtected())
+
+		buf := make([]uint8, BlockSize)
+		require.NoError(t, v.ReadBlock(7, buf))
+		assert.Equal(t, uint8(7), buf[0])
+	})
+
+	t.Run("locked 2mg image", func(t *testing.T) {
+		v := NewVolume()
+		require.NoError(t, v.Load(bytes.NewReader(twoIMG(blocks(8), true)), "hd.2mg"))
+
+		assert.True(t, v.WriteProtected())
+	})
+
+	t.Run("bad suffix", func(t *testing.T) {
+		assert.Error(t, NewVolume().Load(bytes.NewReader(blocks(1)), "hd.dsk"))
+	})
+
+	t.Run("partial block", func(t *testing.T) {
+		assert.Error(t, NewVolume().Load(bytes.NewReader(make([]uint8, 1000)), "hd.hdv"))
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		assert.Error(t, NewVolume().Load(bytes.NewReader(blocks(MaxBlocks+1)), "hd.hdv"))
+	})
+
+	t.Run("bad 2mg header", func(t *testing.T) {
+		assert.Error(t, NewVolume().Load(bytes.NewReader(blocks(8)), "hd.2mg"))
+	})
+}
+
+func TestVolumeReadWriteBlock(t *testing.T) {
+	v := NewVolume()
+	buf := make([]uint8, BlockSize)
+
+	assert.ErrorIs(t, v.ReadBlock(0, buf), ErrNoVolume)
+	assert.ErrorIs(t, v.WriteBlock(0, buf), ErrNoVolume)
+
+	require.NoError(t, v.Load(bytes.NewReader(blocks(4)), "hd.po"))
+
+	assert.ErrorIs(t, v.ReadBlock(4, buf), ErrBadBlock)
+	assert.ErrorIs(t, v.WriteBlock(-1, buf), ErrBadBlock)
+
+	for i := range buf {
+		buf[i] = 0xAB
+	}
+
+	require.NoError(t, v.WriteBlock(2, buf))
+
+	out := make([]uint8, BlockSize)
+	require.NoError(t, v.ReadBlock(2, out))
+	assert.Equal(t, buf, out)
+
+	v.SetWriteProtect(true)
+	assert.ErrorIs(t, v.WriteBlock(2, buf), ErrWriteProtected)
+
+	v.ToggleWriteProtect()
+	assert.False(t, v.WriteProtected())
+}
+
+func TestVolumeSave(t *testing.T) {
+	t.Run("unchanged volumes are not written", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "hd.hdv")
+
+		v := NewVolume()
+		require.NoError(t, v.Load(bytes.NewReader(blocks(4)), file))
+		require.NoError(t, v.Save())
+
+		_, err := os.Stat(file)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("2mg header and trailer are kept", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "hd.2mg")
+		orig := twoIMG(blocks(4), false)
+		require.NoError(t, os.WriteFile(file, orig, 0o644))
+
+		v := NewVolume()
+		require.NoError(t, v.Load(bytes.NewReader(orig), file))
+
+		buf := make([]uint8, BlockSize)
+		buf[0] = 0xFF
+		require.NoError(t, v.WriteBlock(1, buf))
+		require.NoError(t, v.Save())
+
+		saved, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.Len(t, saved, len(orig))
+
+		assert.Equal(t, orig[:twoIMGHeaderSize], saved[:twoIMGHeaderSize])
+		assert.Equal(t, uint8(0xFF), saved[twoIMGHeaderSize+BlockSize])
+		assert.Equal(t, "comment", string(saved[len(saved)-7:]))
+	})
+}
@@ -13,6 +13,10 @@ const (
 	setMemWriteAux  = int(0xC005)
 	rdMemReadAux    = int(0xC013)
 	rdMemWriteAux   = int(0xC014)
+
+	// setAuxBank is the bank-select register of a RamWorks-style memory
+	// card, which picks which 64K bank of auxiliary memory we use.
+	setAuxBank = int(0xC073)
 )
 
 // ReadSwitches returns the list of memory switch addresses that support
@@ -32,6 +36,7 @@ func WriteSwitches() []int {
 		setMemWriteMain,
 		setMemReadAux,
 		setMemWriteAux,
+		setAuxBank,
 	}
 }
 
@@ -76,15 +81,51 @@ func SwitchWrite(addr int, val uint8, stm *memory.StateMap) {
 		metrics.Increment("soft_memory_write_aux_off", 1)
 		stm.SetBool(a2state.MemWriteAux, false)
 		stm.SetSegment(a2state.MemWriteSegment, stm.Segment(a2state.MemMainSegment))
+	case setAuxBank:
+		metrics.Increment("soft_memory_aux_bank", 1)
+		SelectAuxBank(int(val), stm)
 	}
 }
 
-// UseDefaults sets up the default state for memory modes.
-func UseDefaults(stm *memory.StateMap, main, aux *memory.Segment) {
+// SelectAuxBank makes the given bank of auxiliary memory the one that is
+// used for any access to auxiliary memory by the CPU. The display is not
+// affected; it always shows the first bank, which is where the display pages
+// for auxiliary memory live.
+func SelectAuxBank(bank int, stm *memory.StateMap) {
+	banks := stm.Any(a2state.MemAuxBanks).([]*memory.Segment)
+
+	// A card that isn't fully populated will mirror the banks it does have
+	// for bank numbers it doesn't.
+	bank %= len(banks)
+	aux := banks[bank]
+
+	stm.SetInt(a2state.MemAuxBank, bank)
+	stm.SetSegment(a2state.MemAuxSegment, aux)
+
+	if stm.Bool(a2state.MemReadAux) {
+		stm.SetSegment(a2state.MemReadSegment, aux)
+	}
+
+	if stm.Bool(a2state.MemWriteAux) {
+		stm.SetSegment(a2state.MemWriteSegment, aux)
+	}
+
+	// The language card and zero page are also banked along with the rest
+	// of auxiliary memory
+	if stm.Bool(a2state.BankSysBlockAux) {
+		stm.SetSegment(a2state.BankSysBlockSegment, aux)
+	}
+}
+
+// UseDefaults sets up the default state for memory modes. The first of the
+// auxiliary memory banks is the one that is selected.
+func UseDefaults(stm *memory.StateMap, main *memory.Segment, auxBanks []*memory.Segment) {
 	stm.SetBool(a2state.MemReadAux, false)
 	stm.SetBool(a2state.MemWriteAux, false)
 	stm.SetSegment(a2state.MemReadSegment, main)
 	stm.SetSegment(a2state.MemWriteSegment, main)
-	stm.SetSegment(a2state.MemAuxSegment, aux)
+	stm.SetSegment(a2state.MemAuxSegment, auxBanks[0])
 	stm.SetSegment(a2state.MemMainSegment, main)
+	stm.SetAny(a2state.MemAuxBanks, auxBanks)
+	stm.SetInt(a2state.MemAuxBank, 0)
 }
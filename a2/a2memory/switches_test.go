@@ -14,7 +14,7 @@ func TestUseDefaults(t *testing.T) {
 	main := memory.NewSegment(0x10000)
 	aux := memory.NewSegment(0x10000)
 
-	a2memory.UseDefaults(stm, main, aux)
+	a2memory.UseDefaults(stm, main, []*memory.Segment{aux})
 
 	assert.False(t, stm.Bool(a2state.MemReadAux))
 	assert.False(t, stm.Bool(a2state.MemWriteAux))
@@ -81,3 +81,46 @@ func TestSwitchWrite(t *testing.T) {
 		assert.False(t, stm.Bool(a2state.MemWriteAux))
 	})
 }
+
+func TestSelectAuxBank(t *testing.T) {
+	var (
+		c073  = 0xC073
+		stm   = memory.NewStateMap()
+		main  = memory.NewSegment(0x10000)
+		banks = []*memory.Segment{
+			memory.NewSegment(0x10000),
+			memory.NewSegment(0x10000),
+			memory.NewSegment(0x10000),
+		}
+	)
+
+	a2memory.UseDefaults(stm, main, banks)
+
+	t.Run("bank is selected", func(t *testing.T) {
+		a2memory.SwitchWrite(c073, 2, stm)
+		assert.Equal(t, 2, stm.Int(a2state.MemAuxBank))
+		assert.Equal(t, banks[2], stm.Segment(a2state.MemAuxSegment))
+
+		// We're not reading or writing aux memory, so those shouldn't
+		// change
+		assert.Equal(t, main, stm.Segment(a2state.MemReadSegment))
+		assert.Equal(t, main, stm.Segment(a2state.MemWriteSegment))
+	})
+
+	t.Run("aux reads and writes follow the bank", func(t *testing.T) {
+		a2memory.SwitchWrite(0xC003, 0, stm)
+		a2memory.SwitchWrite(0xC005, 0, stm)
+		stm.SetBool(a2state.BankSysBlockAux, true)
+
+		a2memory.SwitchWrite(c073, 1, stm)
+		assert.Equal(t, banks[1], stm.Segment(a2state.MemReadSegment))
+		assert.Equal(t, banks[1], stm.Segment(a2state.MemWriteSegment))
+		assert.Equal(t, banks[1], stm.Segment(a2state.BankSysBlockSegment))
+	})
+
+	t.Run("missing banks are mirrored", func(t *testing.T) {
+		a2memory.SwitchWrite(c073, 4, stm)
+		assert.Equal(t, 1, stm.Int(a2state.MemAuxBank))
+		assert.Equal(t, banks[1], stm.Segment(a2state.MemAuxSegment))
+	})
+}
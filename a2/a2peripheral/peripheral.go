@@ -192,6 +192,37 @@ func Read(addr int, stm *memory.StateMap) uint8 {
 	return pcrom.DirectGet(intAddr)
 }
 
+// Peek returns the byte that Read would return for the given address, but
+// without selecting or deselecting any peripheral's ROM along the way.
+func Peek(addr int, stm *memory.StateMap) uint8 {
+	var (
+		intAddr    = int(iromAddr(addr))
+		periphAddr = int(promAddr(addr))
+		pcrom      = stm.Segment(a2state.PCROMSegment)
+	)
+
+	switch {
+	case stm.Bool(a2state.PCSlotCX):
+		if !stm.Bool(a2state.PCSlotC3) && slot3ROM(addr) {
+			return pcrom.DirectGet(intAddr)
+		}
+
+		if expROM(addr) && stm.Bool(a2state.PCIOSelect) {
+			return expansionROM(stm, addr)
+		}
+
+		return pcrom.DirectGet(periphAddr)
+
+	case stm.Bool(a2state.PCSlotC3) && slot3ROM(addr):
+		return pcrom.DirectGet(periphAddr)
+
+	case stm.Bool(a2state.PCExpansion) && expROM(addr):
+		return expansionROM(stm, addr)
+	}
+
+	return pcrom.DirectGet(intAddr)
+}
+
 func Write(addr int, val uint8, stm *memory.StateMap) {
 	metrics.Increment("soft_pc_failed_write", 1)
 
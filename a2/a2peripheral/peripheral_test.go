@@ -213,6 +213,25 @@ func (s *peripheralSuite) TestRead() {
 	})
 }
 
+func (s *peripheralSuite) TestPeek() {
+	s.rom.DirectSet(iromAddr(0xC401), 0x11)
+	s.rom.DirectSet(promAddr(0xC401), 0x22)
+	s.rom.DirectSet(iromAddr(0xCFFF), 0x33)
+	s.rom.DirectSet(promAddr(0xCFFF), 0x44)
+
+	s.state.SetBool(a2state.PCSlotCX, true)
+	s.state.SetBool(a2state.PCIOSelect, false)
+	s.state.SetBool(a2state.PCIOStrobe, false)
+
+	s.Equal(uint8(0x22), Peek(0xC401, s.state))
+	s.Equal(uint8(0x44), Peek(0xCFFF, s.state))
+	s.False(s.state.Bool(a2state.PCIOSelect))
+	s.False(s.state.Bool(a2state.PCIOStrobe))
+
+	s.state.SetBool(a2state.PCSlotCX, false)
+	s.Equal(uint8(0x11), Peek(0xC401, s.state))
+}
+
 func (s *peripheralSuite) TestWrite() {
 	s.Run("CFFF write disables expansion", func() {
 		s.state.SetBool(a2state.PCIOSelect, true)
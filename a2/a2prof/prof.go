@@ -0,0 +1,258 @@
+// Package a2prof profiles the subroutines that the computer runs. It follows
+// JSR and RTS (along with BRK and RTI, which is how an interrupt enters and
+// leaves) to keep a call stack, and counts the cycles spent in each routine,
+// both in the routine itself (exclusive) and in everything it called
+// (inclusive).
+package a2prof
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pevans/erc/a2/a2sym"
+)
+
+// These are the opcodes that change the call stack. They're the same in
+// every variant of the 6502.
+const (
+	opBRK = 0x00
+	opJSR = 0x20
+	opRTI = 0x40
+	opRTS = 0x60
+)
+
+// topName is the name we give to whatever was running when we began, which
+// is the bottom of every call stack.
+const topName = "(top)"
+
+// A Routine is a subroutine that was called, along with what we know about
+// the time spent in it.
+type Routine struct {
+	Addr int
+	Name string
+
+	// Calls is the number of times the routine was called.
+	Calls int
+
+	// Exclusive is the number of cycles spent in the routine itself, and
+	// Inclusive also counts the cycles of the routines it called.
+	Exclusive uint64
+	Inclusive uint64
+
+	// active is the number of frames of the routine on the call stack (which
+	// is more than one if it's recursive).
+	active int
+}
+
+// A frame is a call to a routine that hasn't returned.
+type frame struct {
+	routine *Routine
+
+	// sp is what the stack pointer was before the call, which is what it'll
+	// be again once the routine returns.
+	sp uint8
+
+	// start is the cycle on which the call was made, and inclusive is true
+	// if this is the outermost frame of its routine (we only count cycles
+	// toward Inclusive from those frames, or recursion would count cycles
+	// more than once).
+	start     uint64
+	inclusive bool
+
+	// path is the call stack up to and including this frame, in the folded
+	// stack format.
+	path string
+}
+
+// A Profiler keeps track of the call stack and of the cycles spent in each
+// routine.
+type Profiler struct {
+	symbols  map[int]string
+	routines map[int]*Routine
+	stack    []frame
+	folded   map[string]uint64
+	cycles   uint64
+}
+
+// New returns a new profiler. Routines are named with the given symbols if
+// they have one (symbols may be nil), or failing that, with the names of the
+// routines in the Apple //e ROM that are documented in a2sym.
+func New(symbols map[int]string) *Profiler {
+	top := &Routine{Addr: -1, Name: topName, active: 1}
+
+	return &Profiler{
+		symbols:  symbols,
+		routines: map[int]*Routine{-1: top},
+		stack:    []frame{{routine: top, inclusive: true, path: topName}},
+		folded:   make(map[string]uint64),
+	}
+}
+
+// Step records the execution of an instruction, which took the given number
+// of cycles. The PC and SP are those the CPU has after executing it.
+func (p *Profiler) Step(opcode uint8, pc uint16, sp uint8, cycles int) {
+	top := &p.stack[len(p.stack)-1]
+
+	p.cycles += uint64(cycles)
+	top.routine.Exclusive += uint64(cycles)
+	p.folded[top.path] += uint64(cycles)
+
+	switch opcode {
+	case opJSR:
+		p.push(int(pc), sp+2)
+	case opBRK:
+		p.push(int(pc), sp+3)
+	case opRTS, opRTI:
+		p.pop(sp)
+	}
+}
+
+// push adds a call to the routine at addr to the stack.
+func (p *Profiler) push(addr int, sp uint8) {
+	routine := p.routine(addr)
+	routine.Calls++
+	routine.active++
+
+	p.stack = append(p.stack, frame{
+		routine:   routine,
+		sp:        sp,
+		start:     p.cycles,
+		inclusive: routine.active == 1,
+		path:      p.stack[len(p.stack)-1].path + ";" + routine.Name,
+	})
+}
+
+// pop removes the frames of any routines that have returned, given the
+// stack pointer that we have now. Usually that's the one frame on top of the
+// stack, but software will sometimes pull a return address off the stack so
+// it can return to its caller's caller; in that case we pop both.
+func (p *Profiler) pop(sp uint8) {
+	for len(p.stack) > 1 {
+		top := p.stack[len(p.stack)-1]
+		if top.sp > sp {
+			return
+		}
+
+		if top.inclusive {
+			top.routine.Inclusive += p.cycles - top.start
+		}
+
+		top.routine.active--
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+}
+
+// routine returns the routine at the given address, which we create if it
+// hasn't been called before.
+func (p *Profiler) routine(addr int) *Routine {
+	if r, ok := p.routines[addr]; ok {
+		return r
+	}
+
+	name := p.symbols[addr]
+	if name == "" {
+		name = a2sym.Subroutine(addr)
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("$%04X", addr)
+	}
+
+	r := &Routine{Addr: addr, Name: name}
+	p.routines[addr] = r
+
+	return r
+}
+
+// Routines returns each routine that was called (along with the top of the
+// stack), sorted by the cycles spent in them, most first. Routines that
+// haven't returned yet count the cycles they've spent so far.
+func (p *Profiler) Routines() []Routine {
+	routines := make([]Routine, 0, len(p.routines))
+	for _, r := range p.routines {
+		routines = append(routines, *r)
+	}
+
+	for _, f := range p.stack {
+		if !f.inclusive {
+			continue
+		}
+
+		for i := range routines {
+			if routines[i].Addr == f.routine.Addr {
+				routines[i].Inclusive += p.cycles - f.start
+			}
+		}
+	}
+
+	sort.Slice(routines, func(i, j int) bool {
+		if routines[i].Inclusive != routines[j].Inclusive {
+			return routines[i].Inclusive > routines[j].Inclusive
+		}
+
+		if routines[i].Exclusive != routines[j].Exclusive {
+			return routines[i].Exclusive > routines[j].Exclusive
+		}
+
+		return routines[i].Addr < routines[j].Addr
+	})
+
+	return routines
+}
+
+// Folded returns the cycles spent in each call stack, in the folded stack
+// format that flame graph tools use. Each line is a call stack of routine
+// names separated by semicolons, followed by the cycles spent at the top of
+// that stack, e.g. "(top);COUT;COUT1 1234".
+func (p *Profiler) Folded() string {
+	paths := make([]string, 0, len(p.folded))
+	for path := range p.folded {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&b, "%s %d\n", path, p.folded[path])
+	}
+
+	return b.String()
+}
+
+// Report returns a table of each routine, sorted as Routines sorts them.
+func (p *Profiler) Report() string {
+	var b strings.Builder
+
+	fmt.Fprintf(
+		&b, "%-16s %-6s %8s %12s %7s %12s %7s\n",
+		"routine", "addr", "calls", "inclusive", "incl%", "exclusive", "excl%",
+	)
+
+	for _, r := range p.Routines() {
+		addr := "-"
+		if r.Addr >= 0 {
+			addr = fmt.Sprintf("$%04X", r.Addr)
+		}
+
+		fmt.Fprintf(
+			&b, "%-16s %-6s %8d %12d %6.2f%% %12d %6.2f%%\n",
+			r.Name, addr, r.Calls,
+			r.Inclusive, p.percent(r.Inclusive),
+			r.Exclusive, p.percent(r.Exclusive),
+		)
+	}
+
+	return b.String()
+}
+
+// percent returns the given number of cycles as a percent of all of those
+// we've seen.
+func (p *Profiler) percent(cycles uint64) float64 {
+	if p.cycles == 0 {
+		return 0
+	}
+
+	return float64(cycles) * 100 / float64(p.cycles)
+}
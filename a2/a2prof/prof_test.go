@@ -0,0 +1,112 @@
+package a2prof
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type profSuite struct {
+	suite.Suite
+
+	prof *Profiler
+}
+
+func (s *profSuite) SetupTest() {
+	s.prof = New(map[int]string{0x0900: "MYSUB"})
+}
+
+func TestProfSuite(t *testing.T) {
+	suite.Run(t, new(profSuite))
+}
+
+// routine returns the routine with the given name from Routines.
+func (s *profSuite) routine(name string) Routine {
+	for _, r := range s.prof.Routines() {
+		if r.Name == name {
+			return r
+		}
+	}
+
+	s.FailNow("no such routine", name)
+
+	return Routine{}
+}
+
+func (s *profSuite) TestCallAndReturn() {
+	s.prof.Step(0xEA, 0x0801, 0xFF, 2)
+	s.prof.Step(opJSR, 0x0900, 0xFD, 6)
+	s.prof.Step(0xEA, 0x0901, 0xFD, 2)
+	s.prof.Step(opJSR, 0xFDED, 0xFB, 6)
+	s.prof.Step(0xEA, 0xFDEE, 0xFB, 3)
+	s.prof.Step(opRTS, 0x0904, 0xFD, 6)
+	s.prof.Step(opRTS, 0x0804, 0xFF, 6)
+
+	mysub := s.routine("MYSUB")
+	s.Equal(1, mysub.Calls)
+	s.Equal(uint64(2+6+6), mysub.Exclusive)
+	s.Equal(uint64(2+6+3+6+6), mysub.Inclusive)
+
+	cout := s.routine("COUT")
+	s.Equal(1, cout.Calls)
+	s.Equal(uint64(3+6), cout.Exclusive)
+	s.Equal(uint64(3+6), cout.Inclusive)
+
+	// The cycles of a JSR count toward the caller, and those of an RTS
+	// toward the routine that's returning
+	s.Equal(uint64(2+6), s.routine(topName).Exclusive)
+	s.Equal(
+		"(top) 8\n"+
+			"(top);MYSUB 14\n"+
+			"(top);MYSUB;COUT 9\n",
+		s.prof.Folded(),
+	)
+}
+
+func (s *profSuite) TestUnnamedRoutine() {
+	s.prof.Step(opJSR, 0x1234, 0xFD, 6)
+	s.Equal(1, s.routine("$1234").Calls)
+}
+
+func (s *profSuite) TestRecursion() {
+	s.prof.Step(opJSR, 0x0900, 0xFD, 6)
+	s.prof.Step(opJSR, 0x0900, 0xFB, 6)
+	s.prof.Step(opRTS, 0x0903, 0xFD, 6)
+	s.prof.Step(opRTS, 0x0803, 0xFF, 6)
+
+	mysub := s.routine("MYSUB")
+	s.Equal(2, mysub.Calls)
+	s.Equal(uint64(18), mysub.Exclusive)
+	s.Equal(uint64(18), mysub.Inclusive)
+}
+
+func (s *profSuite) TestReturnToCallersCaller() {
+	s.prof.Step(opJSR, 0x0900, 0xFD, 6)
+	s.prof.Step(opJSR, 0xFDED, 0xFB, 6)
+
+	// COUT pulls its return address and returns straight to the top
+	s.prof.Step(0x68, 0xFDEE, 0xFC, 4)
+	s.prof.Step(0x68, 0xFDEF, 0xFD, 4)
+	s.prof.Step(opRTS, 0x0803, 0xFF, 6)
+
+	s.Equal(uint64(6+4+4+6), s.routine("MYSUB").Inclusive)
+	s.Equal(uint64(4+4+6), s.routine("COUT").Inclusive)
+}
+
+func (s *profSuite) TestInterrupt() {
+	s.prof.Step(opBRK, 0xFA40, 0xFC, 7)
+	s.prof.Step(0xEA, 0xFA41, 0xFC, 2)
+	s.prof.Step(opRTI, 0x0802, 0xFF, 6)
+
+	irq := s.routine("IRQ")
+	s.Equal(1, irq.Calls)
+	s.Equal(uint64(8), irq.Inclusive)
+}
+
+func (s *profSuite) TestUnfinishedRoutine() {
+	s.prof.Step(opJSR, 0x0900, 0xFD, 6)
+	s.prof.Step(0xEA, 0x0901, 0xFD, 2)
+
+	s.Equal(uint64(2), s.routine("MYSUB").Inclusive)
+	s.Contains(s.prof.Report(), "MYSUB")
+}
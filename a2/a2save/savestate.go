@@ -12,6 +12,7 @@ type SaveState struct {
 	CPU           CPUState
 	Main          []uint8
 	Aux           []uint8
+	AuxBanks      [][]uint8 // any aux banks after the first (which is Aux)
 	StateFlags    StateFlags
 	Drive1        DriveState
 	Drive2        DriveState
@@ -36,11 +37,15 @@ type CPUState struct {
 	EffVal       uint8
 	AddrMode     int
 	ReadOp       bool
+	Variant      int
 }
 
 // StateFlags captures boolean and integer state from the StateMap. Only
 // includes values needed for restoration (excludes Segment pointers).
 type StateFlags struct {
+	// Annunciator state
+	Annunciators [4]bool
+
 	// Bank state
 	BankDFBlockBank2 bool
 	BankReadAttempts int
@@ -66,6 +71,7 @@ type StateFlags struct {
 	KBStrobe  uint8
 
 	// Memory state
+	MemAuxBank  int
 	MemReadAux  bool
 	MemWriteAux bool
 
@@ -1,9 +1,16 @@
 package a2state
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
-	BankDFBlockBank2 = iota
+	Annunciator0 = iota
+	Annunciator1
+	Annunciator2
+	Annunciator3
+	BankDFBlockBank2
 	BankROMSegment
 	BankReadAttempts
 	BankReadRAM
@@ -11,6 +18,10 @@ const (
 	BankSysBlockSegment
 	BankWriteRAM
 	CapsLock
+	CassetteOut
+	ClockIndex
+	ClockLatch
+	ClockMode
 	Computer
 	DebugImage
 	Debugger
@@ -28,12 +39,17 @@ const (
 	DisplayRedraw
 	DisplayStore80
 	DisplayText
+	HDResult
+	HDResultX
+	HDResultY
+	HDStackPointer
 	HelpModal
-	InstructionReadOp
 	KBKeyDown
 	KBLastKey
 	KBMutex
 	KBStrobe
+	MemAuxBank
+	MemAuxBanks
 	MemAuxSegment
 	MemMainSegment
 	MemReadAux
@@ -57,6 +73,10 @@ const (
 )
 
 var keyStringMap = map[int]string{
+	Annunciator0:        "Annunciator0",
+	Annunciator1:        "Annunciator1",
+	Annunciator2:        "Annunciator2",
+	Annunciator3:        "Annunciator3",
 	BankDFBlockBank2:    "BankDFBlockBank2",
 	BankROMSegment:      "BankROMSegment",
 	BankReadAttempts:    "BankReadAttempts",
@@ -65,6 +85,10 @@ var keyStringMap = map[int]string{
 	BankSysBlockSegment: "BankSysBlockSegment",
 	BankWriteRAM:        "BankWriteRAM",
 	CapsLock:            "CapsLock",
+	CassetteOut:         "CassetteOut",
+	ClockIndex:          "ClockIndex",
+	ClockLatch:          "ClockLatch",
+	ClockMode:           "ClockMode",
 	Computer:            "Computer",
 	DebugImage:          "DebugImage",
 	Debugger:            "Debugger",
@@ -82,12 +106,17 @@ var keyStringMap = map[int]string{
 	DisplayRedraw:       "DisplayRedraw",
 	DisplayStore80:      "DisplayStore80",
 	DisplayText:         "DisplayText",
+	HDResult:            "HDResult",
+	HDResultX:           "HDResultX",
+	HDResultY:           "HDResultY",
+	HDStackPointer:      "HDStackPointer",
 	HelpModal:           "HelpModal",
-	InstructionReadOp:   "InstructionReadOp",
 	KBKeyDown:           "KBKeyDown",
 	KBLastKey:           "KBLastkey",
 	KBMutex:             "KBMutex",
 	KBStrobe:            "KBStrobe",
+	MemAuxBank:          "MemAuxBank",
+	MemAuxBanks:         "MemAuxBanks",
 	MemAuxSegment:       "MemAuxSegment",
 	MemMainSegment:      "MemMainSegment",
 	MemReadAux:          "MemReadAux",
@@ -122,3 +151,15 @@ func KeyToString(key any) string {
 
 	return fmt.Sprintf("unknown (%v)", key)
 }
+
+// StringToKey returns the key with the given name, which we match without
+// regard to case. It returns false if there's no such key.
+func StringToKey(name string) (int, bool) {
+	for key, keyName := range keyStringMap {
+		if strings.EqualFold(keyName, name) {
+			return key, true
+		}
+	}
+
+	return 0, false
+}
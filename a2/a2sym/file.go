@@ -0,0 +1,225 @@
+package a2sym
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Table is a set of symbols, which are names for addresses, such as those
+// that an assembler writes out for the program it assembled.
+type Table map[int]string
+
+// userSymbols are the symbols we were given with Use. They take precedence
+// over the names that Apple documented.
+var userSymbols = Table{}
+
+// Use adds the symbols in the table to those that Subroutine, Variable and
+// Labels return. They take precedence over the names from Apple's
+// documentation (and over any symbols we were given before for the same
+// address).
+func Use(t Table) {
+	for addr, name := range t {
+		userSymbols[addr] = name
+	}
+}
+
+// A format is a kind of symbol file we can read.
+type format int
+
+const (
+	formatPlain  format = iota // 0803 PLAYER_MOVE
+	formatVICE                 // al C:0803 .PLAYER_MOVE (also ca65's .lbl)
+	formatCA65                 // sym id=0,name="PLAYER_MOVE",...,val=0x803,...
+	formatMerlin               // PLAYER_MOVE EQU $0803
+)
+
+var (
+	merlinRe = regexp.MustCompile(`^([A-Za-z_.:][\w.:]*)\s*(?:\s[Ee][Qq][Uu]\s|=)\s*(\S+)$`)
+	ca65Re   = regexp.MustCompile(`^(version|info|file|lib|mod|seg|span|scope|sym|csym|line|type)\s`)
+)
+
+// LoadFile reads the symbols in the file at the given path. See Parse for
+// the kinds of file it understands.
+func LoadFile(path string) (Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close() //nolint:errcheck
+
+	t, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return t, nil
+}
+
+// Parse reads symbols from r, which may be in any of these forms:
+//
+//   - a VICE label file, which is what ca65 writes with -Ln (al C:0803
+//     .PLAYER_MOVE)
+//   - a ca65 debug info file, of which we only read the labels (sym
+//     ...,name="PLAYER_MOVE",...,val=0x803,...,type=lab)
+//   - Merlin equates (PLAYER_MOVE EQU $0803, or PLAYER_MOVE = $0803)
+//   - a plain list of addresses and names (0803 PLAYER_MOVE)
+//
+// Which form it is, we tell from the first line that has anything in it.
+// Blank lines and comments (beginning with ;, * or #) are skipped.
+func Parse(r io.Reader) (Table, error) {
+	var (
+		t       = Table{}
+		form    format
+		known   bool
+		scanner = bufio.NewScanner(r)
+	)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.ContainsAny(line[:1], ";*#") {
+			continue
+		}
+
+		if !known {
+			form = sniff(line)
+			known = true
+		}
+
+		addr, name, err := parseLine(form, line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if name != "" {
+			t[addr] = name
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// sniff returns the format that the given line looks to be in.
+func sniff(line string) format {
+	switch {
+	case strings.HasPrefix(line, "al "):
+		return formatVICE
+	case ca65Re.MatchString(line):
+		return formatCA65
+	case merlinRe.MatchString(line):
+		return formatMerlin
+	}
+
+	return formatPlain
+}
+
+// parseLine returns the address and name of the symbol in the line. The
+// name is empty if the line is one that has no symbol for us.
+func parseLine(form format, line string) (int, string, error) {
+	switch form {
+	case formatVICE:
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "al" {
+			return 0, "", fmt.Errorf("expected al <addr> .<name>: %q", line)
+		}
+
+		// The address may have a memory space before it (C: is the
+		// computer's)
+		addrStr := fields[1]
+		if _, after, ok := strings.Cut(addrStr, ":"); ok {
+			addrStr = after
+		}
+
+		addr, err := parseAddr(addrStr)
+
+		return addr, strings.TrimPrefix(fields[2], "."), err
+
+	case formatCA65:
+		return parseCA65(line)
+
+	case formatMerlin:
+		m := merlinRe.FindStringSubmatch(line)
+		if m == nil {
+			return 0, "", fmt.Errorf("expected <name> EQU <addr>: %q", line)
+		}
+
+		addr, err := parseAddr(m[2])
+
+		return addr, m[1], err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return 0, "", fmt.Errorf("expected <addr> <name>: %q", line)
+	}
+
+	addr, err := parseAddr(fields[0])
+
+	return addr, fields[1], err
+}
+
+// parseCA65 returns the address and name of a label in a line of a ca65
+// debug info file. Lines that aren't labels have no name.
+func parseCA65(line string) (int, string, error) {
+	kind, rest, _ := strings.Cut(line, "\t")
+	if strings.TrimSpace(kind) != "sym" {
+		return 0, "", nil
+	}
+
+	attrs := make(map[string]string)
+	for attr := range strings.SplitSeq(rest, ",") {
+		key, val, _ := strings.Cut(attr, "=")
+		attrs[strings.TrimSpace(key)] = strings.Trim(val, `"`)
+	}
+
+	if attrs["type"] != "lab" {
+		return 0, "", nil
+	}
+
+	addr, err := parseAddr(attrs["val"])
+
+	return addr, attrs["name"], err
+}
+
+// parseAddr parses an address in hex, with or without a prefix of $ or 0x.
+// Addresses of more than 16 bits (as VICE and ca65 sometimes write) are
+// fine, so long as the bits above 16 are zero.
+func parseAddr(s string) (int, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(s), "$"), "0x")
+
+	addr, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || addr > 0xFFFF {
+		return 0, fmt.Errorf("invalid address: %q", s)
+	}
+
+	return int(addr), nil
+}
+
+// WriteVICE writes the table as a VICE label file, sorted by address, which
+// Parse (as well as VICE and other emulators) can read back.
+func (t Table) WriteVICE(w io.Writer) error {
+	addrs := make([]int, 0, len(t))
+	for addr := range t {
+		addrs = append(addrs, addr)
+	}
+
+	sort.Ints(addrs)
+
+	for _, addr := range addrs {
+		if _, err := fmt.Fprintf(w, "al C:%04X .%s\n", addr, t[addr]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
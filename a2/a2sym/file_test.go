@@ -0,0 +1,71 @@
+package a2sym
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	want := Table{0x0803: "PLAYER_MOVE", 0x0900: "SCORE"}
+
+	cases := map[string]string{
+		"plain": "; our symbols\n0803 PLAYER_MOVE\n$0900 SCORE\n",
+		"vice":  "al C:0803 .PLAYER_MOVE\nal 000900 .SCORE\n",
+		"ca65": "version\tmajor=2,minor=0\n" +
+			"seg\tid=0,name=\"CODE\",start=0x000800,size=0x0200\n" +
+			"sym\tid=0,name=\"PLAYER_MOVE\",addrsize=absolute,scope=0,def=1,val=0x803,seg=0,type=lab\n" +
+			"sym\tid=1,name=\"SCORE\",addrsize=absolute,scope=0,def=2,val=0x900,seg=0,type=lab\n" +
+			"sym\tid=2,name=\"WIDTH\",addrsize=zeropage,scope=0,def=3,val=0x28,type=equ\n",
+		"merlin": "* game equates\nPLAYER_MOVE EQU $0803\nSCORE = $900\n",
+	}
+
+	for name, src := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(src))
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"0803 PLAYER MOVE\n",
+		"al C:0803\n",
+		"al C:10000 .TOO_FAR\n",
+		"0803 PLAYER_MOVE\nZZZZ SCORE\n",
+	} {
+		_, err := Parse(strings.NewReader(src))
+		assert.Error(t, err, src)
+	}
+}
+
+func TestWriteVICE(t *testing.T) {
+	table := Table{0x0900: "SCORE", 0x0803: "PLAYER_MOVE"}
+
+	var buf bytes.Buffer
+	require.NoError(t, table.WriteVICE(&buf))
+	assert.Equal(t, "al C:0803 .PLAYER_MOVE\nal C:0900 .SCORE\n", buf.String())
+
+	// And we can read back what we wrote
+	got, err := Parse(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, table, got)
+}
+
+func TestUse(t *testing.T) {
+	t.Cleanup(func() {
+		userSymbols = Table{}
+	})
+
+	Use(Table{0x0803: "PLAYER_MOVE", 0xFDED: "MY_COUT"})
+
+	assert.Equal(t, "PLAYER_MOVE", Subroutine(0x0803))
+	assert.Equal(t, "MY_COUT", Subroutine(0xFDED))
+	assert.Equal(t, "MY_COUT", Variable(0xFDED))
+	assert.Equal(t, 0x0803, Labels()["PLAYER_MOVE"])
+}
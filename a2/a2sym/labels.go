@@ -0,0 +1,25 @@
+package a2sym
+
+// Labels returns the address of each subroutine and variable, by its name,
+// so that the names can be used as labels in code we assemble. A few names
+// are given to more than one address (e.g. READ is both a DOS routine and a
+// monitor routine); for those, we return the highest address, which is the
+// one in ROM. Symbols we were given with Use take precedence over any of
+// these.
+func Labels() map[string]int {
+	labels := make(map[string]int, len(subroutineMap)+len(variablesMap)+len(userSymbols))
+
+	for _, names := range []map[int]string{subroutineMap, variablesMap} {
+		for addr, name := range names {
+			if prev, ok := labels[name]; !ok || addr > prev {
+				labels[name] = addr
+			}
+		}
+	}
+
+	for addr, name := range userSymbols {
+		labels[name] = addr
+	}
+
+	return labels
+}
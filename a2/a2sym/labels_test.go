@@ -0,0 +1,17 @@
+package a2sym
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabels(t *testing.T) {
+	labels := Labels()
+
+	assert.Equal(t, 0xFE84, labels["SETNORM"])
+	assert.Equal(t, 0x0020, labels["WNDLFT"])
+
+	// The monitor's READ wins out over the DOS one
+	assert.Equal(t, 0xFEFD, labels["READ"])
+}
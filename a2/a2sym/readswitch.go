@@ -27,6 +27,12 @@ var readSwitchMap = map[int]Switch{
 	0xC055: {Mode: ModeRW, Name: "PAGE2", Description: "off: select display page 2 or, if 80STORE on, display page 1 in auxiliary memory"},
 	0xC056: {Mode: ModeRW, Name: "HIRES", Description: "off: if TEXT off, display low-resolution graphics"},
 	0xC057: {Mode: ModeRW, Name: "HIRES", Description: "on: if TEXT off, display high-resolution or, if DHIRES on, double-high-resolution graphics"},
+	0xC058: {Mode: ModeRW, Name: "AN0", Description: "off: turn annunciator 0 off"},
+	0xC059: {Mode: ModeRW, Name: "AN0", Description: "on: turn annunciator 0 on"},
+	0xC05A: {Mode: ModeRW, Name: "AN1", Description: "off: turn annunciator 1 off"},
+	0xC05B: {Mode: ModeRW, Name: "AN1", Description: "on: turn annunciator 1 on"},
+	0xC05C: {Mode: ModeRW, Name: "AN2", Description: "off: turn annunciator 2 off"},
+	0xC05D: {Mode: ModeRW, Name: "AN2", Description: "on: turn annunciator 2 on"},
 	0xC05E: {Mode: ModeRW, Name: "DHIRES", Description: "on: if IOUDIS on, turn on double-high res."},
 	0xC05F: {Mode: ModeRW, Name: "DHIRES", Description: "off: if IOUDIS on, turn off double-high res."},
 
@@ -310,8 +310,13 @@ var subroutineMap = map[int]string{
 
 // Subroutine returns the name of a subroutine that Apple documented in their
 // technical reference, if one exists, for any given address. If one does not
-// exist, it will return an empty string.
+// exist, it will return an empty string. A symbol we were given with Use
+// takes precedence.
 func Subroutine(addr int) string {
+	if name, ok := userSymbols[addr]; ok {
+		return name
+	}
+
 	name, ok := subroutineMap[addr]
 	if !ok {
 		return ""
@@ -147,7 +147,14 @@ var variablesMap = map[int]string{
 	0xE003: "BASIC2",
 }
 
+// Variable returns the name of a variable that Apple documented, if there is
+// one at the given address, or an empty string if not. A symbol we were
+// given with Use takes precedence.
 func Variable(addr int) string {
+	if name, ok := userSymbols[addr]; ok {
+		return name
+	}
+
 	name, ok := variablesMap[addr]
 	if !ok {
 		return ""
@@ -0,0 +1,165 @@
+package a2
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/memory"
+)
+
+// A Bank is some part of memory that we can read from and write to directly,
+// whether or not the soft switches have it mapped in right now.
+type Bank int
+
+const (
+	// BankMapped is whatever memory the CPU would see at an address.
+	BankMapped Bank = iota
+
+	// BankMain and BankAux are the 64k of main and auxiliary memory. (For
+	// auxiliary memory, that's whichever bank is selected.) In $D000-$DFFF,
+	// they hold the language card bank that is selected.
+	BankMain
+	BankAux
+
+	// BankLC1 and BankLC2 are the language card RAM ($D000-$FFFF) in main
+	// memory, with bank 1 or 2 in $D000-$DFFF. BankAuxLC1 and BankAuxLC2 are
+	// the same, but in auxiliary memory.
+	BankLC1
+	BankLC2
+	BankAuxLC1
+	BankAuxLC2
+
+	// BankROM is the system ROM, at $C000-$FFFF.
+	BankROM
+)
+
+var bankNames = map[Bank]string{
+	BankMapped: "",
+	BankMain:   "main",
+	BankAux:    "aux",
+	BankLC1:    "lc1",
+	BankLC2:    "lc2",
+	BankAuxLC1: "auxlc1",
+	BankAuxLC2: "auxlc2",
+	BankROM:    "rom",
+}
+
+// ParseBank returns the bank with the given name (e.g. aux or lc1), and true
+// if there is one.
+func ParseBank(name string) (Bank, bool) {
+	for bank, bankName := range bankNames {
+		if bankName != "" && bankName == strings.ToLower(name) {
+			return bank, true
+		}
+	}
+
+	return BankMapped, false
+}
+
+// String returns the name of the bank, which is empty for BankMapped.
+func (b Bank) String() string {
+	return bankNames[b]
+}
+
+// Contains returns true if the bank has something at the given address.
+func (b Bank) Contains(addr int) bool {
+	switch b {
+	case BankLC1, BankLC2, BankAuxLC1, BankAuxLC2:
+		return addr >= 0xD000 && addr <= 0xFFFF
+	case BankROM:
+		return addr >= SysRomOffset && addr <= 0xFFFF
+	}
+
+	return addr >= 0 && addr <= 0xFFFF
+}
+
+// BankGet returns the byte at addr in the given bank, without setting off
+// any soft switch. For BankMapped, that is whatever Peek returns.
+func (c *Computer) BankGet(bank Bank, addr int) (uint8, error) {
+	if !bank.Contains(addr) {
+		return 0, bankRangeError(bank, addr)
+	}
+
+	if bank == BankMapped {
+		return c.Peek(addr), nil
+	}
+
+	seg, offset := c.bankSegment(bank, addr)
+
+	return seg.DirectGet(offset), nil
+}
+
+// BankSet sets the byte at addr in the given bank. For BankMapped, that is
+// just what Set does (soft switches and all); for any other bank, we write
+// to memory directly. ROM can't be written to.
+func (c *Computer) BankSet(bank Bank, addr int, val uint8) error {
+	if !bank.Contains(addr) {
+		return bankRangeError(bank, addr)
+	}
+
+	if bank == BankMapped {
+		c.Set(addr, val)
+		return nil
+	}
+
+	if bank == BankROM {
+		return fmt.Errorf("rom can't be written to")
+	}
+
+	seg, offset := c.bankSegment(bank, addr)
+	seg.DirectSet(offset, val)
+
+	return nil
+}
+
+// bankRangeError returns the error we give for an address that isn't in the
+// given bank.
+func bankRangeError(bank Bank, addr int) error {
+	if bank == BankMapped {
+		return fmt.Errorf("$%04X is not an address", addr)
+	}
+
+	return fmt.Errorf("%v has nothing at $%04X", bank, addr)
+}
+
+// bankSegment returns the segment that holds addr in the given bank (which
+// must contain it, and must not be BankMapped), and where in that segment
+// addr is.
+func (c *Computer) bankSegment(bank Bank, addr int) (*memory.Segment, int) {
+	var (
+		main  = c.Main
+		aux   = c.State.Segment(a2state.MemAuxSegment)
+		bank2 = addr >= 0xD000 && addr < 0xE000
+	)
+
+	switch bank {
+	case BankMain:
+		bank2 = bank2 && c.State.Bool(a2state.BankDFBlockBank2)
+		return main, bankOffset(addr, bank2)
+	case BankAux:
+		bank2 = bank2 && c.State.Bool(a2state.BankDFBlockBank2)
+		return aux, bankOffset(addr, bank2)
+	case BankLC1:
+		return main, addr
+	case BankLC2:
+		return main, bankOffset(addr, bank2)
+	case BankAuxLC1:
+		return aux, addr
+	case BankAuxLC2:
+		return aux, bankOffset(addr, bank2)
+	}
+
+	return c.ROM, addr - SysRomOffset
+}
+
+// bankOffset returns where addr is in main or auxiliary memory. Bank 2 of
+// the language card's $D000-$DFFF is kept past the end of the 64k that the
+// CPU can address.
+func bankOffset(addr int, bank2 bool) int {
+	if bank2 {
+		return addr + 0x3000
+	}
+
+	return addr
+}
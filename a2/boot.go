@@ -3,9 +3,13 @@ package a2
 import (
 	"time"
 
+	"github.com/pevans/erc/a2/a2annunciator"
 	"github.com/pevans/erc/a2/a2bank"
+	"github.com/pevans/erc/a2/a2cassette"
+	"github.com/pevans/erc/a2/a2clock"
 	"github.com/pevans/erc/a2/a2disk"
 	"github.com/pevans/erc/a2/a2display"
+	"github.com/pevans/erc/a2/a2hd"
 	"github.com/pevans/erc/a2/a2kb"
 	"github.com/pevans/erc/a2/a2memory"
 	"github.com/pevans/erc/a2/a2peripheral"
@@ -32,7 +36,11 @@ const (
 // also be called a cold start of the computer, and this occurs only when the
 // computer is switched from a powered-off to a powered-on state.
 func (c *Computer) Boot() error {
-	_, err := c.ROM.CopySlice(0, obj.SystemROM())
+	if err := c.checkROM(c.Model()); err != nil {
+		return err
+	}
+
+	_, err := c.ROM.CopySlice(0, c.sysROM())
 	if err != nil {
 		return err
 	}
@@ -42,6 +50,26 @@ func (c *Computer) Boot() error {
 		return err
 	}
 
+	// The clock card's firmware lives in its slot's page of peripheral ROM.
+	_, err = c.ROM.CopySlice(
+		len(obj.SystemROM())+(a2clock.Slot<<8), a2clock.Firmware(),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Likewise for the block device, but only if it has something to offer.
+	// Otherwise we'd boot to an empty device and ProDOS would think it had
+	// one more volume than it really does.
+	if c.HardDisk(1).Loaded() || c.HardDisk(2).Loaded() {
+		_, err = c.ROM.CopySlice(
+			len(obj.SystemROM())+(a2hd.Slot<<8), a2hd.Firmware(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Set the initial reset vector to point to the AppleSoft BASIC system.
 	c.Main.Set(BootVector, uint8(AppleSoft&0xFF))
 	c.Main.Set(BootVector+1, uint8(AppleSoft>>8))
@@ -62,20 +90,25 @@ func (c *Computer) Reset() {
 	c.CPU.P = mos.INTERRUPT | mos.BREAK | mos.UNUSED
 
 	// When reset, the stack goes to its top (which is the end of the stack
-	// page).
+	// page), so any calls that were on it are gone.
 	c.CPU.S = 0xFF
+	c.CPU.Calls.Reset()
 
 	c.State.SetAny(a2state.Computer, c)
 
 	// Set our initial memory mode
 	a2bank.UseDefaults(c.State, c.Main, c.ROM)
 	a2display.UseDefaults(c.State, c.Aux)
+	a2annunciator.UseDefaults(c.State)
 	a2peripheral.UseDefaults(c.State, c.ROM)
 	c.State.SetAny(a2state.KBMutex, &c.keyPressMutex)
 	a2kb.UseDefaults(c.State)
-	a2memory.UseDefaults(c.State, c.Main, c.Aux)
+	a2memory.UseDefaults(c.State, c.Main, c.auxBanks)
 	a2disk.UseDefaults(c.State)
 	a2speaker.UseDefaults(c.State)
+	a2clock.UseDefaults(c.State)
+	a2hd.UseDefaults(c.State)
+	a2cassette.UseDefaults(c.State)
 
 	c.BootTime = time.Now()
 
@@ -1,6 +1,10 @@
 package a2
 
 import (
+	"os"
+	"path/filepath"
+
+	"github.com/pevans/erc/a2/a2hd"
 	"github.com/pevans/erc/a2/a2state"
 	"github.com/pevans/erc/mos"
 )
@@ -32,3 +36,33 @@ func (s *a2Suite) TestReset() {
 	s.Equal(uint8(0xFF), c.CPU.S)
 	s.True(c.State.Bool(a2state.DisplayText))
 }
+
+func (s *a2Suite) TestBootHardDisk() {
+	// A boot block that records the X register it was given (the slot
+	// number, times 16) and then spins in place
+	image := make([]uint8, 4*a2hd.BlockSize)
+	copy(image, []uint8{
+		0x01,             // the number of boot blocks
+		0x8E, 0x00, 0x03, // STX $0300
+		0x4C, 0x04, 0x08, // JMP $0804
+	})
+
+	file := filepath.Join(s.T().TempDir(), "hd.hdv")
+	s.NoError(os.WriteFile(file, image, 0o644))
+
+	c := NewComputer(1)
+	s.NoError(c.LoadHardDisk(1, file))
+	s.NoError(c.Boot())
+
+	for range 2_000_000 {
+		if c.CPU.PC == 0x0804 {
+			break
+		}
+
+		_, err := c.Process()
+		s.NoError(err)
+	}
+
+	s.Equal(uint16(0x0804), c.CPU.PC)
+	s.Equal(uint8(a2hd.Slot<<4), c.Main.Get(0x0300))
+}
@@ -0,0 +1,36 @@
+package a2
+
+import "time"
+
+// SetClockTime fixes the time that the clock card reports to the given time,
+// plus however much time the emulated CPU has spent running. Since that is
+// measured in cycles rather than by the host, the clock reads the same at the
+// same point of every run.
+func (c *Computer) SetClockTime(t time.Time) {
+	c.clockFixed = t
+}
+
+// SetClockOffset shifts the host time that the clock card reports by the
+// given duration. This has no effect if SetClockTime was used.
+func (c *Computer) SetClockOffset(d time.Duration) {
+	c.clockOffset = d
+}
+
+// ClockTime returns the time that the clock card should report.
+func (c *Computer) ClockTime() time.Time {
+	if c.clockFixed.IsZero() {
+		return time.Now().Add(c.clockOffset)
+	}
+
+	// We don't go through time.Duration for the full cycle count, since
+	// that would overflow after a couple of hours of emulated time.
+	var (
+		cycles  = int64(c.CPU.CycleCounter())
+		seconds = cycles / appleMhz
+		rest    = cycles % appleMhz
+	)
+
+	return c.clockFixed.
+		Add(time.Duration(seconds) * time.Second).
+		Add(time.Duration(rest) * time.Second / time.Duration(appleMhz))
+}
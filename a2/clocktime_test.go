@@ -0,0 +1,66 @@
+package a2
+
+import "time"
+
+func (s *a2Suite) TestClockTime() {
+	s.Run("offset from host time", func() {
+		c := NewComputer(1)
+		c.SetClockOffset(-48 * time.Hour)
+
+		s.WithinDuration(time.Now().Add(-48*time.Hour), c.ClockTime(), time.Minute)
+	})
+
+	s.Run("fixed time advances with cycles", func() {
+		start := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+		c := NewComputer(1)
+		c.SetClockTime(start)
+		s.Equal(start, c.ClockTime())
+
+		state := c.CPU.Snapshot()
+		state.CycleCounter = uint64(appleMhz)*90 + uint64(appleMhz)/2
+		s.NoError(c.CPU.Restore(state))
+
+		s.Equal(start.Add(90*time.Second+500*time.Millisecond), c.ClockTime())
+
+		// This is far past the point where nanoseconds would overflow
+		state.CycleCounter = uint64(appleMhz) * 86400 * 365
+		s.NoError(c.CPU.Restore(state))
+		s.Equal(start.Add(365*24*time.Hour), c.ClockTime())
+	})
+}
+
+func (s *a2Suite) TestClockCard() {
+	c := NewComputer(1)
+	s.NoError(c.Boot())
+
+	c.SetClockTime(time.Date(2024, time.January, 31, 9, 30, 15, 0, time.UTC))
+
+	// This is roughly what the ProDOS clock driver does: set the numeric
+	// mode, then ask for the time
+	program := []uint8{
+		0xA9, 0xA3, // LDA #'#'
+		0x20, 0x0B, 0xC1, // JSR $C10B
+		0x20, 0x08, 0xC1, // JSR $C108
+		0x4C, 0x08, 0x03, // JMP $0308
+	}
+
+	for i, b := range program {
+		c.Main.Set(0x300+i, b)
+	}
+
+	c.CPU.PC = 0x300
+	for range 200 {
+		_, err := c.Process()
+		s.NoError(err)
+	}
+
+	s.Equal(uint16(0x308), c.CPU.PC)
+
+	var got []byte
+	for i := 0x200; c.Main.Get(i) != 0x8D; i++ {
+		got = append(got, c.Main.Get(i)&0x7F)
+	}
+
+	s.Equal("01,03,31,09,30,15", string(got))
+}
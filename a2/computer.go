@@ -5,9 +5,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pevans/erc/a2/a2annunciator"
+	"github.com/pevans/erc/a2/a2cassette"
+	"github.com/pevans/erc/a2/a2coverage"
 	"github.com/pevans/erc/a2/a2display"
 	"github.com/pevans/erc/a2/a2drive"
 	"github.com/pevans/erc/a2/a2font"
+	"github.com/pevans/erc/a2/a2hd"
+	"github.com/pevans/erc/a2/a2memory"
+	"github.com/pevans/erc/a2/a2prof"
 	"github.com/pevans/erc/a2/a2speaker"
 	"github.com/pevans/erc/a2/a2state"
 	"github.com/pevans/erc/clock"
@@ -62,6 +68,11 @@ type Computer struct {
 	// for a while).
 	BootTime time.Time
 
+	// The clock card reports host time, shifted by clockOffset, unless
+	// clockFixed is set; see ClockTime.
+	clockFixed  time.Time
+	clockOffset time.Duration
+
 	// There are three primary segments of memory in an Apple //e; main
 	// memory, read-only memory, and auxiliary memory. Each are accessible
 	// through a mechanism called bank-switching.
@@ -69,6 +80,25 @@ type Computer struct {
 	ROM  *memory.Segment
 	Aux  *memory.Segment
 
+	// systemROM, if set, is the system ROM we boot with in place of the one
+	// we embed (see UseSystemROM).
+	systemROM []uint8
+
+	// auxBanks holds every 64K bank of auxiliary memory, as you might have
+	// with a RamWorks card. Aux is always the first bank, and is the only
+	// one the display will show.
+	auxBanks []*memory.Segment
+
+	// coverage, if set, is where we record which parts of memory have been
+	// executed, read and written.
+	coverage *a2coverage.Map
+
+	// profiler, if set, keeps track of the cycles spent in each subroutine.
+	profiler *a2prof.Profiler
+
+	// watchpoints are what the CPU checks its reads and writes against.
+	watchpoints *memory.Watchpoints
+
 	Screen *gfx.FrameBuffer
 
 	// displaySnapshot holds a point-in-time copy of display memory for
@@ -95,6 +125,17 @@ type Computer struct {
 	drive2        *a2drive.Drive
 	selectedDrive *a2drive.Drive
 
+	// These are the volumes of the block device in slot 7, which we use for
+	// hard disk images.
+	hd1 *a2hd.Volume
+	hd2 *a2hd.Volume
+
+	// tape is the cassette in the tape recorder. If tapeFileName is set,
+	// whatever the computer records to the tape is written there on
+	// shutdown.
+	tape         *a2cassette.Tape
+	tapeFileName string
+
 	// diskLogFileName is the name we'll use to write the diskLog
 	diskLogFileName string
 
@@ -169,6 +210,10 @@ const (
 
 	// SysRomOffset is the spot in memory where system ROM can be found.
 	SysRomOffset = 0xC000
+
+	// MaxAuxBanks is the largest number of auxiliary memory banks we allow,
+	// which (at 64k apiece) makes for 8MB of auxiliary memory.
+	MaxAuxBanks = 128
 )
 
 const (
@@ -213,10 +258,17 @@ func NewComputer(speed int) *Computer {
 	comp.Main.UseSoftMap(comp.smap)
 	comp.ROM.UseSoftMap(comp.smap)
 
+	comp.auxBanks = []*memory.Segment{comp.Aux}
+
 	comp.drive1 = a2drive.NewDrive()
 	comp.drive2 = a2drive.NewDrive()
 	comp.selectedDrive = comp.drive1
 
+	comp.hd1 = a2hd.NewVolume()
+	comp.hd2 = a2hd.NewVolume()
+
+	comp.tape = a2cassette.NewTape(appleMhz)
+
 	comp.Disks = NewDiskSet()
 
 	comp.CPU = new(mos.CPU)
@@ -224,6 +276,11 @@ func NewComputer(speed int) *Computer {
 	comp.CPU.WMem = comp
 	comp.CPU.State = comp.State
 
+	comp.watchpoints = memory.NewWatchpoints()
+	comp.CPU.Watch = comp.watchpoints
+	comp.CPU.Calls = mos.NewCallStack()
+	comp.smap.UseWatchpoints(comp.watchpoints, 0xC000, 0xC100)
+
 	// Note that hertz is treated as a unit of cycles per second, but the
 	// number may not feel precisely accurate to how an Apple II might have
 	// run. I've found that if I use 1.023 MHz, the Apple IIe speed, things
@@ -491,6 +548,61 @@ func (c *Computer) SelectDrive(n int) {
 	}
 }
 
+// Annunciator returns true if the given annunciator (0-3) is on. Slot cards
+// that use the annunciators as output lines can use this to see them.
+func (c *Computer) Annunciator(n int) bool {
+	return a2annunciator.On(n, c.State)
+}
+
+// HardDisk returns the block device volume with the specified number (1 or
+// 2). Any value other than 1 is treated as 2.
+func (c *Computer) HardDisk(n int) *a2hd.Volume {
+	if n == 1 {
+		return c.hd1
+	}
+
+	return c.hd2
+}
+
+// SetAuxBanks changes the number of 64k banks of auxiliary memory that the
+// computer has. The first bank (Aux) is kept as it is; any other banks that
+// are kept also retain their contents. This should be done before the
+// computer boots.
+func (c *Computer) SetAuxBanks(n int) error {
+	if n < 1 || n > MaxAuxBanks {
+		return fmt.Errorf("number of aux banks must be between 1 and %d", MaxAuxBanks)
+	}
+
+	for len(c.auxBanks) < n {
+		bank := memory.NewSegment(AuxMemorySize)
+		bank.UseSoftMap(c.smap)
+		c.auxBanks = append(c.auxBanks, bank)
+	}
+
+	c.auxBanks = c.auxBanks[:n]
+	c.State.SetAny(a2state.MemAuxBanks, c.auxBanks)
+
+	// Make sure we aren't left pointing at a bank we just removed
+	a2memory.SelectAuxBank(c.State.Int(a2state.MemAuxBank), c.State)
+
+	return nil
+}
+
+// AuxBanks returns the number of banks of auxiliary memory.
+func (c *Computer) AuxBanks() int {
+	return len(c.auxBanks)
+}
+
+// AuxBank returns the auxiliary memory segment for the given bank, or nil if
+// there is no such bank.
+func (c *Computer) AuxBank(n int) *memory.Segment {
+	if n < 0 || n >= len(c.auxBanks) {
+		return nil
+	}
+
+	return c.auxBanks[n]
+}
+
 // ClockEmulator returns the clock emulator.
 func (c *Computer) ClockEmulator() *clock.Emulator {
 	return c.clockEmulator
@@ -501,6 +613,36 @@ func (c *Computer) CycleCounter() uint64 {
 	return c.CPU.CycleCounter()
 }
 
+// SetHistory has the CPU keep a history of the last n instructions it
+// executed, which the debugger can show and step back through. If n is zero,
+// no history is kept.
+func (c *Computer) SetHistory(n int) error {
+	if n < 0 {
+		return fmt.Errorf("history size must not be negative (%d given)", n)
+	}
+
+	c.CPU.History = nil
+	if n > 0 {
+		c.CPU.History = mos.NewHistory(n)
+	}
+
+	return nil
+}
+
+// ObserveBus adds a bus that observes each access the CPU makes to memory,
+// on the cycle it makes it. Slot cards and the like can use this to see the
+// dummy reads that the CPU makes along with its real ones.
+func (c *Computer) ObserveBus(b mos.Bus) {
+	switch bus := c.CPU.Bus.(type) {
+	case nil:
+		c.CPU.Bus = b
+	case mos.Buses:
+		c.CPU.Bus = append(bus, b)
+	default:
+		c.CPU.Bus = mos.Buses{bus, b}
+	}
+}
+
 // Speaker returns the speaker buffer.
 func (c *Computer) Speaker() a2speaker.Speaker {
 	// Explicit nil check needed because c.speaker is a concrete pointer type;
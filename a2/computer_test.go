@@ -1,6 +1,10 @@
 package a2
 
-import "github.com/pevans/erc/a2/a2state"
+import (
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/memory"
+	"github.com/pevans/erc/mos"
+)
 
 // mockAudioStream is a mock implementation of AudioStream for testing.
 type mockAudioStream struct {
@@ -352,3 +356,199 @@ func (s *a2Suite) TestPressKey() {
 		s.Equal(uint8(0x80), s.comp.State.Uint8(a2state.KBKeyDown))
 	})
 }
+
+// busLog is a bus that remembers every access it observes.
+type busLog struct {
+	accesses []mos.BusAccess
+}
+
+func (b *busLog) Observe(access mos.BusAccess) {
+	b.accesses = append(b.accesses, access)
+}
+
+func (s *a2Suite) TestObserveBus() {
+	c := NewComputer(1)
+	s.NoError(c.Boot())
+
+	first, second := new(busLog), new(busLog)
+	c.ObserveBus(first)
+	c.ObserveBus(second)
+
+	c.Main.Set(0x300, 0xBD) // LDA $20FF,X
+	c.Main.Set(0x301, 0xFF)
+	c.Main.Set(0x302, 0x20)
+	c.CPU.X = 1
+	c.CPU.PC = 0x300
+
+	start := c.CycleCounter()
+	_, err := c.Process()
+	s.NoError(err)
+
+	// The opcode, two operand bytes, the dummy read for the page crossing,
+	// and then the read of $2100
+	s.Len(first.accesses, 5)
+	s.Equal(first.accesses, second.accesses)
+
+	dummy := first.accesses[3]
+	s.True(dummy.Dummy)
+	s.Equal(uint16(0x302), dummy.Addr)
+	s.Equal(start+3, dummy.Cycle)
+	s.Equal(uint16(0x2100), first.accesses[4].Addr)
+	s.Equal(start+5, c.CycleCounter())
+}
+
+func (s *a2Suite) TestSetHistory() {
+	c := NewComputer(1)
+	s.NoError(c.Boot())
+
+	s.Error(c.SetHistory(-1))
+	s.NoError(c.SetHistory(0))
+	s.Nil(c.CPU.History)
+	s.NoError(c.SetHistory(100))
+
+	c.Main.Set(0x300, 0x8D) // STA $2000
+	c.Main.Set(0x301, 0x00)
+	c.Main.Set(0x302, 0x20)
+	c.Main.Set(0x2000, 0x11)
+	c.CPU.A = 0x42
+	c.CPU.PC = 0x300
+
+	_, err := c.Process()
+	s.NoError(err)
+	s.Equal(uint8(0x42), c.Main.Get(0x2000))
+
+	s.Equal(1, c.CPU.Back(1))
+	s.Equal(uint8(0x11), c.Main.Get(0x2000))
+	s.Equal(uint16(0x300), c.CPU.PC)
+}
+
+func (s *a2Suite) TestHistoryBanks() {
+	c := NewComputer(1)
+	s.NoError(c.Boot())
+	s.NoError(c.SetHistory(100))
+
+	// With ALTZP on, the zero page is in auxiliary memory, and with the
+	// language card set to write bank 2, $D000 is at $10000 in the segment
+	c.State.SetSegment(a2state.BankSysBlockSegment, c.Aux)
+	c.State.SetBool(a2state.BankWriteRAM, true)
+	c.State.SetBool(a2state.BankDFBlockBank2, true)
+
+	c.Main.Set(0x300, 0x85) // STA $10
+	c.Main.Set(0x301, 0x10)
+	c.Main.Set(0x302, 0x8D) // STA $D000
+	c.Main.Set(0x303, 0x00)
+	c.Main.Set(0x304, 0xD0)
+	c.Aux.DirectSet(0x10, 0x11)
+	c.Aux.DirectSet(0x10000, 0x22)
+	c.CPU.A = 0x42
+	c.CPU.PC = 0x300
+
+	for range 2 {
+		_, err := c.Process()
+		s.NoError(err)
+	}
+
+	s.Equal(uint8(0x42), c.Aux.DirectGet(0x10))
+	s.Equal(uint8(0x42), c.Aux.DirectGet(0x10000))
+
+	s.Equal(2, c.CPU.Back(2))
+	s.Equal(uint8(0x11), c.Aux.DirectGet(0x10))
+	s.Equal(uint8(0x22), c.Aux.DirectGet(0x10000))
+	s.Equal(uint8(0x00), c.Main.DirectGet(0x10))
+}
+
+func (s *a2Suite) TestHistoryBankSwitch() {
+	c := NewComputer(1)
+	s.NoError(c.Boot())
+	s.NoError(c.SetHistory(100))
+
+	// Start out writing to main memory, with the language card writing to
+	// bank 2
+	c.State.SetBool(a2state.BankWriteRAM, true)
+	c.State.SetBool(a2state.BankDFBlockBank2, true)
+
+	program := []uint8{
+		0x85, 0x10, // STA $10
+		0x8D, 0x00, 0x20, // STA $2000
+		0x8D, 0x00, 0xD0, // STA $D000
+		0x8D, 0x09, 0xC0, // STA $C009 (ALTZP on)
+		0x8D, 0x05, 0xC0, // STA $C005 (RAMWRT on)
+		0x2C, 0x8B, 0xC0, // BIT $C08B
+		0x2C, 0x8B, 0xC0, // BIT $C08B (write to bank 1)
+		0x85, 0x10, // STA $10
+		0x8D, 0x00, 0x20, // STA $2000
+		0x8D, 0x00, 0xD0, // STA $D000
+	}
+
+	for i, b := range program {
+		c.Main.Set(0x300+i, b)
+	}
+
+	c.Main.DirectSet(0x10, 0x01)
+	c.Main.DirectSet(0x2000, 0x02)
+	c.Main.DirectSet(0x10000, 0x03)
+	c.Aux.DirectSet(0x10, 0x04)
+	c.Aux.DirectSet(0x2000, 0x05)
+	c.Aux.DirectSet(0xD000, 0x06)
+	c.CPU.A = 0x42
+	c.CPU.PC = 0x300
+
+	for range 10 {
+		_, err := c.Process()
+		s.NoError(err)
+	}
+
+	s.Equal(uint8(0x42), c.Main.DirectGet(0x10))
+	s.Equal(uint8(0x42), c.Main.DirectGet(0x2000))
+	s.Equal(uint8(0x42), c.Main.DirectGet(0x10000))
+	s.Equal(uint8(0x42), c.Aux.DirectGet(0x10))
+	s.Equal(uint8(0x42), c.Aux.DirectGet(0x2000))
+	s.Equal(uint8(0x42), c.Aux.DirectGet(0xD000))
+
+	// The switches stay where the program left them, but each byte goes
+	// back to where it was written at the time
+	s.Equal(10, c.CPU.Back(10))
+	s.Equal(uint8(0x01), c.Main.DirectGet(0x10))
+	s.Equal(uint8(0x02), c.Main.DirectGet(0x2000))
+	s.Equal(uint8(0x03), c.Main.DirectGet(0x10000))
+	s.Equal(uint8(0x04), c.Aux.DirectGet(0x10))
+	s.Equal(uint8(0x05), c.Aux.DirectGet(0x2000))
+	s.Equal(uint8(0x06), c.Aux.DirectGet(0xD000))
+	s.Equal(uint16(0x300), c.CPU.PC)
+}
+
+func (s *a2Suite) TestWatchpoints() {
+	c := NewComputer(1)
+	s.NoError(c.Boot())
+
+	w := c.Watchpoints()
+	s.NoError(w.Add(memory.Watchpoint{Kind: memory.WatchWrite, From: 0x2000, To: 0x2000}))
+	s.NoError(w.Add(memory.Watchpoint{Kind: memory.WatchRead, From: 0xC030, To: 0xC030}))
+
+	c.Main.Set(0x300, 0x8D) // STA $2000
+	c.Main.Set(0x301, 0x00)
+	c.Main.Set(0x302, 0x20)
+	c.Main.Set(0x303, 0xAD) // LDA $C030
+	c.Main.Set(0x304, 0x30)
+	c.Main.Set(0x305, 0xC0)
+	c.Main.Set(0x2000, 0x11)
+	c.CPU.A = 0x42
+	c.CPU.PC = 0x300
+
+	_, err := c.Process()
+	s.NoError(err)
+
+	hits := w.Hits()
+	s.Require().Len(hits, 1)
+	s.Equal(uint8(0x11), hits[0].Old)
+	s.Equal(uint8(0x42), hits[0].New)
+	s.False(hits[0].SoftSwitch)
+
+	_, err = c.Process()
+	s.NoError(err)
+
+	hits = w.Hits()
+	s.Require().Len(hits, 1)
+	s.Equal(0x303, hits[0].PC)
+	s.True(hits[0].SoftSwitch)
+}
@@ -0,0 +1,148 @@
+package a2
+
+import (
+	"fmt"
+
+	"github.com/pevans/erc/a2/a2coverage"
+	"github.com/pevans/erc/a2/a2display"
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/memory"
+	"github.com/pevans/erc/mos"
+)
+
+// coverageBus records the reads and writes that the CPU makes in the
+// computer's coverage map.
+type coverageBus struct {
+	comp *Computer
+}
+
+// StartCoverage begins recording which parts of memory are executed, read
+// and written, and returns the map that they're recorded in.
+func (c *Computer) StartCoverage() *a2coverage.Map {
+	if c.coverage == nil {
+		c.coverage = a2coverage.NewMap()
+		c.ObserveBus(&coverageBus{comp: c})
+	}
+
+	return c.coverage
+}
+
+// Coverage returns the coverage map, or nil if we aren't recording one.
+func (c *Computer) Coverage() *a2coverage.Map {
+	return c.coverage
+}
+
+// Observe records a read or write in the coverage map. We skip the reads of
+// the instruction itself, since those are counted as execution, and the
+// dummy reads, since the software didn't ask for those.
+func (b *coverageBus) Observe(access mos.BusAccess) {
+	c := b.comp
+	addr := int(access.Addr)
+
+	if access.Dummy {
+		return
+	}
+
+	kind := a2coverage.Write
+	if !access.Write {
+		kind = a2coverage.Read
+
+		start := int(c.CPU.LastPC)
+		if addr >= start && addr <= start+int(c.CPU.Variant.OperandSize(c.CPU.Opcode())) {
+			return
+		}
+	}
+
+	if r := c.coverageRegion(addr, access.Write); r != nil {
+		r.Record(addr, kind)
+	}
+}
+
+// recordExec records the execution of the instruction at the PC. We need to
+// do this before the instruction runs, since it may change which memory is
+// at the PC.
+func (c *Computer) recordExec() {
+	pc := int(c.CPU.PC)
+	size := 1 + int(c.CPU.Variant.OperandSize(c.Peek(pc)))
+
+	for i := range size {
+		addr := (pc + i) & 0xFFFF
+		if r := c.coverageRegion(addr, false); r != nil {
+			r.Record(addr, a2coverage.Exec)
+		}
+	}
+}
+
+// coverageRegion returns the coverage region that the CPU would read (or
+// write) if it accessed addr, given the current memory state. Some accesses
+// don't go to memory at all (like those of soft switches, or writes to ROM),
+// in which case we return nil.
+func (c *Computer) coverageRegion(addr int, write bool) *a2coverage.Region {
+	segmentKey := a2state.MemReadSegment
+	if write {
+		segmentKey = a2state.MemWriteSegment
+	}
+
+	switch {
+	case addr < 0x200:
+		// The zero page and stack follow the language card's choice of
+		// main or auxiliary memory
+		name := c.segmentName(c.State.Segment(a2state.BankSysBlockSegment))
+		return c.coverage.Region(name, 0x0000, 0xC000)
+
+	case addr < 0xC000:
+		name := c.segmentName(a2display.Segment(addr, c.State, segmentKey))
+		return c.coverage.Region(name, 0x0000, 0xC000)
+
+	case addr < 0xC100:
+		return nil
+
+	case addr < 0xD000:
+		// Whether this is the internal ROM or that of a peripheral, it's
+		// all ROM to us
+		if write {
+			return nil
+		}
+
+		return c.coverage.Region("rom", 0xC100, 0x10000)
+	}
+
+	if write && !c.State.Bool(a2state.BankWriteRAM) {
+		return nil
+	}
+
+	if !write && !c.State.Bool(a2state.BankReadRAM) {
+		return c.coverage.Region("rom", 0xC100, 0x10000)
+	}
+
+	name := c.segmentName(c.State.Segment(a2state.BankSysBlockSegment))
+
+	if addr >= 0xE000 {
+		return c.coverage.Region(name+"-lc", 0xE000, 0x10000)
+	}
+
+	if c.State.Bool(a2state.BankDFBlockBank2) {
+		return c.coverage.Region(name+"-lc2", 0xD000, 0xE000)
+	}
+
+	return c.coverage.Region(name+"-lc1", 0xD000, 0xE000)
+}
+
+// segmentName returns the name we use in coverage for a segment of memory,
+// which is either main, aux (for the first bank of auxiliary memory), or
+// auxN for the other banks.
+func (c *Computer) segmentName(seg *memory.Segment) string {
+	for i, bank := range c.auxBanks {
+		if seg != bank {
+			continue
+		}
+
+		if i == 0 {
+			return "aux"
+		}
+
+		return fmt.Sprintf("aux%d", i)
+	}
+
+	return "main"
+}
@@ -0,0 +1,69 @@
+package a2
+
+import (
+	"github.com/pevans/erc/a2/a2coverage"
+	"github.com/pevans/erc/a2/a2state"
+)
+
+func (s *a2Suite) TestCoverage() {
+	c := NewComputer(1)
+	s.NoError(c.Boot())
+	s.Nil(c.Coverage())
+
+	cov := c.StartCoverage()
+	s.Same(cov, c.StartCoverage())
+
+	program := []uint8{
+		0xAD, 0x00, 0x20, // LDA $2000
+		0x8D, 0x00, 0xD0, // STA $D000
+		0x8D, 0x05, 0xC0, // STA $C005 (write to aux memory)
+		0x8D, 0x00, 0x30, // STA $3000
+	}
+
+	for i, b := range program {
+		c.Main.Set(0x300+i, b)
+	}
+
+	// Write to the second bank of language card RAM
+	c.State.SetBool(a2state.BankWriteRAM, true)
+	c.State.SetBool(a2state.BankDFBlockBank2, true)
+
+	c.CPU.PC = 0x300
+	for range 4 {
+		_, err := c.Process()
+		s.NoError(err)
+	}
+
+	main := cov.Region("main", 0, 0xC000)
+	s.Equal(len(program), main.Covered(a2coverage.Exec))
+	s.Equal(uint32(1), main.Count(0x300, a2coverage.Exec))
+	s.Equal(uint32(1), main.Count(0x2000, a2coverage.Read))
+	s.Equal(uint32(0), main.Count(0x300, a2coverage.Read))
+
+	lc := cov.Region("main-lc2", 0xD000, 0xE000)
+	s.Equal(uint32(1), lc.Count(0xD000, a2coverage.Write))
+
+	aux := cov.Region("aux", 0, 0xC000)
+	s.Equal(uint32(1), aux.Count(0x3000, a2coverage.Write))
+}
+
+func (s *a2Suite) TestCoverageSwitches() {
+	c := NewComputer(1)
+	s.NoError(c.Boot())
+	cov := c.StartCoverage()
+
+	// Reading $CFFF turns off expansion ROM, so if coverage were to read
+	// the opcode before the CPU does, the CPU would see the peripheral ROM
+	// rather than the expansion ROM we have on
+	c.ROM.DirectSet(0x0FFF, 0xEA) // NOP
+	c.ROM.DirectSet(0x4FFF, 0xA9) // LDA #
+	c.State.SetBool(a2state.PCSlotCX, true)
+	c.State.SetBool(a2state.PCIOSelect, true)
+
+	c.CPU.PC = 0xCFFF
+	_, err := c.Process()
+	s.NoError(err)
+
+	s.Equal(uint16(0xD000), c.CPU.PC)
+	s.Equal(1, cov.Region("rom", 0xC100, 0x10000).Covered(a2coverage.Exec))
+}
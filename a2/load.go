@@ -3,6 +3,7 @@ package a2
 import (
 	"fmt"
 	"io"
+	"os"
 	"path"
 
 	"github.com/pevans/erc/a2/a2state"
@@ -64,6 +65,20 @@ func (c *Computer) Load(r io.Reader, fileName string) error {
 	return nil
 }
 
+// LoadHardDisk will load the image file with the given name into a volume
+// (1 or 2) of the block device. This must happen before Boot if you want the
+// block device to show up at all.
+func (c *Computer) LoadHardDisk(n int, fileName string) error {
+	data, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("could not open file %v: %w", fileName, err)
+	}
+
+	defer data.Close() //nolint:errcheck
+
+	return c.HardDisk(n).Load(data, fileName)
+}
+
 // LoadFirst will load the first disk in the diskset, regardless of the
 // diskset's current index. Note that this will _alter_ the index to become
 // that of the first disk. An error is returned if the disk can't be loaded.
@@ -2,6 +2,9 @@ package a2
 
 import (
 	"os"
+	"path/filepath"
+
+	"github.com/pevans/erc/a2/a2hd"
 )
 
 func (s *a2Suite) TestComputerLoad() {
@@ -14,3 +17,15 @@ func (s *a2Suite) TestComputerLoad() {
 
 	s.Error(s.comp.Load(nil, "bad"))
 }
+
+func (s *a2Suite) TestComputerLoadHardDisk() {
+	file := filepath.Join(s.T().TempDir(), "hd.hdv")
+	s.NoError(os.WriteFile(file, make([]uint8, 4*a2hd.BlockSize), 0o644))
+
+	c := NewComputer(1)
+	s.NoError(c.LoadHardDisk(1, file))
+	s.Equal(4, c.HardDisk(1).Blocks())
+	s.False(c.HardDisk(2).Loaded())
+
+	s.Error(c.LoadHardDisk(2, filepath.Join(s.T().TempDir(), "nothing.hdv")))
+}
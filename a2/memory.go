@@ -1,6 +1,9 @@
 package a2
 
 import (
+	"github.com/pevans/erc/a2/a2bank"
+	"github.com/pevans/erc/a2/a2display"
+	"github.com/pevans/erc/a2/a2peripheral"
 	"github.com/pevans/erc/a2/a2state"
 	"github.com/pevans/erc/memory"
 )
@@ -25,6 +28,71 @@ func (c *Computer) Set16(addr int, val uint16) {
 	WriteSegment(c.State).Set16(addr, val)
 }
 
+// Peek returns the byte that the CPU would read at addr, but without
+// setting off any soft switch along the way. Soft switches have nothing we
+// can peek at, so in the I/O page ($C000-$C0FF), we return zero.
+func (c *Computer) Peek(addr int) uint8 {
+	switch {
+	case addr >= 0xC000 && addr < 0xC100:
+		return 0
+	case addr >= 0xC100 && addr < 0xD000:
+		return a2peripheral.Peek(addr, c.State)
+	}
+
+	return c.Get(addr)
+}
+
+// DirectGet returns the byte at addr where a write to addr would go, without
+// executing any read switch. Where a write wouldn't go to memory (the I/O
+// page, or ROM), we return what's in the segment we'd write to.
+func (c *Computer) DirectGet(addr int) uint8 {
+	seg, offset := c.WriteLocation(addr)
+	if seg == nil {
+		return WriteSegment(c.State).DirectGet(addr)
+	}
+
+	return seg.DirectGet(offset)
+}
+
+// DirectSet sets the byte at addr where a write to addr would go, without
+// executing any write switch. Where a write wouldn't go to memory, we do
+// nothing.
+func (c *Computer) DirectSet(addr int, val uint8) {
+	seg, offset := c.WriteLocation(addr)
+	if seg == nil {
+		return
+	}
+
+	seg.DirectSet(offset, val)
+}
+
+// WriteLocation returns the segment, and the offset within it, that a write
+// to addr would go to, given the current memory state. This follows the same
+// logic as the soft switches we map to each part of memory. If the write
+// wouldn't go to memory at all, the segment is nil.
+func (c *Computer) WriteLocation(addr int) (*memory.Segment, int) {
+	switch {
+	case addr < 0x200:
+		return a2bank.Segment(c.State), addr
+
+	case addr < 0xC000:
+		return a2display.Segment(addr, c.State, a2state.MemWriteSegment), addr
+
+	case addr < 0xD000:
+		return nil, 0
+	}
+
+	if !c.State.Bool(a2state.BankWriteRAM) {
+		return nil, 0
+	}
+
+	if c.State.Bool(a2state.BankDFBlockBank2) && addr < 0xE000 {
+		return a2bank.Segment(c.State), addr + 0x3000
+	}
+
+	return a2bank.Segment(c.State), addr
+}
+
 // MapRange will, given a range of addresses (from..to), set the read and
 // write map functions to those given.
 func (c *Computer) MapRange(from, to int, rfn memory.SoftRead, wfn memory.SoftWrite) {
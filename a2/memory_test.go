@@ -20,6 +20,18 @@ func (s *a2Suite) TestComputerGet() {
 	s.Equal(val, s.comp.Get(idx))
 }
 
+func (s *a2Suite) TestComputerPeek() {
+	s.comp.Main.DirectSet(0x300, 0x12)
+	s.Equal(uint8(0x12), s.comp.Peek(0x300))
+
+	// Peeking at a soft switch doesn't set it off
+	s.comp.State.SetBool(a2state.DisplayText, true)
+	s.Equal(uint8(0), s.comp.Peek(0xC050))
+	s.True(s.comp.State.Bool(a2state.DisplayText))
+
+	s.Equal(s.comp.Get(0xFFFC), s.comp.Peek(0xFFFC))
+}
+
 func (s *a2Suite) TestComputerSet() {
 	idx := 0x1
 	uidx := int(idx)
@@ -63,3 +75,83 @@ func (s *a2Suite) TestWriteSegment() {
 	s.comp.State.SetSegment(a2state.MemWriteSegment, s.comp.Aux)
 	s.Equal(s.comp.Aux, WriteSegment(s.comp.State))
 }
+
+func (s *a2Suite) TestAuxBanks() {
+	c := NewComputer(1)
+
+	s.Error(c.SetAuxBanks(0))
+	s.Error(c.SetAuxBanks(MaxAuxBanks + 1))
+
+	s.NoError(c.SetAuxBanks(8))
+	s.NoError(c.Boot())
+	s.Equal(8, c.AuxBanks())
+	s.Equal(c.Aux, c.AuxBank(0))
+	s.Nil(c.AuxBank(8))
+
+	// Write to aux memory in bank 5, then check that bank 0 is untouched
+	c.Set(0xC005, 0)
+	c.Set(0xC073, 5)
+	c.Set(0x1000, 0x55)
+	s.Equal(uint8(0x55), c.AuxBank(5).DirectGet(0x1000))
+	s.Equal(uint8(0x00), c.Aux.DirectGet(0x1000))
+
+	// Shrinking the number of banks should not leave us in a bank that no
+	// longer exists
+	s.NoError(c.SetAuxBanks(2))
+	s.Equal(1, c.State.Int(a2state.MemAuxBank))
+	s.Equal(c.AuxBank(1), c.State.Segment(a2state.MemWriteSegment))
+}
+
+func (s *a2Suite) TestParseBank() {
+	bank, ok := ParseBank("AUX")
+	s.True(ok)
+	s.Equal(BankAux, bank)
+	s.Equal("aux", bank.String())
+
+	_, ok = ParseBank("nope")
+	s.False(ok)
+}
+
+func (s *a2Suite) TestBankGet() {
+	s.comp.Aux.DirectSet(0x400, 0x12)
+	s.comp.Main.DirectSet(0xD000, 0x34)
+	s.comp.Main.DirectSet(0x10000, 0x56)
+
+	cases := []struct {
+		bank Bank
+		addr int
+		want uint8
+	}{
+		{BankAux, 0x400, 0x12},
+		{BankLC1, 0xD000, 0x34},
+		{BankLC2, 0xD000, 0x56},
+		{BankROM, 0xFFFC, 0x62},
+		{BankMapped, 0xFFFC, 0x62},
+	}
+
+	for _, c := range cases {
+		val, err := s.comp.BankGet(c.bank, c.addr)
+		s.NoError(err)
+		s.Equal(c.want, val, "%v:%04X", c.bank, c.addr)
+	}
+
+	// Main memory holds whichever language card bank is selected
+	s.comp.State.SetBool(a2state.BankDFBlockBank2, true)
+	val, _ := s.comp.BankGet(BankMain, 0xD000)
+	s.Equal(uint8(0x56), val)
+
+	s.comp.State.SetBool(a2state.BankDFBlockBank2, false)
+	val, _ = s.comp.BankGet(BankMain, 0xD000)
+	s.Equal(uint8(0x34), val)
+
+	_, err := s.comp.BankGet(BankLC1, 0x400)
+	s.Error(err)
+}
+
+func (s *a2Suite) TestBankSet() {
+	s.NoError(s.comp.BankSet(BankAuxLC2, 0xD010, 0x78))
+	s.Equal(uint8(0x78), s.comp.Aux.DirectGet(0x10010))
+
+	s.Error(s.comp.BankSet(BankROM, 0xF800, 0))
+	s.Error(s.comp.BankSet(BankROM, 0x0800, 0))
+}
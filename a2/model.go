@@ -0,0 +1,143 @@
+package a2
+
+import (
+	"fmt"
+
+	"github.com/pevans/erc/mos"
+	"github.com/pevans/erc/obj"
+)
+
+// A Model is the kind of Apple //e that we emulate. For now, the models only
+// differ in which processor they have.
+type Model int
+
+const (
+	// ModelIIeEnhanced is the enhanced //e, which has a 65C02. This is the
+	// default.
+	ModelIIeEnhanced Model = iota
+
+	// ModelIIe is the original //e, which has an NMOS 6502. The ROM we
+	// embed is the enhanced ROM, which is written for the 65C02, so this
+	// model can't boot until it's given an unenhanced ROM with
+	// UseSystemROM.
+	ModelIIe
+
+	// ModelIIeEnhancedWDC is an enhanced //e that's been upgraded with a
+	// Rockwell or WDC 65C02, which adds the bit instructions (RMB, SMB, BBR
+	// and BBS), WAI and STP.
+	ModelIIeEnhancedWDC
+)
+
+// models holds the name and processor of each model, and whether the model
+// needs a ROM other than the one we embed.
+var models = []struct {
+	name      string
+	variant   mos.Variant
+	ownSysROM bool
+}{
+	ModelIIeEnhanced:    {"iie-enhanced", mos.CMOS65C02, false},
+	ModelIIe:            {"iie", mos.NMOS6502, true},
+	ModelIIeEnhancedWDC: {"iie-enhanced-wdc", mos.WDC65C02, false},
+}
+
+// ParseModel returns the model with the given name (e.g. "iie-enhanced").
+func ParseModel(name string) (Model, error) {
+	for m, model := range models {
+		if model.name == name {
+			return Model(m), nil
+		}
+	}
+
+	return 0, fmt.Errorf(
+		"unknown model %q (must be iie-enhanced, iie or iie-enhanced-wdc)", name,
+	)
+}
+
+// String returns the name of the model, as ParseModel would accept it.
+func (m Model) String() string {
+	if int(m) < 0 || int(m) >= len(models) {
+		return "unknown"
+	}
+
+	return models[m].name
+}
+
+// CPUVariant returns the variant of the 6502 that the model has.
+func (m Model) CPUVariant() mos.Variant {
+	if int(m) < 0 || int(m) >= len(models) {
+		return mos.CMOS65C02
+	}
+
+	return models[m].variant
+}
+
+// SetModel changes the model of the computer, which should be done before it
+// boots.
+func (c *Computer) SetModel(m Model) {
+	c.CPU.Variant = m.CPUVariant()
+}
+
+// Model returns the model of the computer. Since the CPU is the only thing
+// that differs between models, that's what we go by (which means the model
+// survives a save state along with the CPU).
+func (c *Computer) Model() Model {
+	return modelOf(c.CPU.Variant)
+}
+
+// modelOf returns the model that has the given variant of the 6502.
+func modelOf(variant mos.Variant) Model {
+	for m, model := range models {
+		if model.variant == variant {
+			return Model(m)
+		}
+	}
+
+	return ModelIIeEnhanced
+}
+
+// UseSystemROM gives the computer a system ROM to boot with in place of the
+// enhanced ROM that we embed, such as the ROM of the original //e (which
+// ModelIIe needs). It must be the same size as the embedded ROM, which
+// covers $C000-$FFFF.
+func (c *Computer) UseSystemROM(rom []uint8) error {
+	if len(rom) != len(obj.SystemROM()) {
+		return fmt.Errorf(
+			"system ROM must be %d bytes, but is %d", len(obj.SystemROM()), len(rom),
+		)
+	}
+
+	c.systemROM = rom
+
+	return nil
+}
+
+// sysROM returns the system ROM that the computer boots with.
+func (c *Computer) sysROM() []uint8 {
+	if c.systemROM != nil {
+		return c.systemROM
+	}
+
+	return obj.SystemROM()
+}
+
+// NeedsSystemROM returns true if the model can't run with the system ROM
+// that we embed, and so must be given one with UseSystemROM.
+func (m Model) NeedsSystemROM() bool {
+	if int(m) < 0 || int(m) >= len(models) {
+		return false
+	}
+
+	return models[m].ownSysROM
+}
+
+// checkROM returns an error if the given model needs a system ROM that we
+// haven't been given.
+func (c *Computer) checkROM(m Model) error {
+	if m.NeedsSystemROM() && c.systemROM == nil {
+		return fmt.Errorf(
+			"the %v model needs its own system ROM, since the one erc includes is for the enhanced //e", m,
+		)
+	}
+
+	return nil
+}
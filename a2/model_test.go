@@ -0,0 +1,56 @@
+package a2
+
+import (
+	"path/filepath"
+
+	"github.com/pevans/erc/mos"
+	"github.com/pevans/erc/obj"
+)
+
+func (s *a2Suite) TestParseModel() {
+	model, err := ParseModel("iie")
+	s.NoError(err)
+	s.Equal(ModelIIe, model)
+	s.Equal("iie", model.String())
+
+	model, err = ParseModel("iie-enhanced")
+	s.NoError(err)
+	s.Equal(ModelIIeEnhanced, model)
+
+	model, err = ParseModel("iie-enhanced-wdc")
+	s.NoError(err)
+	s.Equal(mos.WDC65C02, model.CPUVariant())
+
+	_, err = ParseModel("iic")
+	s.Error(err)
+}
+
+func (s *a2Suite) TestSetModel() {
+	filename := filepath.Join(s.T().TempDir(), "test.state")
+
+	comp := NewComputer(1)
+	s.Equal(ModelIIeEnhanced, comp.Model())
+
+	// The original //e can't boot with the enhanced ROM that we embed. We
+	// don't have its own ROM, so we stand in the enhanced one for it.
+	comp.SetModel(ModelIIe)
+	s.Error(comp.Boot())
+	s.Error(comp.UseSystemROM(make([]uint8, 0x3000)))
+	s.NoError(comp.UseSystemROM(obj.SystemROM()))
+	s.NoError(comp.Boot())
+	s.Equal(mos.NMOS6502, comp.CPU.Variant)
+	s.NoError(comp.SaveState(filename))
+
+	// Nor can a computer without the ROM take on the model from a save
+	// state
+	newComp := NewComputer(1)
+	s.NoError(newComp.Boot())
+	s.Error(newComp.LoadState(filename))
+	s.Equal(ModelIIeEnhanced, newComp.Model())
+
+	s.NoError(newComp.UseSystemROM(obj.SystemROM()))
+	s.NoError(newComp.LoadState(filename))
+	s.Equal(ModelIIe, newComp.Model())
+	s.True(ModelIIe.NeedsSystemROM())
+	s.False(ModelIIeEnhanced.NeedsSystemROM())
+}
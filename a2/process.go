@@ -6,21 +6,35 @@ import (
 
 // Process executes a single execution of an opcode in the Apple II.
 func (c *Computer) Process() (int, error) {
+	if c.coverage != nil {
+		c.recordExec()
+	}
+
 	err := c.CPU.Execute()
 	if err != nil {
 		return c.CPU.OpcodeCycles(), err
 	}
 
+	cycles := c.CPU.OpcodeCycles()
+
+	if c.profiler != nil {
+		c.profiler.Step(c.CPU.Opcode(), c.CPU.PC, c.CPU.S, cycles)
+	}
+
 	// Check if this is was a knock-knock on one of our bank switches
 	switch c.CPU.EffAddr {
 	case 0xC081, 0xC083, 0xC085, 0xC087, 0xC089, 0xC08B, 0xC08D, 0xC08F:
-		if c.State.Bool(a2state.InstructionReadOp) {
+		if c.CPU.ReadOp {
 			c.State.SetInt(a2state.BankReadAttempts, c.State.Int(a2state.BankReadAttempts)+1)
-			return c.CPU.OpcodeCycles(), nil
+			return cycles, nil
 		}
 	}
 
-	c.State.SetInt(a2state.BankReadAttempts, 0)
+	// Storing to the state map isn't free, so we'd rather not do it for
+	// every instruction if we don't have to
+	if c.State.Int(a2state.BankReadAttempts) != 0 {
+		c.State.SetInt(a2state.BankReadAttempts, 0)
+	}
 
-	return c.CPU.OpcodeCycles(), nil
+	return cycles, nil
 }
@@ -0,0 +1,19 @@
+package a2
+
+import "github.com/pevans/erc/a2/a2prof"
+
+// StartProfiler begins keeping track of the cycles spent in each subroutine
+// that the computer runs, and returns the profiler that does so. Routines
+// are named by the given symbols (which may be nil), if they have one.
+func (c *Computer) StartProfiler(symbols map[int]string) *a2prof.Profiler {
+	if c.profiler == nil {
+		c.profiler = a2prof.New(symbols)
+	}
+
+	return c.profiler
+}
+
+// Profiler returns the subroutine profiler, or nil if we aren't profiling.
+func (c *Computer) Profiler() *a2prof.Profiler {
+	return c.profiler
+}
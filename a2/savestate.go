@@ -9,6 +9,7 @@ import (
 	"github.com/pevans/erc/a2/a2save"
 	"github.com/pevans/erc/a2/a2state"
 	"github.com/pevans/erc/gfx"
+	"github.com/pevans/erc/mos"
 	"github.com/pevans/erc/obj"
 )
 
@@ -44,6 +45,7 @@ func (c *Computer) SaveState(filename string) error {
 		CPU:        *c.CPU.Snapshot(),
 		Main:       c.Main.Bytes(),
 		Aux:        c.Aux.Bytes(),
+		AuxBanks:   c.auxBankBytes(),
 		StateFlags: *c.snapshotStateFlags(),
 		Drive1:     *c.Drive(1).Snapshot(),
 		Drive2:     *c.Drive(2).Snapshot(),
@@ -95,8 +97,15 @@ func (c *Computer) LoadState(filename string) error {
 		)
 	}
 
+	// The model the state was saved with may need a ROM that we don't have
+	if err := c.checkROM(modelOf(mos.Variant(state.CPU.Variant))); err != nil {
+		return fmt.Errorf("could not restore model: %w", err)
+	}
+
 	// Restore CPU
-	c.CPU.Restore(&state.CPU)
+	if err := c.CPU.Restore(&state.CPU); err != nil {
+		return fmt.Errorf("could not restore cpu: %w", err)
+	}
 
 	// Restore memory
 	if err := c.Main.RestoreBytes(state.Main); err != nil {
@@ -107,6 +116,18 @@ func (c *Computer) LoadState(filename string) error {
 		return fmt.Errorf("could not restore aux memory: %w", err)
 	}
 
+	// The save state may have come from a computer with a different number
+	// of aux banks; if so, we take on however many it had.
+	if err := c.SetAuxBanks(len(state.AuxBanks) + 1); err != nil {
+		return fmt.Errorf("could not restore aux memory banks: %w", err)
+	}
+
+	for i, bytes := range state.AuxBanks {
+		if err := c.auxBanks[i+1].RestoreBytes(bytes); err != nil {
+			return fmt.Errorf("could not restore aux memory bank %d: %w", i+1, err)
+		}
+	}
+
 	// Restore state flags
 	c.restoreStateFlags(&state.StateFlags)
 
@@ -179,6 +200,14 @@ func (c *Computer) LoadStateSlot() error {
 // StateMap.
 func (c *Computer) snapshotStateFlags() *a2save.StateFlags {
 	return &a2save.StateFlags{
+		// Annunciator state
+		Annunciators: [4]bool{
+			c.State.Bool(a2state.Annunciator0),
+			c.State.Bool(a2state.Annunciator1),
+			c.State.Bool(a2state.Annunciator2),
+			c.State.Bool(a2state.Annunciator3),
+		},
+
 		// Bank state
 		BankDFBlockBank2: c.State.Bool(a2state.BankDFBlockBank2),
 		BankReadAttempts: c.State.Int(a2state.BankReadAttempts),
@@ -204,6 +233,7 @@ func (c *Computer) snapshotStateFlags() *a2save.StateFlags {
 		KBStrobe:  c.State.Uint8(a2state.KBStrobe),
 
 		// Memory state
+		MemAuxBank:  c.State.Int(a2state.MemAuxBank),
 		MemReadAux:  c.State.Bool(a2state.MemReadAux),
 		MemWriteAux: c.State.Bool(a2state.MemWriteAux),
 
@@ -220,6 +250,12 @@ func (c *Computer) snapshotStateFlags() *a2save.StateFlags {
 // restoreStateFlags restores the boolean and integer state flags to the
 // StateMap.
 func (c *Computer) restoreStateFlags(flags *a2save.StateFlags) {
+	// Annunciator state
+	c.State.SetBool(a2state.Annunciator0, flags.Annunciators[0])
+	c.State.SetBool(a2state.Annunciator1, flags.Annunciators[1])
+	c.State.SetBool(a2state.Annunciator2, flags.Annunciators[2])
+	c.State.SetBool(a2state.Annunciator3, flags.Annunciators[3])
+
 	// Bank state
 	c.State.SetBool(a2state.BankDFBlockBank2, flags.BankDFBlockBank2)
 	c.State.SetInt(a2state.BankReadAttempts, flags.BankReadAttempts)
@@ -245,6 +281,7 @@ func (c *Computer) restoreStateFlags(flags *a2save.StateFlags) {
 	c.State.SetUint8(a2state.KBStrobe, flags.KBStrobe)
 
 	// Memory state
+	c.State.SetInt(a2state.MemAuxBank, flags.MemAuxBank)
 	c.State.SetBool(a2state.MemReadAux, flags.MemReadAux)
 	c.State.SetBool(a2state.MemWriteAux, flags.MemWriteAux)
 
@@ -260,28 +297,37 @@ func (c *Computer) restoreStateFlags(flags *a2save.StateFlags) {
 // rebuildSegmentReferences restores the Segment pointers in StateMap based on
 // the current boolean state.
 func (c *Computer) rebuildSegmentReferences() {
+	// The aux segment we use depends on which aux bank is selected (the
+	// display always uses the first)
+	aux := c.AuxBank(c.State.Int(a2state.MemAuxBank))
+	if aux == nil {
+		c.State.SetInt(a2state.MemAuxBank, 0)
+		aux = c.Aux
+	}
+
 	// Core segment references
 	c.State.SetSegment(a2state.MemMainSegment, c.Main)
-	c.State.SetSegment(a2state.MemAuxSegment, c.Aux)
+	c.State.SetSegment(a2state.MemAuxSegment, aux)
 	c.State.SetSegment(a2state.DisplayAuxSegment, c.Aux)
 	c.State.SetSegment(a2state.BankROMSegment, c.ROM)
 	c.State.SetSegment(a2state.PCROMSegment, c.ROM)
+	c.State.SetAny(a2state.MemAuxBanks, c.auxBanks)
 
 	// Rebuild read/write segment pointers based on flags
 	if c.State.Bool(a2state.MemReadAux) {
-		c.State.SetSegment(a2state.MemReadSegment, c.Aux)
+		c.State.SetSegment(a2state.MemReadSegment, aux)
 	} else {
 		c.State.SetSegment(a2state.MemReadSegment, c.Main)
 	}
 
 	if c.State.Bool(a2state.MemWriteAux) {
-		c.State.SetSegment(a2state.MemWriteSegment, c.Aux)
+		c.State.SetSegment(a2state.MemWriteSegment, aux)
 	} else {
 		c.State.SetSegment(a2state.MemWriteSegment, c.Main)
 	}
 
 	if c.State.Bool(a2state.BankSysBlockAux) {
-		c.State.SetSegment(a2state.BankSysBlockSegment, c.Aux)
+		c.State.SetSegment(a2state.BankSysBlockSegment, aux)
 	} else {
 		c.State.SetSegment(a2state.BankSysBlockSegment, c.Main)
 	}
@@ -290,6 +336,16 @@ func (c *Computer) rebuildSegmentReferences() {
 	c.State.SetAny(a2state.Computer, c)
 }
 
+// auxBankBytes returns the contents of each aux bank after the first.
+func (c *Computer) auxBankBytes() [][]uint8 {
+	banks := make([][]uint8, 0, len(c.auxBanks)-1)
+	for _, bank := range c.auxBanks[1:] {
+		banks = append(banks, bank.Bytes())
+	}
+
+	return banks
+}
+
 // Snapshot returns a snapshot of the DiskSet for serialization.
 func (set *DiskSet) Snapshot() *a2save.DiskSetState {
 	images := make([]string, len(set.images))
@@ -40,6 +40,7 @@ func (s *a2Suite) TestSaveStateLoadStateRoundTrip() {
 	s.comp.State.SetBool(a2state.DisplayHires, true)
 	s.comp.State.SetBool(a2state.MemReadAux, true)
 	s.comp.State.SetUint8(a2state.KBLastKey, 0x41)
+	s.comp.State.SetBool(a2state.Annunciator1, true)
 
 	// Set speed
 	s.comp.SetSpeed(3)
@@ -79,6 +80,7 @@ func (s *a2Suite) TestSaveStateLoadStateRoundTrip() {
 	s.True(newComp.State.Bool(a2state.DisplayHires))
 	s.True(newComp.State.Bool(a2state.MemReadAux))
 	s.Equal(uint8(0x41), newComp.State.Uint8(a2state.KBLastKey))
+	s.True(newComp.Annunciator(1))
 
 	// Verify speed was restored
 	s.Equal(3, newComp.speed)
@@ -161,3 +163,29 @@ func (s *a2Suite) TestSegmentReferencesRebuilt() {
 	s.True(newComp.State.Bool(a2state.MemReadAux))
 	s.Equal(newComp.Aux, newComp.State.Segment(a2state.MemReadSegment))
 }
+
+func (s *a2Suite) TestSaveStateAuxBanks() {
+	filename := filepath.Join(s.T().TempDir(), "test.state")
+
+	comp := NewComputer(1)
+	s.NoError(comp.SetAuxBanks(4))
+	s.NoError(comp.Boot())
+
+	comp.AuxBank(3).DirectSet(0x2000, 0x33)
+	comp.Set(0xC073, 3)
+	comp.Set(0xC003, 0)
+
+	s.NoError(comp.SaveState(filename))
+
+	// Even though this computer has just the one bank, it should take on
+	// the banks from the save state
+	newComp := NewComputer(1)
+	s.NoError(newComp.Boot())
+	s.NoError(newComp.LoadState(filename))
+
+	s.Equal(4, newComp.AuxBanks())
+	s.Equal(3, newComp.State.Int(a2state.MemAuxBank))
+	s.Equal(newComp.AuxBank(3), newComp.State.Segment(a2state.MemReadSegment))
+	s.Equal(newComp.Aux, newComp.State.Segment(a2state.DisplayAuxSegment))
+	s.Equal(uint8(0x33), newComp.Get(0x2000))
+}
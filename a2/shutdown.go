@@ -79,5 +79,17 @@ func (c *Computer) Shutdown() error {
 		return fmt.Errorf("could not save image: %w", err)
 	}
 
+	if err := c.HardDisk(1).Save(); err != nil {
+		return fmt.Errorf("could not save hard disk: %w", err)
+	}
+
+	if err := c.HardDisk(2).Save(); err != nil {
+		return fmt.Errorf("could not save hard disk: %w", err)
+	}
+
+	if err := c.SaveTape(); err != nil {
+		return fmt.Errorf("could not save tape: %w", err)
+	}
+
 	return nil
 }
@@ -1,9 +1,13 @@
 package a2
 
 import (
+	"github.com/pevans/erc/a2/a2annunciator"
 	"github.com/pevans/erc/a2/a2bank"
+	"github.com/pevans/erc/a2/a2cassette"
+	"github.com/pevans/erc/a2/a2clock"
 	"github.com/pevans/erc/a2/a2disk"
 	"github.com/pevans/erc/a2/a2display"
+	"github.com/pevans/erc/a2/a2hd"
 	"github.com/pevans/erc/a2/a2kb"
 	"github.com/pevans/erc/a2/a2memory"
 	"github.com/pevans/erc/a2/a2peripheral"
@@ -66,6 +70,14 @@ func (c *Computer) MapSoftSwitches() {
 		c.smap.SetWrite(a, a2display.SwitchWrite)
 	}
 
+	for _, a := range a2annunciator.ReadSwitches() {
+		c.smap.SetRead(a, a2annunciator.SwitchRead)
+	}
+
+	for _, a := range a2annunciator.WriteSwitches() {
+		c.smap.SetWrite(a, a2annunciator.SwitchWrite)
+	}
+
 	for _, a := range a2disk.ReadSwitches() {
 		c.smap.SetRead(a, a2disk.SwitchRead)
 	}
@@ -74,6 +86,22 @@ func (c *Computer) MapSoftSwitches() {
 		c.smap.SetWrite(a, a2disk.SwitchWrite)
 	}
 
+	for _, a := range a2clock.ReadSwitches() {
+		c.smap.SetRead(a, a2clock.SwitchRead)
+	}
+
+	for _, a := range a2clock.WriteSwitches() {
+		c.smap.SetWrite(a, a2clock.SwitchWrite)
+	}
+
+	for _, a := range a2hd.ReadSwitches() {
+		c.smap.SetRead(a, a2hd.SwitchRead)
+	}
+
+	for _, a := range a2hd.WriteSwitches() {
+		c.smap.SetWrite(a, a2hd.SwitchWrite)
+	}
+
 	for _, a := range a2speaker.ReadSwitches() {
 		c.smap.SetRead(a, a2speaker.SwitchRead)
 	}
@@ -81,4 +109,12 @@ func (c *Computer) MapSoftSwitches() {
 	for _, a := range a2speaker.WriteSwitches() {
 		c.smap.SetWrite(a, a2speaker.SwitchWrite)
 	}
+
+	for _, a := range a2cassette.ReadSwitches() {
+		c.smap.SetRead(a, a2cassette.SwitchRead)
+	}
+
+	for _, a := range a2cassette.WriteSwitches() {
+		c.smap.SetWrite(a, a2cassette.SwitchWrite)
+	}
 }
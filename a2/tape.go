@@ -0,0 +1,58 @@
+package a2
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pevans/erc/a2/a2cassette"
+)
+
+// Tape returns the tape in the computer's cassette recorder.
+func (c *Computer) Tape() *a2cassette.Tape {
+	return c.tape
+}
+
+// LoadTape loads the WAV file with the given name into the cassette recorder,
+// so that the computer can read it from cassette input.
+func (c *Computer) LoadTape(fileName string) error {
+	data, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("could not open file %v: %w", fileName, err)
+	}
+
+	defer data.Close() //nolint:errcheck
+
+	if err := c.tape.LoadWAV(data); err != nil {
+		return fmt.Errorf("could not load tape %v: %w", fileName, err)
+	}
+
+	return nil
+}
+
+// RecordTape tells the cassette recorder to keep track of cassette output,
+// which will be written as a WAV file with the given name when the computer
+// shuts down.
+func (c *Computer) RecordTape(fileName string) {
+	c.tapeFileName = fileName
+	c.tape.Record()
+}
+
+// SaveTape writes the cassette output that has been recorded so far, if we
+// were asked to record it.
+func (c *Computer) SaveTape() error {
+	if c.tapeFileName == "" {
+		return nil
+	}
+
+	file, err := os.Create(c.tapeFileName)
+	if err != nil {
+		return fmt.Errorf("could not create file %v: %w", c.tapeFileName, err)
+	}
+
+	if err := c.tape.WriteWAV(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
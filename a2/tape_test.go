@@ -0,0 +1,79 @@
+package a2
+
+import (
+	"bytes"
+	"testing"
+)
+
+// runMonitor calls a routine in the monitor ROM with A1 and A2 set to the
+// given range, and runs until it returns.
+func (s *a2Suite) runMonitor(c *Computer, routine, start, end int) {
+	program := []uint8{
+		0x20, uint8(routine), uint8(routine >> 8), // JSR routine
+		0x4C, 0x03, 0x03, // JMP $0303
+	}
+
+	for i, b := range program {
+		c.Main.Set(0x300+i, b)
+	}
+
+	c.Main.Set(0x3C, uint8(start))
+	c.Main.Set(0x3D, uint8(start>>8))
+	c.Main.Set(0x3E, uint8(end))
+	c.Main.Set(0x3F, uint8(end>>8))
+
+	// WRITE rings the bell when it's done, which goes through the output
+	// hook; we haven't run the reset routine, so we need to set that up
+	c.Main.Set(0x36, 0xF0)
+	c.Main.Set(0x37, 0xFD)
+
+	c.CPU.PC = 0x300
+	for range 20_000_000 {
+		if c.CPU.PC == 0x303 {
+			return
+		}
+
+		_, err := c.Process()
+		s.Require().NoError(err)
+	}
+
+	s.FailNow("monitor routine did not return")
+}
+
+func (s *a2Suite) TestTapeRoundTrip() {
+	// The monitor writes a ten second leader, which takes a while to run
+	if testing.Short() {
+		s.T().Skip("skipping cassette round trip in short mode")
+	}
+
+	data := []uint8("HELLO, CASSETTE!")
+
+	writer := NewComputer(1)
+	s.Require().NoError(writer.Boot())
+	writer.Tape().Record()
+
+	for i, b := range data {
+		writer.Main.Set(0x800+i, b)
+	}
+
+	// The monitor's WRITE routine
+	s.runMonitor(writer, 0xFECD, 0x800, 0x800+len(data)-1)
+	s.NotZero(writer.Tape().Toggles())
+
+	var wav bytes.Buffer
+	s.Require().NoError(writer.Tape().WriteWAV(&wav))
+
+	reader := NewComputer(1)
+	s.Require().NoError(reader.Boot())
+	s.Require().NoError(reader.Tape().LoadWAV(&wav))
+
+	// The monitor's READ routine
+	s.runMonitor(reader, 0xFEFD, 0x900, 0x900+len(data)-1)
+
+	got := make([]uint8, len(data))
+	for i := range got {
+		got[i] = reader.Main.Get(0x900 + i)
+	}
+
+	s.Equal(data, got)
+}
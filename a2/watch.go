@@ -0,0 +1,10 @@
+package a2
+
+import "github.com/pevans/erc/memory"
+
+// Watchpoints returns the watchpoints that the CPU checks its reads and
+// writes against. Hits pile up in them until someone (in practice, the
+// debugger) asks for them.
+func (c *Computer) Watchpoints() *memory.Watchpoints {
+	return c.watchpoints
+}
@@ -1,5 +1,7 @@
 // Package assembler provides a minimal 65C02 assembler that produces bootable
-// Apple II DOS 3.3 disk images for black box testing.
+// Apple II DOS 3.3 disk images for black box testing. After a .cpu w65c02
+// directive, it also knows the bit instructions of the Rockwell/WDC 65C02
+// (e.g. BBR0 $12,LOOP), along with WAI and STP.
 package assembler
 
 import (
@@ -7,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/pevans/erc/a2/a2enc"
+	"github.com/pevans/erc/mos"
 )
 
 const (
@@ -17,18 +20,101 @@ const (
 // Assemble takes 65C02 assembly source and a filename (used in error
 // messages) and returns a 143,360-byte DOS 3.3 disk image.
 func Assemble(src []byte, filename string) ([]byte, error) {
+	code, _, err := AssembleCode(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(code) > maxCodeSize {
+		return nil, fmt.Errorf("assembled code exceeds track 0 capacity: %d bytes (max %d)", len(code), maxCodeSize)
+	}
+
+	return buildDiskImage(code), nil
+}
+
+// AssembleCode is like Assemble, except that it returns the assembled bytes
+// and the address at which they begin (their origin), rather than a disk
+// image. There's no limit on how much code there can be.
+func AssembleCode(src []byte, filename string) ([]byte, uint16, error) {
 	a := &assembler{
 		filename: filename,
 		origin:   defaultOrigin,
 		labels:   make(map[string]uint16),
 	}
-	return a.run(src)
+
+	code, err := a.run(src)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return code, a.origin, nil
+}
+
+// Labels assembles the source just as AssembleCode does, but returns the
+// address of each label it defines, rather than the code.
+func Labels(src []byte, filename string) (map[string]uint16, error) {
+	a := &assembler{
+		filename: filename,
+		origin:   defaultOrigin,
+		labels:   make(map[string]uint16),
+	}
+
+	if _, err := a.run(src); err != nil {
+		return nil, err
+	}
+
+	return a.labels, nil
+}
+
+// AssembleLine assembles one line of source as though it were at the given
+// address, and returns its bytes and the address they begin at (which is
+// different only if the line is an .org). Labels in the operand are looked
+// up in labels. If the line defines a label, it's added to labels, in place
+// of any label of the same name that was there before; that way, a line can
+// refer to labels defined by the lines assembled before it. The line may use
+// the instructions of the given variant of the 6502; only the Rockwell/WDC
+// 65C02 adds any to those we assemble by default.
+func AssembleLine(line string, pc uint16, labels map[string]uint16, variant mos.Variant) ([]byte, uint16, error) {
+	if labels == nil {
+		labels = make(map[string]uint16)
+	}
+
+	a := &assembler{
+		origin: pc,
+		labels: labels,
+		wdc:    variant == mos.WDC65C02,
+	}
+
+	nodes, err := a.parseAll([]string{line})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	delete(labels, nodes[0].label)
+
+	if err := a.pass1(nodes); err != nil {
+		return nil, 0, err
+	}
+
+	code, err := a.pass2(nodes)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return code, a.origin, nil
 }
 
 type assembler struct {
 	filename string
 	origin   uint16
 	labels   map[string]uint16
+
+	// wdc is true if we're assembling for the Rockwell/WDC 65C02 (see the
+	// .cpu directive), and so know its bit instructions
+	wdc bool
+
+	// nodes are the lines of the source that run last parsed
+	nodes []*node
 }
 
 // node holds information about one source line, populated across both passes.
@@ -45,6 +131,12 @@ type node struct {
 }
 
 func (a *assembler) errorf(lineNum int, format string, args ...any) error {
+	// A line assembled on its own (by AssembleLine) has no file or line
+	// number to point to
+	if a.filename == "" {
+		return fmt.Errorf(format, args...)
+	}
+
 	return fmt.Errorf("%s:%d: "+format, append([]any{a.filename, lineNum}, args...)...)
 }
 
@@ -56,20 +148,13 @@ func (a *assembler) run(src []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	if err := a.pass1(nodes); err != nil {
-		return nil, err
-	}
+	a.nodes = nodes
 
-	code, err := a.pass2(nodes)
-	if err != nil {
+	if err := a.pass1(nodes); err != nil {
 		return nil, err
 	}
 
-	if len(code) > maxCodeSize {
-		return nil, fmt.Errorf("assembled code exceeds track 0 capacity: %d bytes (max %d)", len(code), maxCodeSize)
-	}
-
-	return buildDiskImage(code), nil
+	return a.pass2(nodes)
 }
 
 func (a *assembler) parseAll(rawLines []string) ([]*node, error) {
@@ -77,11 +162,19 @@ func (a *assembler) parseAll(rawLines []string) ([]*node, error) {
 
 	for i, raw := range rawLines {
 		lineNum := i + 1
-		p, err := parseLine(raw)
+		p, err := parseLine(raw, a.wdc)
 		if err != nil {
 			return nil, a.errorf(lineNum, "%v", err)
 		}
 
+		// Since .cpu decides which instructions the lines after it may use,
+		// we have to deal with it as we parse
+		if p.dir == "cpu" {
+			if a.wdc, err = parseCPU(p.dirArgs); err != nil {
+				return nil, a.errorf(lineNum, "%v", err)
+			}
+		}
+
 		n := &node{
 			lineNum: lineNum,
 			label:   p.label,
@@ -123,6 +216,12 @@ func (a *assembler) pass1(nodes []*node) error {
 			}
 			n.size = size
 			pc = newPC
+
+			// The code we assemble begins wherever .org says it does
+			if n.dir == "org" {
+				a.origin = newPC
+			}
+
 			continue
 		}
 
@@ -184,6 +283,9 @@ func (a *assembler) directiveInfo(n *node, pc uint16) (size int, newPC uint16, e
 	case "halt":
 		return 3, pc + 3, nil // JMP * emits 3 bytes
 
+	case "cpu":
+		return 0, pc, nil
+
 	default:
 		return 0, 0, a.errorf(n.lineNum, "unknown directive .%s", n.dir)
 	}
@@ -244,6 +346,9 @@ func (a *assembler) emitDirective(n *node) ([]byte, error) {
 		pc := n.pc
 		return []byte{opcode, byte(pc & 0xFF), byte(pc >> 8)}, nil
 
+	case "cpu":
+		return nil, nil
+
 	default:
 		return nil, a.errorf(n.lineNum, "unknown directive .%s", n.dir)
 	}
@@ -253,6 +358,10 @@ func (a *assembler) emitInstruction(n *node) ([]byte, error) {
 	opcode, _ := lookupOpcode(n.mnem, n.mode)
 	out := []byte{opcode}
 
+	if n.mode == modeZPR {
+		return a.emitZeroPageRelative(n, out)
+	}
+
 	if n.oi.isLabel {
 		addr, ok := a.labels[n.oi.label]
 		if !ok {
@@ -284,6 +393,46 @@ func (a *assembler) emitInstruction(n *node) ([]byte, error) {
 	return out, nil
 }
 
+// emitZeroPageRelative appends the operand of a BBR or BBS instruction to
+// out, which is a zero page address followed by a branch offset.
+func (a *assembler) emitZeroPageRelative(n *node, out []byte) ([]byte, error) {
+	target := uint16(n.oi.target)
+
+	if n.oi.isLabel {
+		addr, ok := a.labels[n.oi.label]
+		if !ok {
+			return nil, a.errorf(n.lineNum, "undefined label %q", n.oi.label)
+		}
+		target = addr
+	}
+
+	next := int(n.pc) + 3
+	offset := int(target) - next
+	if offset < -128 || offset > 127 {
+		return nil, a.errorf(n.lineNum, "branch target out of range: offset %d", offset)
+	}
+
+	return append(out, byte(n.oi.value), byte(int8(offset))), nil
+}
+
+// parseCPU returns whether the processor named by the arguments of a .cpu
+// directive is the Rockwell/WDC 65C02 (w65c02), rather than the 65C02
+// (65c02).
+func parseCPU(args []string) (bool, error) {
+	if len(args) != 1 {
+		return false, fmt.Errorf(".cpu requires exactly one argument")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "65c02":
+		return false, nil
+	case "w65c02":
+		return true, nil
+	}
+
+	return false, fmt.Errorf("unknown cpu %q (must be 65c02 or w65c02)", args[0])
+}
+
 // resolveMode returns the first mode from oi.modes that the instruction
 // supports.
 func resolveMode(mnem string, oi operandInfo) (int, bool) {
@@ -0,0 +1,118 @@
+package assembler
+
+import (
+	"testing"
+
+	"github.com/pevans/erc/mos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseZeroPageRelative(t *testing.T) {
+	cases := []struct {
+		zp     uint32
+		target string
+		want   operandInfo
+		err    bool
+	}{
+		{0x12, "loop", operandInfo{modes: []int{modeZPR}, value: 0x12, isLabel: true, label: "loop"}, false},
+		{0x12, "$0830", operandInfo{modes: []int{modeZPR}, value: 0x12, target: 0x0830}, false},
+		{0xFF, "$FFFF", operandInfo{modes: []int{modeZPR}, value: 0xFF, target: 0xFFFF}, false},
+		{0x12, "$10000", operandInfo{}, true},
+		{0x12, "0830", operandInfo{}, true},
+		{0x12, "$", operandInfo{}, true},
+	}
+
+	for _, c := range cases {
+		oi, err := parseZeroPageRelative(c.zp, c.target)
+		if c.err {
+			assert.Error(t, err, c.target)
+			continue
+		}
+
+		require.NoError(t, err, c.target)
+		assert.Equal(t, c.want, oi, c.target)
+	}
+}
+
+func TestAssembleWDC(t *testing.T) {
+	cases := []struct {
+		src  string
+		want []byte
+		err  string
+	}{
+		// The branch of BBR and BBS is relative to the end of the
+		// instruction, which is three bytes long
+		{"loop: BBR0 $12,loop", []byte{0x0F, 0x12, 0xFD}, ""},
+		{"BBR0 $12,next\nnext: NOP", []byte{0x0F, 0x12, 0x00, 0xEA}, ""},
+		{"BBS7 $34,$0810", []byte{0xFF, 0x34, 0x0D}, ""},
+		{"BBS7 $34,$0882", []byte{0xFF, 0x34, 0x7F}, ""},
+		{"BBS7 $34,$0783", []byte{0xFF, 0x34, 0x80}, ""},
+		{"RMB3 $56", []byte{0x37, 0x56}, ""},
+		{"SMB5 $78", []byte{0xD7, 0x78}, ""},
+		{"WAI\nSTP", []byte{0xCB, 0xDB}, ""},
+
+		{"BBS7 $34,$0883", nil, "branch target out of range: offset 128"},
+		{"BBR0 $12,$0782", nil, "branch target out of range: offset -129"},
+		{"BBR0 $12,nowhere", nil, "undefined label"},
+		{"BBR0 $1234,$0800", nil, "unknown index register"},
+		{"RMB3 $1234", nil, "no valid addressing mode"},
+	}
+
+	for _, c := range cases {
+		src := ".org $0800\n.cpu w65c02\n" + c.src
+
+		code, _, err := AssembleCode([]byte(src), "test.s")
+		if c.err != "" {
+			require.Error(t, err, c.src)
+			assert.Contains(t, err.Error(), c.err, c.src)
+
+			continue
+		}
+
+		require.NoError(t, err, c.src)
+		assert.Equal(t, c.want, code, c.src)
+	}
+}
+
+func TestAssembleCPU(t *testing.T) {
+	// Without .cpu w65c02, the bit instructions aren't instructions, so
+	// their names are free to be labels
+	code, _, err := AssembleCode([]byte(".org $0800\nRMB3: NOP\nJMP RMB3"), "test.s")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xEA, 0x4C, 0x00, 0x08}, code)
+
+	for _, src := range []string{"RMB3 $56", "SMB5 $78", "BBR0 $12,$0800", "BBS7 $12,$0800", "WAI", "STP"} {
+		_, _, err := AssembleCode([]byte(src), "test.s")
+		require.Error(t, err, src)
+		assert.Contains(t, err.Error(), "use .cpu w65c02", src)
+	}
+
+	// .cpu 65c02 goes back to the plain 65C02
+	_, _, err = AssembleCode([]byte(".cpu w65c02\nRMB3 $56\n.cpu 65c02\nSMB5 $78"), "test.s")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test.s:4:")
+
+	_, _, err = AssembleCode([]byte(".cpu 6502"), "test.s")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown cpu")
+
+	_, _, err = AssembleCode([]byte(".cpu"), "test.s")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ".cpu requires exactly one argument")
+
+	// A line on its own follows the variant it's given
+	code, _, err = AssembleLine("RMB3 $56", 0x0800, nil, mos.WDC65C02)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x37, 0x56}, code)
+
+	_, _, err = AssembleLine("RMB3 $56", 0x0800, nil, mos.CMOS65C02)
+	assert.Error(t, err)
+
+	_, _, err = AssembleLine("BBR0 $12,far", 0x0800, map[string]uint16{"far": 0x0900}, mos.WDC65C02)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "branch target out of range")
+
+	assert.True(t, NeedsWDC("BBR0"))
+	assert.False(t, NeedsWDC("LDA"))
+}
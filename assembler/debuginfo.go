@@ -0,0 +1,86 @@
+package assembler
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// DebugInfo tells a debugger where the code that we assembled came from in
+// its source, so that it can set breakpoints on lines and show which line
+// is running.
+type DebugInfo struct {
+	// Source is the path to the source file.
+	Source string `json:"source"`
+
+	// Lines are the lines of source that emit any bytes, in the order they
+	// appear.
+	Lines []LineInfo `json:"lines"`
+
+	// Labels are the address of each label in the source.
+	Labels map[string]uint16 `json:"labels"`
+}
+
+// LineInfo is a line of source, and the bytes it was assembled into.
+type LineInfo struct {
+	// Line is the line number, counting from 1.
+	Line int `json:"line"`
+
+	// Addr is the address of the first byte the line emits, and Size is
+	// how many it does.
+	Addr uint16 `json:"addr"`
+	Size int    `json:"size"`
+
+	// Code is true if the line is an instruction, rather than data.
+	Code bool `json:"code"`
+}
+
+// Debug assembles the source just as AssembleCode does, but returns its
+// debug info, rather than the code.
+func Debug(src []byte, filename string) (*DebugInfo, error) {
+	a := &assembler{
+		filename: filename,
+		origin:   defaultOrigin,
+		labels:   make(map[string]uint16),
+	}
+
+	if _, err := a.run(src); err != nil {
+		return nil, err
+	}
+
+	info := &DebugInfo{
+		Source: filename,
+		Lines:  []LineInfo{},
+		Labels: a.labels,
+	}
+
+	for _, n := range a.nodes {
+		if n.size == 0 {
+			continue
+		}
+
+		info.Lines = append(info.Lines, LineInfo{
+			Line: n.lineNum,
+			Addr: n.pc,
+			Size: n.size,
+			Code: n.mnem != "" || n.dir == "halt",
+		})
+	}
+
+	return info, nil
+}
+
+// LoadDebugInfo reads the debug info in the file at the given path, as
+// erc-assembler writes it.
+func LoadDebugInfo(path string) (*DebugInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var info DebugInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
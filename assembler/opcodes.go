@@ -18,11 +18,12 @@ const (
 	modeIDY            // indirect y-indexed
 	modeREL            // relative
 	modeZPI            // zero page indirect (65C02)
+	modeZPR            // zero page relative (BBR and BBS)
 )
 
 // operandSize maps each mode to its operand byte count (not counting the
-// opcode byte). Indexed by the mode constants (modeIMP=1 .. modeZPI=14).
-var operandSize = [15]int{
+// opcode byte). Indexed by the mode constants (modeIMP=1 .. modeZPR=15).
+var operandSize = [16]int{
 	0,    // 0: unused
 	0, 0, // modeIMP, modeACC
 	1,       // modeIMM
@@ -32,6 +33,7 @@ var operandSize = [15]int{
 	1, 1, // modeIDX, modeIDY
 	1, // modeREL
 	1, // modeZPI
+	2, // modeZPR
 }
 
 // mosToAsm maps mos address mode constants to assembler mode constants.
@@ -50,6 +52,7 @@ var mosToAsm = map[int]int{
 	mos.AmIDY: modeIDY,
 	mos.AmREL: modeREL,
 	mos.AmZPI: modeZPI,
+	mos.AmZPR: modeZPR,
 }
 
 type opcodeKey struct {
@@ -58,8 +61,15 @@ type opcodeKey struct {
 }
 
 var (
-	opcodeTable    map[opcodeKey]byte
-	validMnemonics map[string]struct{}
+	// opcodeTable holds every instruction we can assemble, which includes
+	// the bit instructions of the Rockwell/WDC 65C02.
+	opcodeTable map[opcodeKey]byte
+
+	// cmosMnemonics are the mnemonics of the 65C02, which we assemble by
+	// default; wdcMnemonics are those of the Rockwell/WDC 65C02, which we
+	// assemble after a .cpu w65c02 directive.
+	cmosMnemonics map[string]struct{}
+	wdcMnemonics  map[string]struct{}
 )
 
 // mnemNormalize maps mos-internal names to standard 65C02 assembler
@@ -70,29 +80,37 @@ var mnemNormalize = map[string]string{
 
 func init() {
 	opcodeTable = buildOpcodeTable()
-	validMnemonics = make(map[string]struct{}, len(opcodeTable))
-	for key := range opcodeTable {
-		validMnemonics[key.mnem] = struct{}{}
+	cmosMnemonics = make(map[string]struct{})
+	wdcMnemonics = make(map[string]struct{})
+
+	for key, opcode := range opcodeTable {
+		wdcMnemonics[key.mnem] = struct{}{}
+
+		if Mnemonic(mos.CMOS65C02.InstructionName(opcode)) == key.mnem {
+			cmosMnemonics[key.mnem] = struct{}{}
+		}
 	}
 }
 
+// buildOpcodeTable builds our opcodes from those of the Rockwell/WDC 65C02,
+// which is the 65C02 plus the bit instructions (RMB, SMB, BBR and BBS) and
+// WAI and STP. Those that only the Rockwell/WDC 65C02 has can only be
+// assembled after a .cpu w65c02 directive (see isValidMnem).
 func buildOpcodeTable() map[opcodeKey]byte {
 	table := make(map[opcodeKey]byte)
 
 	for i := range 256 {
 		opcode := uint8(i)
-		name := mos.OpcodeInstructionName(opcode)
+		name := mos.WDC65C02.InstructionName(opcode)
 
 		// Skip placeholder/undefined/filler opcodes.
 		if name == "NP2" || name == "NP3" || name == "NOP" {
 			continue
 		}
 
-		if norm, ok := mnemNormalize[name]; ok {
-			name = norm
-		}
+		name = Mnemonic(name)
 
-		asmMode, ok := mosToAsm[mos.OpcodeAddrMode(opcode)]
+		asmMode, ok := mosToAsm[mos.WDC65C02.AddrMode(opcode)]
 		if !ok {
 			continue // skip BY2/BY3 placeholder modes
 		}
@@ -109,12 +127,49 @@ func buildOpcodeTable() map[opcodeKey]byte {
 	return table
 }
 
+// Mnemonic returns the standard mnemonic for the given name of an
+// instruction in the mos package, which is usually the same name.
+func Mnemonic(name string) string {
+	if norm, ok := mnemNormalize[name]; ok {
+		return norm
+	}
+
+	return name
+}
+
+// Encode returns the opcode that we would assemble for the given mnemonic
+// and address mode, if there is one. The mode is one of those in the mos
+// package (e.g. mos.AmABS). Note that some of these (see NeedsWDC) can only
+// be assembled after a .cpu w65c02 directive.
+func Encode(mnem string, mosMode int) (byte, bool) {
+	mode, ok := mosToAsm[mosMode]
+	if !ok {
+		return 0, false
+	}
+
+	return lookupOpcode(mnem, mode)
+}
+
 func lookupOpcode(mnem string, mode int) (byte, bool) {
 	op, ok := opcodeTable[opcodeKey{mnem, mode}]
 	return op, ok
 }
 
-func isValidMnem(mnem string) bool {
-	_, ok := validMnemonics[mnem]
+// isValidMnem returns true if mnem is an instruction of the 65C02, or of the
+// Rockwell/WDC 65C02 if wdc is true.
+func isValidMnem(mnem string, wdc bool) bool {
+	if wdc {
+		_, ok := wdcMnemonics[mnem]
+		return ok
+	}
+
+	_, ok := cmosMnemonics[mnem]
 	return ok
 }
+
+// NeedsWDC returns true if mnem is an instruction that only the
+// Rockwell/WDC 65C02 has (e.g. RMB0), and so can only be assembled after a
+// .cpu w65c02 directive.
+func NeedsWDC(mnem string) bool {
+	return isValidMnem(mnem, true) && !isValidMnem(mnem, false)
+}
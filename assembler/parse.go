@@ -21,7 +21,9 @@ var (
 	identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 )
 
-func parseLine(raw string) (*parsedLine, error) {
+// parseLine parses a line of source. Instructions must be those of the
+// 65C02, or of the Rockwell/WDC 65C02 if wdc is true.
+func parseLine(raw string, wdc bool) (*parsedLine, error) {
 	p := &parsedLine{}
 
 	// Strip comment.
@@ -79,7 +81,11 @@ func parseLine(raw string) (*parsedLine, error) {
 	}
 
 	mnem := strings.ToUpper(mnemRaw)
-	if !isValidMnem(mnem) {
+	if !isValidMnem(mnem, wdc) {
+		if NeedsWDC(mnem) {
+			return nil, fmt.Errorf("%s is only on the Rockwell/WDC 65C02 (use .cpu w65c02)", mnem)
+		}
+
 		return nil, fmt.Errorf("unknown mnemonic %q", mnem)
 	}
 
@@ -92,6 +98,7 @@ func parseLine(raw string) (*parsedLine, error) {
 type operandInfo struct {
 	modes   []int  // modes to try in preference order
 	value   uint32 // numeric operand value (for non-label operands)
+	target  uint32 // branch target of a zero page relative operand
 	isLabel bool
 	label   string
 }
@@ -221,10 +228,37 @@ func parseIndexedOperand(operand string) (operandInfo, error) {
 		}
 		return operandInfo{modes: []int{modeABY}, value: v}, nil
 	default:
+		if v <= 0xFF {
+			return parseZeroPageRelative(v, strings.TrimSpace(operand[idx+1:]))
+		}
 		return operandInfo{}, fmt.Errorf("unknown index register %q in operand %s", regStr, operand)
 	}
 }
 
+// parseZeroPageRelative returns the operand of a BBR or BBS instruction, such
+// as $12,LOOP or $12,$0830, given the zero page address and the branch target
+// that follows it.
+func parseZeroPageRelative(zp uint32, targetStr string) (operandInfo, error) {
+	oi := operandInfo{modes: []int{modeZPR}, value: zp}
+
+	if identRe.MatchString(targetStr) {
+		oi.isLabel = true
+		oi.label = targetStr
+		return oi, nil
+	}
+
+	target, err := parseHexValue(targetStr)
+	if err != nil {
+		return operandInfo{}, fmt.Errorf("invalid branch target: %v", err)
+	}
+	if target > 0xFFFF {
+		return operandInfo{}, fmt.Errorf("branch target out of range: %s", targetStr)
+	}
+
+	oi.target = target
+	return oi, nil
+}
+
 func parsePlainAddr(operand string) (operandInfo, error) {
 	v, err := parseHexValue(operand)
 	if err != nil {
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pevans/erc/a2"
+)
+
+// clockTimeLayouts are the formats we accept for a fixed clock time.
+var clockTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// useClockFlags configures the time that the computer's clock card will
+// report. If timeStr is not empty, the clock starts from that time and
+// advances with emulated cycles; otherwise, it follows host time shifted by
+// offset.
+func useClockFlags(comp *a2.Computer, timeStr string, offset time.Duration) {
+	if timeStr == "" {
+		comp.SetClockOffset(offset)
+		return
+	}
+
+	for _, layout := range clockTimeLayouts {
+		t, err := time.ParseInLocation(layout, timeStr, time.Local)
+		if err == nil {
+			comp.SetClockTime(t)
+			return
+		}
+	}
+
+	fail(fmt.Sprintf("invalid clock time %q (expected e.g. 2006-01-02T15:04:05)", timeStr))
+}
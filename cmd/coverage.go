@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pevans/erc/a2/a2coverage"
+)
+
+// writeCoverage writes the coverage report (coverage.txt) and its heatmap
+// (coverage.png) into the given directory.
+func writeCoverage(cov *a2coverage.Map, dir string) {
+	outputs := []struct {
+		name  string
+		write func(*os.File) error
+	}{
+		{"coverage.txt", func(f *os.File) error { return cov.WriteReport(f) }},
+		{"coverage.png", func(f *os.File) error { return cov.WritePNG(f) }},
+	}
+
+	for _, out := range outputs {
+		f, err := os.Create(filepath.Join(dir, out.name))
+		if err != nil {
+			fail(fmt.Sprintf("could not create %s: %v", out.name, err))
+		}
+
+		if err := out.write(f); err != nil {
+			fail(fmt.Sprintf("could not write %s: %v", out.name, err))
+		}
+
+		if err := f.Close(); err != nil {
+			fail(fmt.Sprintf("could not write %s: %v", out.name, err))
+		}
+	}
+}
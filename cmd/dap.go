@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/dap"
+	"github.com/pevans/erc/debug"
+	"github.com/pevans/erc/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dapPortFlag  int
+	dapModelFlag string
+	dapROMFlag   string
+)
+
+var dapCmd = &cobra.Command{
+	Use:   "dap",
+	Short: "Serve the Debug Adapter Protocol, so that an editor can debug a disk image",
+	Long: "Talk to an editor (like VS Code) with the Debug Adapter Protocol over stdin and stdout, or " +
+		"with --port, over a TCP port of the loopback interface. The editor launches a disk image, and " +
+		"if erc-assembler wrote debug info for it (with -dbg), can set breakpoints on lines of its " +
+		"source. The emulator runs headless, without a display.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		serveDAP()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dapCmd)
+
+	dapCmd.Flags().IntVar(
+		&dapPortFlag,
+		"port",
+		0,
+		"Listen for the editor on this TCP port, rather than talking over stdin and stdout",
+	)
+	dapCmd.Flags().StringVar(
+		&dapModelFlag,
+		"model",
+		"iie-enhanced",
+		"Model of Apple //e to emulate (iie-enhanced, iie with an NMOS 6502 and the ROM given by --rom, or iie-enhanced-wdc with a Rockwell/WDC 65C02)",
+	)
+	dapCmd.Flags().StringVar(
+		&dapROMFlag,
+		"rom",
+		"",
+		"System ROM ($C000-$FFFF, 16K) to use in place of the enhanced //e ROM that erc includes",
+	)
+}
+
+func serveDAP() {
+	var (
+		r io.Reader = os.Stdin
+		w io.Writer = os.Stdout
+	)
+
+	// We check the model now, since once the editor is talking to us, we
+	// can't just quit
+	model, err := parseModelFlag(dapModelFlag, dapROMFlag)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	// We never write out metrics for the programs we debug
+	metrics.Enable(false)
+
+	if dapPortFlag != 0 {
+		conn := acceptDAPClient(dapPortFlag)
+		defer conn.Close() //nolint:errcheck
+
+		r, w = conn, conn
+	} else {
+		// Stdout is the editor's now, so anything else that would be
+		// printed there goes to stderr instead
+		os.Stdout = os.Stderr
+	}
+
+	server := dap.NewServer(r, w, func(program string) (dap.Target, error) {
+		return launchDAPTarget(model, program)
+	})
+	if err := server.Serve(); err != nil {
+		fail(fmt.Sprintf("dap: %v", err))
+	}
+}
+
+// acceptDAPClient waits for an editor to connect to the given port of the
+// loopback interface.
+func acceptDAPClient(port int) net.Conn {
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		fail(fmt.Sprintf("could not listen for dap: %v", err))
+	}
+
+	defer ln.Close() //nolint:errcheck
+
+	fmt.Fprintf(os.Stderr, "listening for dap on %v\n", ln.Addr())
+
+	conn, err := ln.Accept()
+	if err != nil {
+		fail(fmt.Sprintf("could not accept dap client: %v", err))
+	}
+
+	return conn
+}
+
+// launchDAPTarget boots the computer with the given disk image.
+func launchDAPTarget(model a2.Model, program string) (dap.Target, error) {
+	comp := a2.NewComputer(1)
+	comp.SetModel(model)
+
+	if err := useROMFile(comp, dapROMFlag); err != nil {
+		return nil, err
+	}
+
+	if err := comp.Disks.Append(program); err != nil {
+		return nil, fmt.Errorf("could not open file %s: %w", program, err)
+	}
+
+	if err := comp.LoadFirst(); err != nil {
+		return nil, fmt.Errorf("could not load file: %w", err)
+	}
+
+	if err := comp.Boot(); err != nil {
+		return nil, fmt.Errorf("could not boot emulator: %w", err)
+	}
+
+	comp.DisableSpeaker()
+
+	return debug.DAPTarget(comp), nil
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/a2/a2drive"
+	"github.com/pevans/erc/a2/a2enc"
+	"github.com/pevans/erc/memory"
+	"github.com/pevans/erc/mos/disasm"
+	"github.com/spf13/cobra"
+)
+
+// bootAddr is where the boot ROM loads the first sector of a disk, and
+// bootEntry is where it jumps once it has.
+const (
+	bootAddr  = 0x0800
+	bootEntry = 0x0801
+)
+
+var (
+	disasmBootFlag    bool
+	disasmAddressFlag string
+	disasmEntryFlag   string
+	disasmModelFlag   string
+	disasmSourceFlag  bool
+	disasmSymbolsFlag []string
+)
+
+var disasmCmd = &cobra.Command{
+	Use:   "disasm [file]",
+	Short: "Disassemble the code in a file or the boot sector of a disk image",
+	Long: "Load a file (or, with --boot, the boot sector of a disk image) into memory and disassemble " +
+		"whatever code can be reached from its entry points. Bytes that can't be reached are listed as data.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		disassemble(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(disasmCmd)
+
+	disasmCmd.Flags().BoolVar(
+		&disasmBootFlag,
+		"boot",
+		false,
+		"Disassemble the boot sector of the given disk image, which is loaded at 0800 and entered at 0801",
+	)
+	disasmCmd.Flags().StringVar(
+		&disasmAddressFlag,
+		"address",
+		"0800",
+		"Hex address at which to load the file",
+	)
+	disasmCmd.Flags().StringVar(
+		&disasmEntryFlag,
+		"entry",
+		"",
+		"Comma-separated hex addresses at which code begins (by default, the address the file is loaded at)",
+	)
+	disasmCmd.Flags().StringVar(
+		&disasmModelFlag,
+		"model",
+		"iie-enhanced",
+		"Model whose processor we disassemble for: iie-enhanced (65C02), iie (6502), or iie-enhanced-wdc (WDC 65C02)",
+	)
+	disasmCmd.Flags().BoolVar(
+		&disasmSourceFlag,
+		"source",
+		false,
+		"Write source that erc-assembler can assemble, rather than a listing",
+	)
+	disasmCmd.Flags().StringSliceVar(
+		&disasmSymbolsFlag,
+		"symbols",
+		nil,
+		"Load symbols from files (VICE or ca65 labels, ca65 debug info, Merlin equates, or lines of addr name) to name addresses",
+	)
+}
+
+func disassemble(file string) {
+	model, err := a2.ParseModel(disasmModelFlag)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	var (
+		data    []byte
+		addr    uint16
+		entries []uint16
+	)
+
+	if disasmBootFlag {
+		data = bootSector(file)
+		addr = bootAddr
+		entries = []uint16{bootEntry}
+	} else {
+		data, err = os.ReadFile(file)
+		if err != nil {
+			fail(fmt.Sprintf("could not read file %s: %v", file, err))
+		}
+
+		addr = parseHexAddr("--address", disasmAddressFlag)
+		entries = []uint16{addr}
+	}
+
+	if int(addr)+len(data) > 0x10000 {
+		fail(fmt.Sprintf("file is too large to load at %04X: %d bytes", addr, len(data)))
+	}
+
+	if disasmEntryFlag != "" {
+		entries = nil
+		for entry := range strings.SplitSeq(disasmEntryFlag, ",") {
+			entries = append(entries, parseHexAddr("--entry", strings.TrimSpace(entry)))
+		}
+	}
+
+	mem := memory.NewSegment(0x10000)
+	if _, err := mem.CopySlice(int(addr), data); err != nil {
+		fail(fmt.Sprintf("could not load file into memory: %v", err))
+	}
+
+	var (
+		d     = disasm.New(model.CPUVariant(), useSymbolsFlag(disasmSymbolsFlag))
+		insts = d.Trace(mem, addr, int(addr)+len(data), entries)
+		write = disasm.WriteListing
+	)
+
+	if disasmSourceFlag {
+		write = disasm.WriteSource
+	}
+
+	if err := write(os.Stdout, insts); err != nil {
+		fail(fmt.Sprintf("could not write disassembly: %v", err))
+	}
+}
+
+// bootSector returns the first sector of the given disk image, which is the
+// one that the boot ROM loads. (That's logical sector 0 of track 0 in both
+// DOS 3.3 and ProDOS order.)
+func bootSector(file string) []byte {
+	imageType, err := a2drive.ImageType(file)
+	if err != nil {
+		fail(fmt.Sprintf("could not determine image type: %v", err))
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fail(fmt.Sprintf("could not read disk image %s: %v", file, err))
+	}
+
+	if imageType == a2enc.Nibble {
+		if len(data) != a2enc.EncodedSize {
+			fail(fmt.Sprintf(
+				"disk image has unexpected size: %d (given) != %d (expected)",
+				len(data), a2enc.EncodedSize,
+			))
+		}
+
+		physicalSeg := memory.NewSegment(len(data))
+		if _, err := physicalSeg.CopySlice(0, data); err != nil {
+			fail(fmt.Sprintf("could not copy bytes to segment: %v", err))
+		}
+
+		logicalSeg, err := a2enc.Decode(a2enc.DOS33, physicalSeg)
+		if err != nil {
+			fail(fmt.Sprintf("could not decode disk image: %v", err))
+		}
+
+		data = make([]byte, a2enc.LogSectorLen)
+		for i := range data {
+			data[i] = logicalSeg.Get(i)
+		}
+	}
+
+	if len(data) < a2enc.LogSectorLen {
+		fail(fmt.Sprintf("disk image %s is too small to have a boot sector", file))
+	}
+
+	return data[:a2enc.LogSectorLen]
+}
+
+// parseHexAddr returns the address given in hex by the named flag.
+func parseHexAddr(flag, hex string) uint16 {
+	addr, err := strconv.ParseUint(hex, 16, 16)
+	if err != nil {
+		fail(fmt.Sprintf("invalid %s address %q: %v", flag, hex, err))
+	}
+
+	return uint16(addr)
+}
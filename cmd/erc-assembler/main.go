@@ -1,23 +1,28 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
+	"github.com/pevans/erc/a2/a2sym"
 	"github.com/pevans/erc/assembler"
 )
 
 func main() {
-	var outputPath string
+	var outputPath, symbolsPath, debugPath string
 
 	flag.StringVar(&outputPath, "o", "", "Output .dsk file path (omit or - for stdout)")
+	flag.StringVar(&debugPath, "dbg", "", "Also write debug info (which lines of source are at which addresses) to this file, for erc dap")
+	flag.StringVar(&symbolsPath, "sym", "", "Also write the labels to this file, as a VICE label file that erc run --symbols can load")
 	flag.Parse()
 
 	args := flag.Args()
 	if len(args) != 1 {
-		fmt.Fprintln(os.Stderr, "usage: erc-assembler [-o output.dsk] input.s")
+		fmt.Fprintln(os.Stderr, "usage: erc-assembler [-o output.dsk] [-sym output.sym] [-dbg output.dbg] input.s")
 		os.Exit(1)
 	}
 
@@ -48,6 +53,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	if symbolsPath != "" {
+		if err := writeSymbols(symbolsPath, src, filename); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write symbols: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if debugPath != "" {
+		if err := writeDebugInfo(debugPath, src, filename); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write debug info: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if outputPath == "" || outputPath == "-" {
 		_, err = os.Stdout.Write(image)
 	} else {
@@ -59,3 +78,54 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// writeSymbols writes the labels defined in src to a VICE label file at the
+// given path.
+func writeSymbols(path string, src []byte, filename string) error {
+	labels, err := assembler.Labels(src, filename)
+	if err != nil {
+		return err
+	}
+
+	// A table has one name per address; where labels share an address, we
+	// keep whichever name sorts first, so that the file is the same each time
+	table := a2sym.Table{}
+	for name, addr := range labels {
+		if prev, ok := table[int(addr)]; !ok || name < prev {
+			table[int(addr)] = name
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := table.WriteVICE(file); err != nil {
+		file.Close() //nolint:errcheck
+		return err
+	}
+
+	return file.Close()
+}
+
+// writeDebugInfo writes the debug info for src, as JSON, to the given path.
+// The source is named by its absolute path, so that a debugger can match it
+// with the files it has open.
+func writeDebugInfo(path string, src []byte, filename string) error {
+	if abs, err := filepath.Abs(filename); err == nil && filename != "<stdin>" {
+		filename = abs
+	}
+
+	info, err := assembler.Debug(src, filename)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
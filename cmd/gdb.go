@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/debug"
+	"github.com/pevans/erc/gdb"
+)
+
+// A gdbServer is the stub that a GDB client attaches to, along with the
+// computer it debugs. If we weren't asked to listen for a client, the stub
+// is nil, and no client is ever attached.
+type gdbServer struct {
+	stub   *gdb.Stub
+	comp   *a2.Computer
+	target gdb.Target
+}
+
+// useGDBFlag listens for a GDB client on the given port of the loopback
+// interface, unless the port is zero.
+func useGDBFlag(comp *a2.Computer, port int) *gdbServer {
+	if port == 0 {
+		return &gdbServer{comp: comp}
+	}
+
+	stub, err := gdb.Listen(port)
+	if err != nil {
+		fail(fmt.Sprintf("could not listen for gdb: %v", err))
+	}
+
+	fmt.Fprintf(os.Stderr, "listening for gdb on %v\n", stub.Addr())
+
+	return &gdbServer{
+		stub:   stub,
+		comp:   comp,
+		target: debug.GDBTarget(comp),
+	}
+}
+
+// attached returns true if a GDB client is attached.
+func (g *gdbServer) attached() bool {
+	return g.stub != nil && g.stub.Attached()
+}
+
+// shouldStop returns true if we should stop for the debugger. While a GDB
+// client is attached, it's the one that decides; otherwise, we stop for any
+// breakpoint or watchpoint we have.
+func (g *gdbServer) shouldStop() bool {
+	if g.attached() {
+		return g.stub.Check(g.target)
+	}
+
+	// We check both, so that a breakpoint doesn't leave watchpoint hits to
+	// stop us again as soon as we resume
+	breakHit := debug.CheckBreakpoint(g.comp)
+	watchHit := debug.CheckWatchpoints(g.comp)
+
+	return breakHit || watchHit
+}
+
+// debug lets the GDB client, if one is attached, debug the computer until
+// it resumes it (or detaches). It returns false if there's no client, and
+// so the debugger is ours.
+func (g *gdbServer) debug() bool {
+	if !g.attached() {
+		return false
+	}
+
+	g.stub.Debug(g.target)
+	g.comp.State.SetBool(a2state.Debugger, false)
+
+	return true
+}
+
+// acceptAll attaches each GDB client that connects, one at a time, in the
+// background.
+func (g *gdbServer) acceptAll() {
+	if g.stub != nil {
+		go g.stub.AcceptAll()
+	}
+}
+
+// close stops listening for GDB clients, and hangs up on the one that's
+// attached, if there is one.
+func (g *gdbServer) close() {
+	if g.stub == nil {
+		return
+	}
+
+	if err := g.stub.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "could not close gdb stub: %v\n", err)
+	}
+}
+
+// waitForClient waits for the first GDB client to attach.
+func (g *gdbServer) waitForClient() {
+	if g.stub == nil {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "waiting for gdb to attach")
+
+	if err := g.stub.Accept(); err != nil {
+		fail(fmt.Sprintf("could not accept gdb client: %v", err))
+	}
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/a2/a2hd"
+)
+
+// loadHardDisks loads each of the given image files into a volume of the
+// computer's block device. This has to happen before the computer boots.
+func loadHardDisks(comp *a2.Computer, images []string, writeProtect bool) {
+	if len(images) > a2hd.Volumes {
+		fail(fmt.Sprintf("at most %d hard disk images may be attached", a2hd.Volumes))
+	}
+
+	for i, filename := range images {
+		if err := comp.LoadHardDisk(i+1, filename); err != nil {
+			fail(fmt.Sprintf("could not load hard disk %s: %v", filename, err))
+		}
+
+		if writeProtect {
+			comp.HardDisk(i + 1).SetWriteProtect(true)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/peterh/liner"
 	"github.com/pevans/erc/a2"
@@ -18,6 +19,7 @@ import (
 	"github.com/pevans/erc/a2/a2state"
 	"github.com/pevans/erc/debug"
 	"github.com/pevans/erc/input"
+	"github.com/pevans/erc/internal/metrics"
 	"github.com/pevans/erc/memory"
 	"github.com/pevans/erc/record"
 	"github.com/pevans/erc/shortcut"
@@ -37,8 +39,24 @@ var (
 	headlessKeysFlag         string
 	headlessStartInDebugger  bool
 	headlessDebugBreakFlag   string
+	headlessLoadBreakpoints  bool
+	headlessDebugWatchFlag   string
 	headlessMonochromeFlag   string
 	headlessDebugImageFlag   bool
+	headlessClockTimeFlag    string
+	headlessClockOffsetFlag  time.Duration
+	headlessHardDiskFlag     []string
+	headlessHDWriteProtect   bool
+	headlessAuxBanksFlag     int
+	headlessTapeInFlag       string
+	headlessTapeOutFlag      string
+	headlessModelFlag        string
+	headlessROMFlag          string
+	headlessHistoryFlag      int
+	headlessCoverageFlag     bool
+	headlessProfileFlag      bool
+	headlessSymbolsFlag      []string
+	headlessGDBPortFlag      int
 )
 
 var headlessCmd = &cobra.Command{
@@ -121,7 +139,19 @@ func init() {
 		&headlessDebugBreakFlag,
 		"debug-break",
 		"",
-		"Comma-separated hex addresses at which to enter the debugger (e.g. FA62,0801)",
+		"Comma-separated hex addresses at which to enter the debugger, each optionally with a condition (e.g. FA62,0801 if A == $C1)",
+	)
+	headlessCmd.Flags().BoolVar(
+		&headlessLoadBreakpoints,
+		"load-breakpoints",
+		false,
+		"Load the breakpoints saved alongside the first disk image (<disk>.breakpoints), and save any changes to them",
+	)
+	headlessCmd.Flags().StringVar(
+		&headlessDebugWatchFlag,
+		"debug-watch",
+		"",
+		"Comma-separated watchpoints (kind:addr or kind:from-to, where kind is read, write or change) that enter the debugger (e.g. write:0300-03FF,change:00FE)",
 	)
 	headlessCmd.Flags().StringVar(
 		&headlessMonochromeFlag,
@@ -135,6 +165,90 @@ func init() {
 		false,
 		"Write out debug artifact files alongside the disk image",
 	)
+	headlessCmd.Flags().StringSliceVar(
+		&headlessHardDiskFlag,
+		"hd",
+		nil,
+		"Attach up to two hard disk images (.po, .hdv, .2mg) to the block device in slot 7",
+	)
+	headlessCmd.Flags().BoolVar(
+		&headlessHDWriteProtect,
+		"hd-write-protect",
+		true,
+		"Whether to write-protect the hard disk images (use --hd-write-protect=false to let the run change them)",
+	)
+	headlessCmd.Flags().IntVar(
+		&headlessAuxBanksFlag,
+		"aux-banks",
+		1,
+		"Number of 64K banks of auxiliary memory, selected with $C073 (up to 128, for 8MB)",
+	)
+	headlessCmd.Flags().StringVar(
+		&headlessClockTimeFlag,
+		"clock-time",
+		"",
+		"Start the clock card at a fixed time that advances with emulated cycles (e.g. 2024-01-31T09:00:00)",
+	)
+	headlessCmd.Flags().DurationVar(
+		&headlessClockOffsetFlag,
+		"clock-offset",
+		0,
+		"Shift the host time reported by the clock card (e.g. -24h)",
+	)
+	headlessCmd.Flags().StringVar(
+		&headlessTapeInFlag,
+		"tape-in",
+		"",
+		"Play a WAV file into cassette input ($C060), starting at the first read",
+	)
+	headlessCmd.Flags().StringVar(
+		&headlessTapeOutFlag,
+		"tape-out",
+		"",
+		"Record cassette output ($C020) to a WAV file, written when the run ends",
+	)
+	headlessCmd.Flags().StringVar(
+		&headlessModelFlag,
+		"model",
+		"iie-enhanced",
+		"Model of Apple //e to emulate (iie-enhanced, iie with an NMOS 6502 and the ROM given by --rom, or iie-enhanced-wdc with a Rockwell/WDC 65C02)",
+	)
+	headlessCmd.Flags().StringVar(
+		&headlessROMFlag,
+		"rom",
+		"",
+		"System ROM ($C000-$FFFF, 16K) to use in place of the enhanced //e ROM that erc includes",
+	)
+	headlessCmd.Flags().IntVar(
+		&headlessHistoryFlag,
+		"history",
+		1000,
+		"Number of executed instructions the debugger keeps, so you can go back through them (0 to keep none)",
+	)
+	headlessCmd.Flags().BoolVar(
+		&headlessCoverageFlag,
+		"coverage",
+		false,
+		"Write out which memory was executed, read and written (coverage.txt) along with a heatmap of it (coverage.png)",
+	)
+	headlessCmd.Flags().BoolVar(
+		&headlessProfileFlag,
+		"profile-routines",
+		false,
+		"Write out the cycles spent in each subroutine (profile.txt) and each call stack (profile.folded, for flame graphs)",
+	)
+	headlessCmd.Flags().IntVar(
+		&headlessGDBPortFlag,
+		"gdb-port",
+		0,
+		"Listen on this local TCP port for a debugger that speaks the GDB remote protocol, and wait for one to attach before running",
+	)
+	headlessCmd.Flags().StringSliceVar(
+		&headlessSymbolsFlag,
+		"symbols",
+		nil,
+		"Load symbols from files (VICE or ca65 labels, ca65 debug info, Merlin equates, or lines of addr name) to name addresses in the debugger, elog and profile",
+	)
 }
 
 // headlessKeyEvent is a key press or release injected at a specific step.
@@ -144,12 +258,28 @@ type headlessKeyEvent struct {
 }
 
 func runHeadless(images []string) {
+	// We only write out metrics when we're debugging an image
+	metrics.Enable(headlessDebugImageFlag)
+
 	comp := a2.NewComputer(1)
+	symbols := useSymbolsFlag(headlessSymbolsFlag)
 
 	if headlessDebugImageFlag {
 		comp.State.SetBool(a2state.DebugImage, true)
 	}
 
+	useModelFlag(comp, headlessModelFlag, headlessROMFlag)
+	useClockFlags(comp, headlessClockTimeFlag, headlessClockOffsetFlag)
+	useTapeFlags(comp, headlessTapeInFlag, headlessTapeOutFlag)
+
+	if err := comp.SetAuxBanks(headlessAuxBanksFlag); err != nil {
+		fail(err.Error())
+	}
+
+	if err := comp.SetHistory(headlessHistoryFlag); err != nil {
+		fail(err.Error())
+	}
+
 	for _, filename := range images {
 		if err := comp.Disks.Append(filename); err != nil {
 			fail(fmt.Sprintf("could not open file %s: %v", filename, err))
@@ -160,6 +290,8 @@ func runHeadless(images []string) {
 		fail(fmt.Sprintf("could not load file: %v", err))
 	}
 
+	loadHardDisks(comp, headlessHardDiskFlag, headlessHDWriteProtect)
+
 	if err := comp.Boot(); err != nil {
 		fail(fmt.Sprintf("could not boot emulator: %v", err))
 	}
@@ -198,6 +330,16 @@ func runHeadless(images []string) {
 		}
 	}
 
+	// We start coverage and profiling after any warm-up, so that they only
+	// include the part of the run we're interested in
+	if headlessCoverageFlag {
+		comp.StartCoverage()
+	}
+
+	if headlessProfileFlag {
+		comp.StartProfiler(symbols)
+	}
+
 	rec := &record.Recorder{}
 
 	if headlessWatchMemFlag != "" {
@@ -292,14 +434,47 @@ func runHeadless(images []string) {
 		videoRec.CaptureAt(captureSteps...)
 	}
 
-	hasBreakpoints := headlessDebugBreakFlag != ""
-	if hasBreakpoints {
+	if headlessDebugBreakFlag != "" {
 		if err := debug.ParseBreakpoints(headlessDebugBreakFlag); err != nil {
 			fail(err.Error())
 		}
 	}
 
-	debugMode := headlessStartInDebugger || hasBreakpoints
+	// Unlike erc run, we only use the saved breakpoints if we're asked to,
+	// so that a scripted run doesn't turn into an interactive one because
+	// of a file that happens to be next to the disk
+	if headlessLoadBreakpoints {
+		if _, err := debug.LoadBreakpoints(images[0]); err != nil {
+			fail(err.Error())
+		}
+	}
+
+	ranScript, err := debug.RunStartupScript(comp, images[0])
+	if err != nil {
+		fail(err.Error())
+	}
+
+	// A startup script only makes this a debugging session if it stopped
+	// emulation, or left breakpoints or watchpoints for us to stop at; one
+	// that only prints something shouldn't keep a scripted run waiting at
+	// the prompt
+	scriptStopped := ranScript && comp.State.Bool(a2state.Debugger)
+	hasBreakpoints := debug.HasBreakpoints()
+
+	if headlessDebugWatchFlag != "" {
+		if err := debug.ParseWatchpoints(comp, headlessDebugWatchFlag); err != nil {
+			fail(err.Error())
+		}
+	}
+
+	hasWatchpoints := len(comp.Watchpoints().List()) > 0
+
+	gdbSrv := useGDBFlag(comp, headlessGDBPortFlag)
+	gdbSrv.waitForClient()
+	gdbSrv.acceptAll()
+
+	debugMode := headlessStartInDebugger || hasBreakpoints || hasWatchpoints ||
+		scriptStopped || headlessGDBPortFlag != 0
 
 	var line *liner.State
 	if debugMode {
@@ -310,7 +485,7 @@ func runHeadless(images []string) {
 			comp.State.SetBool(a2state.Debugger, true)
 		}
 
-		enterDebugger(comp, line)
+		enterDebugger(comp, line, gdbSrv)
 	}
 
 	var keyEvents map[int]headlessKeyEvent
@@ -324,12 +499,12 @@ func runHeadless(images []string) {
 
 	earlyExit := false
 	for i := range headlessStepsFlag {
-		if hasBreakpoints && debug.HasBreakpoint(int(comp.CPU.PC)) {
+		if debugMode && gdbSrv.shouldStop() {
 			comp.State.SetBool(a2state.Debugger, true)
 		}
 
 		if debugMode && comp.State.Bool(a2state.Debugger) {
-			enterDebugger(comp, line)
+			enterDebugger(comp, line, gdbSrv)
 		}
 
 		step := i
@@ -408,14 +583,26 @@ func runHeadless(images []string) {
 		}
 	}
 
+	if headlessCoverageFlag {
+		writeCoverage(comp.Coverage(), headlessOutputFlag)
+	}
+
+	if headlessProfileFlag {
+		writeProfile(comp.Profiler(), headlessOutputFlag)
+	}
+
+	gdbSrv.close()
+
 	if err := comp.Shutdown(); err != nil {
 		fail(fmt.Sprintf("could not properly shut down emulator: %v", err))
 	}
 }
 
-func enterDebugger(comp *a2.Computer, line *liner.State) {
+func enterDebugger(comp *a2.Computer, line *liner.State, gdbSrv *gdbServer) {
 	for comp.State.Bool(a2state.Debugger) {
-		debug.Prompt(comp, line)
+		if !gdbSrv.debug() {
+			debug.Prompt(comp, line)
+		}
 	}
 }
 
@@ -541,6 +728,10 @@ func headlessRegObserver(comp *a2.Computer, reg string) (record.Observer, error)
 // headlessStateNameToKey maps a2state names to their integer keys for use
 // with --watch-comp.
 var headlessStateNameToKey = map[string]int{
+	"Annunciator0":        a2state.Annunciator0,
+	"Annunciator1":        a2state.Annunciator1,
+	"Annunciator2":        a2state.Annunciator2,
+	"Annunciator3":        a2state.Annunciator3,
 	"BankDFBlockBank2":    a2state.BankDFBlockBank2,
 	"BankROMSegment":      a2state.BankROMSegment,
 	"BankReadRAM":         a2state.BankReadRAM,
@@ -564,6 +755,7 @@ var headlessStateNameToKey = map[string]int{
 	"KBKeyDown":           a2state.KBKeyDown,
 	"KBLastKey":           a2state.KBLastKey,
 	"KBStrobe":            a2state.KBStrobe,
+	"MemAuxBank":          a2state.MemAuxBank,
 	"MemAuxSegment":       a2state.MemAuxSegment,
 	"MemMainSegment":      a2state.MemMainSegment,
 	"MemReadAux":          a2state.MemReadAux,
@@ -577,6 +769,7 @@ var headlessStateNameToKey = map[string]int{
 	"PCSlotC3":            a2state.PCSlotC3,
 	"PCSlotCX":            a2state.PCSlotCX,
 	"CapsLock":            a2state.CapsLock,
+	"CassetteOut":         a2state.CassetteOut,
 	"Paused":              a2state.Paused,
 	"SpeakerState":        a2state.SpeakerState,
 }
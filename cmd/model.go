@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pevans/erc/a2"
+)
+
+// useModelFlag sets the model of Apple //e that the computer emulates, and
+// the system ROM it boots with, if we were given one.
+func useModelFlag(comp *a2.Computer, name, romFile string) {
+	model, err := parseModelFlag(name, romFile)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	comp.SetModel(model)
+
+	if err := useROMFile(comp, romFile); err != nil {
+		fail(err.Error())
+	}
+}
+
+// parseModelFlag returns the model with the given name, or an error if
+// there's no such model, or if it needs a system ROM and we weren't given
+// one.
+func parseModelFlag(name, romFile string) (a2.Model, error) {
+	model, err := a2.ParseModel(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if model.NeedsSystemROM() && romFile == "" {
+		return 0, fmt.Errorf(
+			"the %v model needs the ROM of the original //e, which erc doesn't include; give it with --rom", model,
+		)
+	}
+
+	return model, nil
+}
+
+// useROMFile gives the computer the system ROM in the given file, if there
+// is one.
+func useROMFile(comp *a2.Computer, romFile string) error {
+	if romFile == "" {
+		return nil
+	}
+
+	rom, err := os.ReadFile(romFile)
+	if err != nil {
+		return fmt.Errorf("could not read ROM %s: %w", romFile, err)
+	}
+
+	return comp.UseSystemROM(rom)
+}
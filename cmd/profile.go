@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pevans/erc/a2/a2prof"
+)
+
+// writeProfile writes the subroutine profile (profile.txt) and its folded
+// call stacks (profile.folded) into the given directory.
+func writeProfile(prof *a2prof.Profiler, dir string) {
+	outputs := map[string]string{
+		"profile.txt":    prof.Report(),
+		"profile.folded": prof.Folded(),
+	}
+
+	for name, content := range outputs {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			fail(fmt.Sprintf("could not write %s: %v", name, err))
+		}
+	}
+}
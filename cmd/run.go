@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/peterh/liner"
 	"github.com/pevans/erc/a2"
@@ -12,6 +13,7 @@ import (
 	"github.com/pevans/erc/a2/a2state"
 	"github.com/pevans/erc/debug"
 	"github.com/pevans/erc/input"
+	"github.com/pevans/erc/internal/metrics"
 	"github.com/pevans/erc/render"
 	"github.com/pevans/erc/shortcut"
 	"github.com/pkg/profile"
@@ -21,6 +23,7 @@ import (
 var (
 	debugImageFlag      bool
 	debugBreakFlag      string
+	debugWatchFlag      string
 	profileFlag         bool
 	speedFlag           int
 	writeProtectFlag    bool
@@ -29,6 +32,18 @@ var (
 	volumeOffFlag       bool
 	startInDebuggerFlag bool
 	capsLockFlag        bool
+	clockTimeFlag       string
+	clockOffsetFlag     time.Duration
+	hardDiskFlag        []string
+	hdWriteProtectFlag  bool
+	auxBanksFlag        int
+	tapeInFlag          string
+	tapeOutFlag         string
+	modelFlag           string
+	romFlag             string
+	historyFlag         int
+	symbolsFlag         []string
+	gdbPortFlag         int
 )
 
 var runCmd = &cobra.Command{
@@ -45,7 +60,8 @@ func init() {
 	rootCmd.AddCommand(runCmd)
 
 	runCmd.Flags().BoolVar(&debugImageFlag, "debug-image", false, "Write out debugging files to debug image loading")
-	runCmd.Flags().StringVar(&debugBreakFlag, "debug-break", "", "Set breakpoints for a comma-separated list of addresses (eg 3FC8,9D94)")
+	runCmd.Flags().StringVar(&debugBreakFlag, "debug-break", "", "Set breakpoints for a comma-separated list of addresses, each optionally with a condition (eg 3FC8,9D94 if A == $C1)")
+	runCmd.Flags().StringVar(&debugWatchFlag, "debug-watch", "", "Set watchpoints for a comma-separated list of kind:addr or kind:from-to, where kind is read, write or change (eg write:0300-03FF,change:00FE)")
 	runCmd.Flags().BoolVar(&profileFlag, "profile", false, "Write out a profile trace")
 	runCmd.Flags().IntVar(&speedFlag, "speed", 1, "Starting speed of the emulator (more is faster)")
 	runCmd.Flags().BoolVar(&writeProtectFlag, "write-protect", false, "Whether to write-protect the image")
@@ -54,6 +70,18 @@ func init() {
 	runCmd.Flags().BoolVar(&volumeOffFlag, "volume-off", false, "Start with audio muted")
 	runCmd.Flags().BoolVar(&startInDebuggerFlag, "start-in-debugger", false, "Start the emulator in the debugger")
 	runCmd.Flags().BoolVar(&capsLockFlag, "caps-lock", false, "Start with caps lock enabled")
+	runCmd.Flags().StringVar(&clockTimeFlag, "clock-time", "", "Start the clock card at a fixed time (eg 2024-01-31T09:00:00) instead of host time")
+	runCmd.Flags().StringSliceVar(&hardDiskFlag, "hd", nil, "Attach up to two hard disk images (.po, .hdv, .2mg) to the block device in slot 7")
+	runCmd.Flags().BoolVar(&hdWriteProtectFlag, "hd-write-protect", false, "Whether to write-protect the hard disk images")
+	runCmd.Flags().IntVar(&auxBanksFlag, "aux-banks", 1, "Number of 64K banks of auxiliary memory, selected with $C073 (up to 128, for 8MB)")
+	runCmd.Flags().DurationVar(&clockOffsetFlag, "clock-offset", 0, "Shift the host time reported by the clock card (eg -24h)")
+	runCmd.Flags().StringVar(&tapeInFlag, "tape-in", "", "Play a WAV file into cassette input ($C060)")
+	runCmd.Flags().StringVar(&tapeOutFlag, "tape-out", "", "Record cassette output ($C020) to a WAV file, written on exit")
+	runCmd.Flags().StringVar(&modelFlag, "model", "iie-enhanced", "Model of Apple //e to emulate (iie-enhanced, iie with an NMOS 6502 and the ROM given by --rom, or iie-enhanced-wdc with a Rockwell/WDC 65C02)")
+	runCmd.Flags().StringVar(&romFlag, "rom", "", "System ROM ($C000-$FFFF, 16K) to use in place of the enhanced //e ROM that erc includes")
+	runCmd.Flags().IntVar(&historyFlag, "history", 1000, "Number of executed instructions the debugger keeps, so you can go back through them (0 to keep none)")
+	runCmd.Flags().IntVar(&gdbPortFlag, "gdb-port", 0, "Listen on this local TCP port for a debugger that speaks the GDB remote protocol")
+	runCmd.Flags().StringSliceVar(&symbolsFlag, "symbols", nil, "Load symbols from files (VICE or ca65 labels, ca65 debug info, Merlin equates, or lines of addr name) to name addresses in the debugger")
 }
 
 func runEmulator(images []string) {
@@ -74,6 +102,9 @@ func runEmulator(images []string) {
 		fail("monochrome flag must be either 'green' or 'amber'")
 	}
 
+	// Symbols come first, so that breakpoints may be given by name
+	useSymbolsFlag(symbolsFlag)
+
 	// Parse and add breakpoints if provided
 	if debugBreakFlag != "" {
 		if err := debug.ParseBreakpoints(debugBreakFlag); err != nil {
@@ -81,6 +112,10 @@ func runEmulator(images []string) {
 		}
 	}
 
+	// We only write out metrics when we're debugging an image, so
+	// otherwise there's no sense in collecting them
+	metrics.Enable(debugImageFlag)
+
 	// Build the computer and screen objects
 	comp := a2.NewComputer(speedFlag)
 
@@ -88,6 +123,24 @@ func runEmulator(images []string) {
 		comp.SetMuted(true)
 	}
 
+	useModelFlag(comp, modelFlag, romFlag)
+	useClockFlags(comp, clockTimeFlag, clockOffsetFlag)
+	useTapeFlags(comp, tapeInFlag, tapeOutFlag)
+
+	if err := comp.SetAuxBanks(auxBanksFlag); err != nil {
+		fail(err.Error())
+	}
+
+	if err := comp.SetHistory(historyFlag); err != nil {
+		fail(err.Error())
+	}
+
+	if debugWatchFlag != "" {
+		if err := debug.ParseWatchpoints(comp, debugWatchFlag); err != nil {
+			fail(err.Error())
+		}
+	}
+
 	// Set up a signal handler for graceful shutdown
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
@@ -118,14 +171,24 @@ func runEmulator(images []string) {
 		fail(fmt.Sprintf("could not load file %s: %v", images[0], err))
 	}
 
+	if _, err := debug.LoadBreakpoints(images[0]); err != nil {
+		fail(err.Error())
+	}
+
 	if writeProtectFlag {
 		comp.Drive(1).SetWriteProtect(true)
 	}
 
+	loadHardDisks(comp, hardDiskFlag, hdWriteProtectFlag)
+
 	if err := comp.Boot(); err != nil {
 		fail(fmt.Sprintf("could not boot emulator: %v", err))
 	}
 
+	if _, err := debug.RunStartupScript(comp, images[0]); err != nil {
+		fail(err.Error())
+	}
+
 	if startInDebuggerFlag {
 		comp.State.SetBool(a2state.Debugger, true)
 	}
@@ -170,12 +233,17 @@ func runEmulator(images []string) {
 
 	emulator := comp.ClockEmulator()
 
+	gdbSrv := useGDBFlag(comp, gdbPortFlag)
+	gdbSrv.acceptAll()
+
 	emulator.SetDebuggerEntry(func() {
-		debug.Prompt(comp, line)
+		if !gdbSrv.debug() {
+			debug.Prompt(comp, line)
+		}
 	})
 
 	emulator.SetBreakpointCheck(func() {
-		if debug.HasBreakpoint(int(comp.CPU.PC)) {
+		if gdbSrv.shouldStop() {
 			comp.State.SetBool(a2state.Debugger, true)
 		}
 	})
@@ -188,6 +256,8 @@ func runEmulator(images []string) {
 	}
 
 	// Shutdown if we exit the draw loop
+	gdbSrv.close()
+
 	if err := comp.Shutdown(); err != nil {
 		fail(fmt.Sprintf("could not properly shut down emulator: %v", err))
 	}
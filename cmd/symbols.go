@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pevans/erc/a2/a2sym"
+)
+
+// useSymbolsFlag loads each of the given symbol files, so that the names in
+// them are used (ahead of Apple's) wherever we show an address, and returns
+// all the symbols it loaded.
+func useSymbolsFlag(files []string) a2sym.Table {
+	all := a2sym.Table{}
+
+	for _, file := range files {
+		table, err := a2sym.LoadFile(file)
+		if err != nil {
+			fail(fmt.Sprintf("could not load symbols: %v", err))
+		}
+
+		for addr, name := range table {
+			all[addr] = name
+		}
+	}
+
+	a2sym.Use(all)
+
+	return all
+}
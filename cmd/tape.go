@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pevans/erc/a2"
+)
+
+// useTapeFlags sets up the computer's cassette recorder. If in is not empty,
+// it's a WAV file to play into cassette input; if out is not empty, cassette
+// output is recorded and written there as a WAV file on shutdown.
+func useTapeFlags(comp *a2.Computer, in, out string) {
+	if in != "" {
+		if err := comp.LoadTape(in); err != nil {
+			fail(fmt.Sprintf("could not load tape: %v", err))
+		}
+	}
+
+	if out != "" {
+		comp.RecordTape(out)
+	}
+}
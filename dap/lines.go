@@ -0,0 +1,79 @@
+package dap
+
+import (
+	"path/filepath"
+
+	"github.com/pevans/erc/assembler"
+)
+
+// A lineMap tells us which line of source is at which address, and the
+// other way around. A nil lineMap is one for a program we have no debug
+// info for.
+type lineMap struct {
+	source string
+	byAddr map[uint16]int
+	code   []assembler.LineInfo
+}
+
+// newLineMap returns the line map for the given debug info.
+func newLineMap(info *assembler.DebugInfo) *lineMap {
+	m := &lineMap{
+		source: info.Source,
+		byAddr: make(map[uint16]int),
+	}
+
+	for _, line := range info.Lines {
+		if !line.Code {
+			continue
+		}
+
+		m.byAddr[line.Addr] = line.Line
+		m.code = append(m.code, line)
+	}
+
+	return m
+}
+
+// line returns the line of source whose instruction begins at addr, if
+// there is one.
+func (m *lineMap) line(addr uint16) (int, bool) {
+	if m == nil {
+		return 0, false
+	}
+
+	line, ok := m.byAddr[addr]
+
+	return line, ok
+}
+
+// covers returns true if the map is for the source at the given path. We
+// allow the paths to differ in their directory, since the client may not
+// name the file quite as the assembler did.
+func (m *lineMap) covers(path string) bool {
+	if m == nil {
+		return false
+	}
+
+	if filepath.Clean(path) == filepath.Clean(m.source) {
+		return true
+	}
+
+	return filepath.Base(path) == filepath.Base(m.source)
+}
+
+// resolve returns the address of the first instruction at or after the
+// given line, and the line it's on. It returns false if there's no
+// instruction past that line.
+func (m *lineMap) resolve(line int) (uint16, int, bool) {
+	if m == nil {
+		return 0, 0, false
+	}
+
+	for _, info := range m.code {
+		if info.Line >= line {
+			return info.Addr, info.Line, true
+		}
+	}
+
+	return 0, 0, false
+}
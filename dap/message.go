@@ -0,0 +1,98 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A request is what the client asks of us.
+type request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// A response answers a request.
+type response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+// An event tells the client something happened that it didn't ask about,
+// like the target stopping.
+type event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+// readRequest reads the next request from r. Each message is a header
+// (of which we only need Content-Length), a blank line, and then that many
+// bytes of JSON.
+func readRequest(r *bufio.Reader) (request, error) {
+	var (
+		req    request
+		length = -1
+	)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return req, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		name, val, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(val)); err != nil {
+				return req, fmt.Errorf("invalid Content-Length: %q", val)
+			}
+		}
+	}
+
+	if length < 0 {
+		return req, fmt.Errorf("message has no Content-Length")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return req, err
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return req, fmt.Errorf("invalid message: %w", err)
+	}
+
+	return req, nil
+}
+
+// writeMessage writes a response or event to w, with its header.
+func writeMessage(w io.Writer, msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+
+	_, err = w.Write(body)
+
+	return err
+}
@@ -0,0 +1,643 @@
+// Package dap is a server for the Debug Adapter Protocol, which lets an
+// editor like VS Code debug a program that erc-assembler assembled: set
+// breakpoints on lines of its source, step through it, and look at the
+// stack, registers and memory while it's stopped.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pevans/erc/a2/a2sym"
+	"github.com/pevans/erc/assembler"
+)
+
+// A Target is the computer that we debug.
+type Target interface {
+	// PC returns the address of the next instruction, and Step executes
+	// it.
+	PC() uint16
+	Step() error
+
+	// Depth returns the number of subroutine calls that haven't returned,
+	// and Frames returns where each of them is, beginning with the
+	// innermost (which is at the PC).
+	Depth() int
+	Frames() []Frame
+
+	// Scopes returns the things a client can look at while we're stopped,
+	// like the registers.
+	Scopes() []Scope
+
+	// Evaluate returns the value of an expression, written as the
+	// debugger's print command would take it.
+	Evaluate(expr string) (string, error)
+}
+
+// A Frame is a place in a subroutine (or in the code that called it).
+type Frame struct {
+	Addr uint16
+	Name string
+}
+
+// A Scope is a set of variables, like the registers.
+type Scope struct {
+	Name      string
+	Variables []Variable
+}
+
+// A Variable is something that has a value the client can see.
+type Variable struct {
+	Name, Value string
+}
+
+// A Launcher returns the target for the program (a disk image) that the
+// client wants to debug.
+type Launcher func(program string) (Target, error)
+
+// A stepMode is how we're running the target: until something stops it,
+// or until we reach the next line of source in some way.
+type stepMode int
+
+const (
+	modeContinue stepMode = iota
+	modeStepIn
+	modeNext
+	modeStepOut
+)
+
+// threadID is the ID of the one thread we have.
+const threadID = 1
+
+// batchSize is the number of instructions we run between looking for
+// requests from the client.
+const batchSize = 10000
+
+// A Server answers the requests of one client.
+type Server struct {
+	r      *bufio.Reader
+	w      io.Writer
+	seq    int
+	launch Launcher
+
+	requests chan request
+	target   Target
+	lines    *lineMap
+
+	stopOnEntry bool
+
+	// sourceBreaks are the lines that the client wants to break on, by the
+	// path of their source, and breakpoints are the addresses they come to.
+	sourceBreaks map[string][]int
+	breakpoints  map[uint16]bool
+
+	running bool
+	mode    stepMode
+	depth   int
+	done    bool
+}
+
+// NewServer returns a server that reads requests from r and writes to w,
+// and which uses launch to start the program the client asks for.
+func NewServer(r io.Reader, w io.Writer, launch Launcher) *Server {
+	return &Server{
+		r:            bufio.NewReader(r),
+		w:            w,
+		launch:       launch,
+		requests:     make(chan request, 16),
+		sourceBreaks: make(map[string][]int),
+		breakpoints:  make(map[uint16]bool),
+	}
+}
+
+// Serve answers requests, and runs the target when the client wants it
+// to, until the client disconnects.
+func (s *Server) Serve() error {
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(s.requests)
+
+		for {
+			req, err := readRequest(s.r)
+			if err != nil {
+				if err != io.EOF {
+					errs <- err
+				}
+
+				return
+			}
+
+			s.requests <- req
+		}
+	}()
+
+	for !s.done {
+		if !s.running {
+			req, ok := <-s.requests
+			if !ok {
+				break
+			}
+
+			s.handle(req)
+
+			continue
+		}
+
+		select {
+		case req, ok := <-s.requests:
+			if !ok {
+				s.done = true
+				break
+			}
+
+			s.handle(req)
+
+		default:
+			s.run()
+		}
+	}
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// run executes a batch of instructions, unless something stops us first.
+func (s *Server) run() {
+	for range batchSize {
+		if err := s.target.Step(); err != nil {
+			s.output(fmt.Sprintf("couldn't execute instruction: %v\n", err))
+			s.stop("exception")
+
+			return
+		}
+
+		if reason, ok := s.shouldStop(); ok {
+			s.stop(reason)
+			return
+		}
+	}
+}
+
+// shouldStop returns true (and the reason) if we should stop where we are.
+func (s *Server) shouldStop() (string, bool) {
+	pc := s.target.PC()
+
+	if s.breakpoints[pc] {
+		return "breakpoint", true
+	}
+
+	switch s.mode {
+	case modeStepIn:
+		return "step", s.atLine(pc)
+	case modeNext:
+		return "step", s.target.Depth() <= s.depth && s.atLine(pc)
+	case modeStepOut:
+		return "step", s.target.Depth() < s.depth
+	}
+
+	return "", false
+}
+
+// atLine returns true if an instruction from a line of source begins at
+// addr. If we have no debug info, every instruction counts as a line.
+func (s *Server) atLine(addr uint16) bool {
+	if s.lines == nil {
+		return true
+	}
+
+	_, ok := s.lines.line(addr)
+
+	return ok
+}
+
+// resume runs the target in the given mode.
+func (s *Server) resume(mode stepMode) {
+	s.mode = mode
+	s.depth = s.target.Depth()
+	s.running = true
+}
+
+// stop stops running the target, and tells the client why.
+func (s *Server) stop(reason string) {
+	s.running = false
+	s.mode = modeContinue
+
+	s.event("stopped", map[string]any{
+		"reason":            reason,
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+}
+
+// handle answers a request.
+func (s *Server) handle(req request) {
+	if req.Type != "request" {
+		return
+	}
+
+	var (
+		body any
+		err  error
+	)
+
+	switch req.Command {
+	case "initialize":
+		body = map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+		}
+
+	case "launch":
+		err = s.launchTarget(req.Arguments)
+		if err == nil {
+			s.respond(req, nil, nil)
+
+			// Now that we know where the source is, the client can tell us
+			// where its breakpoints are
+			s.event("initialized", nil)
+
+			return
+		}
+
+	case "disconnect", "terminate":
+		s.done = true
+
+	default:
+		body, err = s.handleTarget(req)
+	}
+
+	s.respond(req, body, err)
+}
+
+// handleTarget answers the requests that need a target to have been
+// launched.
+func (s *Server) handleTarget(req request) (any, error) {
+	if req.Command == "setBreakpoints" {
+		return s.setBreakpoints(req.Arguments)
+	}
+
+	if s.target == nil {
+		return nil, fmt.Errorf("%v: nothing has been launched", req.Command)
+	}
+
+	if s.running {
+		switch req.Command {
+		case "pause":
+			return nil, s.afterResponse(req, func() { s.stop("pause") })
+		case "threads":
+			return s.threads(), nil
+		}
+
+		return nil, fmt.Errorf("%v: the program is running", req.Command)
+	}
+
+	switch req.Command {
+	case "configurationDone":
+		if s.stopOnEntry {
+			// We have to respond before we say we've stopped
+			return nil, s.afterResponse(req, func() { s.stop("entry") })
+		}
+
+		s.resume(modeContinue)
+
+	case "threads":
+		return s.threads(), nil
+
+	case "stackTrace":
+		return s.stackTrace(), nil
+
+	case "scopes":
+		return s.scopes(), nil
+
+	case "variables":
+		return s.variables(req.Arguments)
+
+	case "evaluate":
+		return s.evaluate(req.Arguments)
+
+	case "continue":
+		s.resume(modeContinue)
+		return map[string]any{"allThreadsContinued": true}, nil
+
+	case "next":
+		s.resume(modeNext)
+
+	case "stepIn":
+		s.resume(modeStepIn)
+
+	case "stepOut":
+		if s.target.Depth() == 0 {
+			return nil, fmt.Errorf("not in a subroutine (that we saw called)")
+		}
+
+		s.resume(modeStepOut)
+
+	case "pause":
+		// We're already stopped
+
+	default:
+		return nil, fmt.Errorf("%v is not supported", req.Command)
+	}
+
+	return nil, nil
+}
+
+// errResponded is returned by afterResponse, to say that there's nothing
+// left to respond with.
+var errResponded = errors.New("responded")
+
+// afterResponse responds to the request successfully, then calls f.
+func (s *Server) afterResponse(req request, f func()) error {
+	s.respond(req, nil, nil)
+	f()
+
+	return errResponded
+}
+
+// launchArgs are the arguments of a launch request.
+type launchArgs struct {
+	// Program is the disk image to boot.
+	Program string `json:"program"`
+
+	// DebugInfo is the file that erc-assembler wrote with -dbg. If it's
+	// empty, we look for a .dbg file with the same name as the program.
+	DebugInfo string `json:"debugInfo"`
+
+	StopOnEntry bool `json:"stopOnEntry"`
+}
+
+// launchTarget starts the program that the client asked for.
+func (s *Server) launchTarget(raw json.RawMessage) error {
+	var args launchArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return fmt.Errorf("invalid launch arguments: %w", err)
+	}
+
+	if args.Program == "" {
+		return fmt.Errorf("launch requires a program")
+	}
+
+	infoPath := args.DebugInfo
+	if infoPath == "" {
+		guess := strings.TrimSuffix(args.Program, filepath.Ext(args.Program)) + ".dbg"
+		if _, err := os.Stat(guess); err == nil {
+			infoPath = guess
+		}
+	}
+
+	if infoPath != "" {
+		info, err := assembler.LoadDebugInfo(infoPath)
+		if err != nil {
+			return fmt.Errorf("could not load debug info: %w", err)
+		}
+
+		s.lines = newLineMap(info)
+
+		// The names of the program's labels are better than anything else
+		// we could call those addresses
+		symbols := a2sym.Table{}
+		for name, addr := range info.Labels {
+			symbols[int(addr)] = name
+		}
+
+		a2sym.Use(symbols)
+	}
+
+	target, err := s.launch(args.Program)
+	if err != nil {
+		return err
+	}
+
+	s.target = target
+	s.stopOnEntry = args.StopOnEntry
+	s.resolveBreakpoints()
+
+	return nil
+}
+
+// breakpointArgs are the arguments of a setBreakpoints request.
+type breakpointArgs struct {
+	Source struct {
+		Path string `json:"path"`
+	} `json:"source"`
+
+	Breakpoints []struct {
+		Line int `json:"line"`
+	} `json:"breakpoints"`
+}
+
+// setBreakpoints replaces the breakpoints in a source with the ones the
+// client gave us, and tells it which lines they ended up on.
+func (s *Server) setBreakpoints(raw json.RawMessage) (any, error) {
+	var args breakpointArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid setBreakpoints arguments: %w", err)
+	}
+
+	lines := make([]int, len(args.Breakpoints))
+	for i, bp := range args.Breakpoints {
+		lines[i] = bp.Line
+	}
+
+	s.sourceBreaks[args.Source.Path] = lines
+	s.resolveBreakpoints()
+
+	results := make([]map[string]any, len(lines))
+	for i, line := range lines {
+		results[i] = s.breakpointResult(args.Source.Path, line)
+	}
+
+	return map[string]any{"breakpoints": results}, nil
+}
+
+// breakpointResult describes where a breakpoint on a line of source went.
+func (s *Server) breakpointResult(path string, line int) map[string]any {
+	if !s.lines.covers(path) {
+		return map[string]any{
+			"verified": false,
+			"line":     line,
+			"message":  "no debug info for this source",
+		}
+	}
+
+	_, at, ok := s.lines.resolve(line)
+	if !ok {
+		return map[string]any{
+			"verified": false,
+			"line":     line,
+			"message":  "no code at or after this line",
+		}
+	}
+
+	return map[string]any{"verified": true, "line": at}
+}
+
+// resolveBreakpoints works out the address of each line we break on.
+func (s *Server) resolveBreakpoints() {
+	s.breakpoints = make(map[uint16]bool)
+
+	for path, lines := range s.sourceBreaks {
+		if !s.lines.covers(path) {
+			continue
+		}
+
+		for _, line := range lines {
+			if addr, _, ok := s.lines.resolve(line); ok {
+				s.breakpoints[addr] = true
+			}
+		}
+	}
+}
+
+func (s *Server) threads() any {
+	return map[string]any{
+		"threads": []map[string]any{{"id": threadID, "name": "6502"}},
+	}
+}
+
+// stackTrace returns the frames of the stack, innermost first.
+func (s *Server) stackTrace() any {
+	frames := s.target.Frames()
+	out := make([]map[string]any, len(frames))
+
+	for i, frame := range frames {
+		f := map[string]any{
+			"id":                          i + 1,
+			"name":                        frame.Name,
+			"line":                        0,
+			"column":                      0,
+			"instructionPointerReference": fmt.Sprintf("0x%04X", frame.Addr),
+		}
+
+		if line, ok := s.lines.line(frame.Addr); ok {
+			f["line"] = line
+			f["column"] = 1
+			f["source"] = map[string]any{
+				"name": filepath.Base(s.lines.source),
+				"path": s.lines.source,
+			}
+		} else {
+			f["name"] = fmt.Sprintf("%v ($%04X)", frame.Name, frame.Addr)
+			f["presentationHint"] = "subtle"
+		}
+
+		out[i] = f
+	}
+
+	return map[string]any{"stackFrames": out, "totalFrames": len(out)}
+}
+
+// scopes returns the scopes of the target. Each scope's variables are
+// referred to by its place in the list (counting from 1).
+func (s *Server) scopes() any {
+	scopes := s.target.Scopes()
+	out := make([]map[string]any, len(scopes))
+
+	for i, scope := range scopes {
+		out[i] = map[string]any{
+			"name":               scope.Name,
+			"variablesReference": i + 1,
+			"expensive":          false,
+		}
+	}
+
+	return map[string]any{"scopes": out}
+}
+
+func (s *Server) variables(raw json.RawMessage) (any, error) {
+	var args struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid variables arguments: %w", err)
+	}
+
+	scopes := s.target.Scopes()
+	if args.VariablesReference < 1 || args.VariablesReference > len(scopes) {
+		return nil, fmt.Errorf("no variables for reference %d", args.VariablesReference)
+	}
+
+	vars := scopes[args.VariablesReference-1].Variables
+	out := make([]map[string]any, len(vars))
+
+	for i, v := range vars {
+		out[i] = map[string]any{
+			"name":               v.Name,
+			"value":              v.Value,
+			"variablesReference": 0,
+		}
+	}
+
+	return map[string]any{"variables": out}, nil
+}
+
+func (s *Server) evaluate(raw json.RawMessage) (any, error) {
+	var args struct {
+		Expression string `json:"expression"`
+	}
+
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid evaluate arguments: %w", err)
+	}
+
+	result, err := s.target.Evaluate(args.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{"result": result, "variablesReference": 0}, nil
+}
+
+// respond sends the response to a request: the body if err is nil, or
+// else the error.
+func (s *Server) respond(req request, body any, err error) {
+	if errors.Is(err, errResponded) {
+		return
+	}
+
+	resp := response{
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Success:    err == nil,
+		Command:    req.Command,
+		Body:       body,
+	}
+
+	if err != nil {
+		resp.Message = err.Error()
+	}
+
+	s.seq++
+	resp.Seq = s.seq
+
+	s.write(resp)
+}
+
+// event sends an event with the given body.
+func (s *Server) event(name string, body any) {
+	s.seq++
+	s.write(event{Seq: s.seq, Type: "event", Event: name, Body: body})
+}
+
+// output sends some text for the client to show in its debug console.
+func (s *Server) output(text string) {
+	s.event("output", map[string]any{"category": "console", "output": text})
+}
+
+// write writes a message to the client. If we can't, the client is gone,
+// and we'll find out from reading.
+func (s *Server) write(msg any) {
+	_ = writeMessage(s.w, msg)
+}
@@ -0,0 +1,403 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pevans/erc/assembler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// An op is an instruction of the fake target: a JSR, RTS or JMP to addr,
+// or else something that just moves on to the next one.
+type op struct {
+	name string
+	addr uint16
+	size uint16
+}
+
+// fakeTarget runs a program made of ops, and keeps its own stack of
+// return addresses.
+type fakeTarget struct {
+	pc    uint16
+	ops   map[uint16]op
+	stack []uint16
+}
+
+// The program we debug, which is at these lines of prog.s.
+//
+//	1: ; a test
+//	2: start  JSR sub     ($0800)
+//	3:        NOP         ($0803)
+//	4: loop   JMP loop    ($0804)
+//	5:
+//	6: ; the subroutine
+//	7: sub    NOP         ($0810)
+//	8:        RTS         ($0811)
+func newFakeTarget() *fakeTarget {
+	return &fakeTarget{
+		pc: 0x0800,
+		ops: map[uint16]op{
+			0x0800: {name: "JSR", addr: 0x0810, size: 3},
+			0x0803: {name: "NOP", size: 1},
+			0x0804: {name: "JMP", addr: 0x0804, size: 3},
+			0x0810: {name: "NOP", size: 1},
+			0x0811: {name: "RTS", size: 1},
+		},
+	}
+}
+
+func testDebugInfo() *assembler.DebugInfo {
+	return &assembler.DebugInfo{
+		Source: "/src/prog.s",
+		Lines: []assembler.LineInfo{
+			{Line: 2, Addr: 0x0800, Size: 3, Code: true},
+			{Line: 3, Addr: 0x0803, Size: 1, Code: true},
+			{Line: 4, Addr: 0x0804, Size: 3, Code: true},
+			{Line: 7, Addr: 0x0810, Size: 1, Code: true},
+			{Line: 8, Addr: 0x0811, Size: 1, Code: true},
+		},
+		Labels: map[string]uint16{
+			"start": 0x0800, "loop": 0x0804, "sub": 0x0810,
+		},
+	}
+}
+
+func (f *fakeTarget) PC() uint16 { return f.pc }
+func (f *fakeTarget) Depth() int { return len(f.stack) }
+
+func (f *fakeTarget) Step() error {
+	o, ok := f.ops[f.pc]
+	if !ok {
+		return fmt.Errorf("no instruction at $%04X", f.pc)
+	}
+
+	switch o.name {
+	case "JSR":
+		f.stack = append(f.stack, f.pc+o.size)
+		f.pc = o.addr
+	case "RTS":
+		f.pc = f.stack[len(f.stack)-1]
+		f.stack = f.stack[:len(f.stack)-1]
+	case "JMP":
+		f.pc = o.addr
+	default:
+		f.pc += o.size
+	}
+
+	return nil
+}
+
+func (f *fakeTarget) Frames() []Frame {
+	frames := []Frame{{Addr: f.pc, Name: "here"}}
+	for i := len(f.stack) - 1; i >= 0; i-- {
+		frames = append(frames, Frame{Addr: f.stack[i] - 3, Name: "caller"})
+	}
+
+	return frames
+}
+
+func (f *fakeTarget) Scopes() []Scope {
+	return []Scope{
+		{Name: "Registers", Variables: []Variable{
+			{Name: "PC", Value: fmt.Sprintf("$%04X", f.pc)},
+		}},
+	}
+}
+
+func (f *fakeTarget) Evaluate(expr string) (string, error) {
+	if expr != "pc" {
+		return "", fmt.Errorf("unknown: %v", expr)
+	}
+
+	return fmt.Sprintf("$%04X", f.pc), nil
+}
+
+// A client talks to a server as an editor would.
+type client struct {
+	t      *testing.T
+	w      io.Writer
+	r      *bufio.Reader
+	seq    int
+	target *fakeTarget
+	done   chan error
+}
+
+// newClient starts a server, and launches the program with the given
+// arguments.
+func newClient(t *testing.T, launch map[string]any) *client {
+	var (
+		inR, inW   = io.Pipe()
+		outR, outW = io.Pipe()
+		c          = &client{t: t, w: inW, r: bufio.NewReader(outR), done: make(chan error, 1)}
+	)
+
+	server := NewServer(inR, outW, func(program string) (Target, error) {
+		c.target = newFakeTarget()
+		return c.target, nil
+	})
+
+	go func() {
+		c.done <- server.Serve()
+		_ = outW.Close()
+	}()
+
+	t.Cleanup(func() { _ = inW.Close() })
+
+	resp := c.request("initialize", nil)
+	require.True(t, resp["success"].(bool))
+
+	resp = c.request("launch", launch)
+	require.True(t, resp["success"].(bool), resp["message"])
+	c.wait("initialized")
+
+	return c
+}
+
+// send sends a request, and returns its sequence number.
+func (c *client) send(command string, args any) int {
+	c.seq++
+
+	msg := map[string]any{"seq": c.seq, "type": "request", "command": command}
+	if args != nil {
+		msg["arguments"] = args
+	}
+
+	require.NoError(c.t, writeMessage(c.w, msg))
+
+	return c.seq
+}
+
+// read reads the next message from the server.
+func (c *client) read() map[string]any {
+	length := -1
+
+	for {
+		line, err := c.r.ReadString('\n')
+		require.NoError(c.t, err)
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		if val, ok := strings.CutPrefix(line, "Content-Length:"); ok {
+			length, err = strconv.Atoi(strings.TrimSpace(val))
+			require.NoError(c.t, err)
+		}
+	}
+
+	body := make([]byte, length)
+	_, err := io.ReadFull(c.r, body)
+	require.NoError(c.t, err)
+
+	var msg map[string]any
+	require.NoError(c.t, json.Unmarshal(body, &msg))
+
+	return msg
+}
+
+// request sends a request, and returns its response, skipping over any
+// events.
+func (c *client) request(command string, args any) map[string]any {
+	seq := c.send(command, args)
+
+	for {
+		msg := c.read()
+		if msg["type"] == "response" && int(msg["request_seq"].(float64)) == seq {
+			return msg
+		}
+	}
+}
+
+// wait returns the body of the next event with the given name.
+func (c *client) wait(name string) map[string]any {
+	for {
+		msg := c.read()
+		if msg["type"] == "event" && msg["event"] == name {
+			body, _ := msg["body"].(map[string]any)
+			return body
+		}
+	}
+}
+
+// stopped waits for the target to stop, and returns why it did.
+func (c *client) stopped() string {
+	return c.wait("stopped")["reason"].(string)
+}
+
+// body returns the body of a successful response to a request.
+func (c *client) body(command string, args any) map[string]any {
+	resp := c.request(command, args)
+	require.True(c.t, resp["success"].(bool), resp["message"])
+
+	return resp["body"].(map[string]any)
+}
+
+// writeDebugInfo writes the test debug info next to a program, where the
+// server will look for it, and returns the program's path.
+func writeDebugInfo(t *testing.T) string {
+	dir := t.TempDir()
+
+	data, err := json.Marshal(testDebugInfo())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prog.dbg"), data, 0o644))
+
+	return filepath.Join(dir, "prog.dsk")
+}
+
+func setBreakpoints(c *client, lines ...int) []any {
+	bps := make([]map[string]any, len(lines))
+	for i, line := range lines {
+		bps[i] = map[string]any{"line": line}
+	}
+
+	body := c.body("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": "/elsewhere/prog.s"},
+		"breakpoints": bps,
+	})
+
+	return body["breakpoints"].([]any)
+}
+
+func TestBreakpoints(t *testing.T) {
+	c := newClient(t, map[string]any{"program": writeDebugInfo(t)})
+
+	bps := setBreakpoints(c, 1, 5, 9)
+	require.Len(t, bps, 3)
+
+	// A line with no code moves to the next that has some
+	assert.Equal(t, true, bps[0].(map[string]any)["verified"])
+	assert.Equal(t, 2.0, bps[0].(map[string]any)["line"])
+	assert.Equal(t, 7.0, bps[1].(map[string]any)["line"])
+	assert.Equal(t, false, bps[2].(map[string]any)["verified"])
+
+	c.request("configurationDone", nil)
+	assert.Equal(t, "breakpoint", c.stopped())
+	assert.Equal(t, uint16(0x0810), c.target.pc)
+
+	frames := c.body("stackTrace", map[string]any{"threadId": 1})["stackFrames"].([]any)
+	require.Len(t, frames, 2)
+
+	top := frames[0].(map[string]any)
+	assert.Equal(t, 7.0, top["line"])
+	assert.Equal(t, "/src/prog.s", top["source"].(map[string]any)["path"])
+	assert.Equal(t, 2.0, frames[1].(map[string]any)["line"])
+}
+
+func TestStopOnEntry(t *testing.T) {
+	c := newClient(t, map[string]any{"program": writeDebugInfo(t), "stopOnEntry": true})
+
+	c.request("configurationDone", nil)
+	assert.Equal(t, "entry", c.stopped())
+	assert.Equal(t, uint16(0x0800), c.target.pc)
+}
+
+func TestStepping(t *testing.T) {
+	c := newClient(t, map[string]any{"program": writeDebugInfo(t), "stopOnEntry": true})
+
+	c.request("configurationDone", nil)
+	c.stopped()
+
+	// Step into the subroutine, then out of it
+	c.request("stepIn", nil)
+	assert.Equal(t, "step", c.stopped())
+	assert.Equal(t, uint16(0x0810), c.target.pc)
+
+	c.request("stepOut", nil)
+	c.stopped()
+	assert.Equal(t, uint16(0x0803), c.target.pc)
+
+	// Run to the start again, and step over the subroutine
+	c.target.pc = 0x0800
+	c.request("next", nil)
+	c.stopped()
+	assert.Equal(t, uint16(0x0803), c.target.pc)
+
+	// We can't step out of nothing
+	resp := c.request("stepOut", nil)
+	assert.False(t, resp["success"].(bool))
+}
+
+func TestPause(t *testing.T) {
+	c := newClient(t, map[string]any{"program": writeDebugInfo(t)})
+
+	setBreakpoints(c, 4)
+	c.request("configurationDone", nil)
+	assert.Equal(t, "breakpoint", c.stopped())
+
+	// The program loops forever, so without the breakpoint, we only stop if
+	// we pause
+	setBreakpoints(c)
+	c.request("continue", nil)
+
+	resp := c.request("pause", map[string]any{"threadId": 1})
+	assert.True(t, resp["success"].(bool))
+	assert.Equal(t, "pause", c.stopped())
+	assert.Equal(t, uint16(0x0804), c.target.pc)
+}
+
+func TestVariables(t *testing.T) {
+	c := newClient(t, map[string]any{"program": writeDebugInfo(t), "stopOnEntry": true})
+
+	c.request("configurationDone", nil)
+	c.stopped()
+
+	scopes := c.body("scopes", map[string]any{"frameId": 1})["scopes"].([]any)
+	require.Len(t, scopes, 1)
+	assert.Equal(t, "Registers", scopes[0].(map[string]any)["name"])
+
+	vars := c.body("variables", map[string]any{"variablesReference": 1})["variables"].([]any)
+	require.Len(t, vars, 1)
+	assert.Equal(t, "$0800", vars[0].(map[string]any)["value"])
+
+	resp := c.request("variables", map[string]any{"variablesReference": 2})
+	assert.False(t, resp["success"].(bool))
+
+	assert.Equal(t, "$0800", c.body("evaluate", map[string]any{"expression": "pc"})["result"])
+
+	resp = c.request("evaluate", map[string]any{"expression": "nope"})
+	assert.False(t, resp["success"].(bool))
+	assert.Equal(t, "unknown: nope", resp["message"])
+}
+
+func TestDisconnect(t *testing.T) {
+	c := newClient(t, map[string]any{"program": writeDebugInfo(t)})
+
+	resp := c.request("disconnect", nil)
+	assert.True(t, resp["success"].(bool))
+	assert.NoError(t, <-c.done)
+}
+
+func TestLaunchErrors(t *testing.T) {
+	var (
+		inR, inW   = io.Pipe()
+		outR, outW = io.Pipe()
+		c          = &client{t: t, w: inW, r: bufio.NewReader(outR)}
+	)
+
+	server := NewServer(inR, outW, func(program string) (Target, error) {
+		return nil, fmt.Errorf("no such disk")
+	})
+
+	go func() { _ = server.Serve() }()
+	t.Cleanup(func() { _ = inW.Close() })
+
+	resp := c.request("launch", map[string]any{})
+	assert.False(t, resp["success"].(bool))
+	assert.Equal(t, "launch requires a program", resp["message"])
+
+	resp = c.request("launch", map[string]any{"program": "x.dsk"})
+	assert.Equal(t, "no such disk", resp["message"])
+
+	resp = c.request("stackTrace", nil)
+	assert.Equal(t, "stackTrace: nothing has been launched", resp["message"])
+}
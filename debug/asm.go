@@ -0,0 +1,125 @@
+package debug
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/a2/a2sym"
+	"github.com/pevans/erc/assembler"
+)
+
+// asmState is what we know while we're assembling lines into memory. If
+// active is true, each line we're given is assembled at loc, rather than
+// executed as a command.
+var asmState struct {
+	active bool
+	loc    location
+}
+
+// asmLabels are the labels that lines we have assembled defined, so that
+// later lines (even in later asm sessions) can use them. Lines can also
+// refer to the names from a2sym, which we look up each time, since more
+// symbols may have been loaded since.
+var asmLabels = make(map[string]uint16)
+
+func asm(comp *a2.Computer, tokens []string) {
+	if len(tokens) < 2 {
+		say("invalid command: 'asm' requires an address, like: asm <addr> [instruction]")
+		return
+	}
+
+	loc, err := parseLocation(tokens[1])
+	if err != nil {
+		say(fmt.Sprintf("invalid address: %v", err))
+		return
+	}
+
+	if loc.bank == a2.BankROM {
+		say("invalid address: rom can't be written to")
+		return
+	}
+
+	asmState.loc = loc
+
+	// With an instruction, we just assemble that one line
+	if len(tokens) > 2 {
+		assembleLine(comp, strings.Join(tokens[2:], " "))
+		return
+	}
+
+	asmState.active = true
+	say("assembling into memory; enter an empty line to stop")
+}
+
+// asmPrompt returns the prompt we show while we're assembling lines.
+func asmPrompt() string {
+	return fmt.Sprintf("asm %v> ", asmState.loc)
+}
+
+// asmInput handles a line of input while we're assembling lines. An empty
+// line stops us.
+func asmInput(comp *a2.Computer, line string) {
+	if strings.TrimSpace(line) == "" {
+		asmState.active = false
+		say("stopped assembling")
+
+		return
+	}
+
+	assembleLine(comp, line)
+}
+
+// assembleLine assembles the line at the location we're assembling to, and
+// moves that location past what we wrote.
+func assembleLine(comp *a2.Computer, line string) {
+	symbols := make(map[string]uint16)
+	for name, addr := range a2sym.Labels() {
+		symbols[name] = uint16(addr)
+	}
+
+	labels := maps.Clone(symbols)
+	maps.Copy(labels, asmLabels)
+
+	loc := asmState.loc
+
+	code, start, err := assembler.AssembleLine(line, uint16(loc.addr), labels, comp.CPU.Variant)
+	if err != nil {
+		say(fmt.Sprintf("couldn't assemble: %v", err))
+		return
+	}
+
+	// Whatever label the line defined is one we keep for ourselves
+	for name, addr := range labels {
+		if sym, ok := symbols[name]; !ok || sym != addr {
+			asmLabels[name] = addr
+		}
+	}
+
+	loc.addr = int(start)
+	if len(code) > 0 && !loc.bank.Contains(loc.addr+len(code)-1) {
+		say(fmt.Sprintf("couldn't assemble: %d bytes at %v would run past the end of memory", len(code), loc))
+		return
+	}
+
+	hexCol := make([]string, len(code))
+	for i, b := range code {
+		if err := comp.BankSet(loc.bank, loc.addr+i, b); err != nil {
+			say(fmt.Sprintf("couldn't assemble: %v", err))
+			return
+		}
+
+		hexCol[i] = fmt.Sprintf("%02X", b)
+	}
+
+	say(fmt.Sprintf("%v:%-11s | %s", loc, strings.Join(hexCol, " "), strings.TrimSpace(line)))
+
+	loc.addr += len(code)
+	if loc.bank.Contains(loc.addr) {
+		asmState.loc = loc
+	} else {
+		asmState.active = false
+		say("reached the end of memory; stopped assembling")
+	}
+}
@@ -0,0 +1,103 @@
+package debug
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// breakpointFile is the file where we keep the breakpoints for the disk
+// image we're debugging. If it's empty, we don't keep them anywhere.
+var breakpointFile string
+
+// disabledPrefix begins each line of a breakpoint file that is for a
+// disabled breakpoint.
+const disabledPrefix = "disabled "
+
+// LoadBreakpoints adds the breakpoints saved for the given disk image, which
+// are kept in a file alongside it (e.g. game.dsk.breakpoints). From then on,
+// any change made to the breakpoints in the debugger is saved to that file.
+// It returns the number of breakpoints it loaded. It's not an error if the
+// file doesn't exist; that just means no breakpoints were saved.
+func LoadBreakpoints(image string) (int, error) {
+	breakpointFile = fmt.Sprintf("%v.breakpoints", image)
+
+	file, err := os.Open(breakpointFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("could not open breakpoint file: %w", err)
+	}
+
+	defer file.Close() //nolint:errcheck
+
+	loaded := 0
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		spec, disabled := strings.CutPrefix(line, disabledPrefix)
+
+		bp, err := parseBreakpoint(strings.Fields(spec))
+		if err != nil {
+			return loaded, fmt.Errorf("%v:%d: invalid breakpoint %q: %w", breakpointFile, lineNum, spec, err)
+		}
+
+		bp.Enabled = !disabled
+		addBreakpoint(bp)
+		loaded++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return loaded, fmt.Errorf("could not read breakpoint file: %w", err)
+	}
+
+	if loaded > 0 {
+		say(fmt.Sprintf("loaded %d breakpoints from %v", loaded, breakpointFile))
+	}
+
+	return loaded, nil
+}
+
+// saveBreakpoints writes the breakpoints we have to the breakpoint file, one
+// per line, in the order of their numbers. Transient breakpoints are left
+// out. If there are no breakpoints to save, the file is removed.
+func saveBreakpoints() {
+	if breakpointFile == "" {
+		return
+	}
+
+	bps := slices.DeleteFunc(sortedBreakpoints(), func(bp *Breakpoint) bool {
+		return bp.transient
+	})
+
+	if len(bps) == 0 {
+		err := os.Remove(breakpointFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			say(fmt.Sprintf("couldn't remove breakpoint file %v: %v", breakpointFile, err))
+		}
+
+		return
+	}
+
+	var sb strings.Builder
+	for _, bp := range bps {
+		if !bp.Enabled {
+			sb.WriteString(disabledPrefix)
+		}
+
+		sb.WriteString(bp.String())
+		sb.WriteString("\n")
+	}
+
+	if err := os.WriteFile(breakpointFile, []byte(sb.String()), 0o644); err != nil {
+		say(fmt.Sprintf("couldn't save breakpoints to %v: %v", breakpointFile, err))
+	}
+}
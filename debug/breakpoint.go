@@ -2,34 +2,450 @@ package debug
 
 import (
 	"fmt"
-	"strconv"
+	"slices"
+	"sort"
 	"strings"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/debug/expr"
 )
 
-// A set of addresses at which we should automatically enter the debugger.
-var breakpoints = make(map[int]bool)
+// A Breakpoint is an address at which we should automatically enter the
+// debugger, so long as its condition (if it has one) is true.
+type Breakpoint struct {
+	// ID is the number by which the debugger commands refer to the
+	// breakpoint.
+	ID int
+
+	Addr int
+	Cond *expr.Expr
+
+	// Enabled is true if we should check the breakpoint at all. A disabled
+	// breakpoint is kept around so it can be enabled again later.
+	Enabled bool
+
+	// Ignore is the number of times that we'll let the breakpoint pass
+	// before we stop at it.
+	Ignore int
+
+	// ignored is the number of times that we have let it pass so far.
+	ignored int
+
+	// Log, if set, is a message we show when the breakpoint is hit. We
+	// carry on afterward rather than stop.
+	Log string
+
+	// Commands, if there are any, are debugger commands we run when we stop
+	// at the breakpoint. If one of them resumes emulation, we don't stop
+	// after all.
+	Commands []string
+
+	// Hits is the number of times that the PC has reached the address.
+	Hits int
+
+	// transient is true if the breakpoint was given on the command line
+	// (see ParseBreakpoints). It's only for this run, so we don't save it
+	// to the breakpoint file.
+	transient bool
+}
+
+// String returns the breakpoint in the form that the break command accepts.
+func (bp *Breakpoint) String() string {
+	s := fmt.Sprintf("%04X", bp.Addr)
+
+	if bp.Cond != nil {
+		s += " if " + bp.Cond.String()
+	}
+
+	if bp.Ignore > 0 {
+		s += fmt.Sprintf(" ignore %d", bp.Ignore)
+	}
+
+	if bp.Log != "" {
+		s += " log " + bp.Log
+	}
 
+	if len(bp.Commands) > 0 {
+		s += " do " + strings.Join(bp.Commands, "; ")
+	}
+
+	return s
+}
+
+// The breakpoints we have, by address.
+var breakpoints = make(map[int]*Breakpoint)
+
+// nextBreakpointID is the number we'll give to the next breakpoint we add.
+var nextBreakpointID = 1
+
+// lastStop is the PC and cycle count at which we last stopped for a
+// breakpoint. We won't stop there again until the CPU has moved on;
+// otherwise, we could never resume from a breakpoint.
+var lastStop struct {
+	pc     uint16
+	cycles uint64
+	set    bool
+}
+
+// runningCommands is the breakpoint whose commands we're running, if we
+// are. A breakpoint that's hit while they run (say, by a next command) just
+// stops, rather than running commands of its own.
+var runningCommands *Breakpoint
+
+// AddBreakpoint adds a breakpoint without any condition at the given
+// address.
 func AddBreakpoint(addr int) {
-	breakpoints[addr] = true
+	addBreakpoint(&Breakpoint{Addr: addr, Enabled: true})
+}
+
+// HasBreakpoints returns true if there are any breakpoints, enabled or not.
+func HasBreakpoints() bool {
+	return len(breakpoints) > 0
 }
 
-func HasBreakpoint(addr int) bool {
-	return breakpoints[addr]
+// addBreakpoint adds the given breakpoint. If there's already one at its
+// address, it's replaced, but the new breakpoint keeps the old one's number.
+func addBreakpoint(bp *Breakpoint) {
+	if old, ok := breakpoints[bp.Addr]; ok {
+		bp.ID = old.ID
+	} else {
+		bp.ID = nextBreakpointID
+		nextBreakpointID++
+	}
+
+	breakpoints[bp.Addr] = bp
 }
 
-// ParseBreakpoints parses a comma-separated list of hex addresses and adds
-// each as a breakpoint. It returns an error if any address is invalid.
+// sortedBreakpoints returns the breakpoints we have in the order of their
+// numbers.
+func sortedBreakpoints() []*Breakpoint {
+	bps := make([]*Breakpoint, 0, len(breakpoints))
+	for _, bp := range breakpoints {
+		bps = append(bps, bp)
+	}
+
+	sort.Slice(bps, func(i, j int) bool {
+		return bps[i].ID < bps[j].ID
+	})
+
+	return bps
+}
+
+// CheckBreakpoint returns true if the computer should stop at the
+// breakpoint at its PC, if there is one. Breakpoints that only log a message
+// do so here.
+func CheckBreakpoint(comp *a2.Computer) bool {
+	bp, ok := breakpoints[int(comp.CPU.PC)]
+	if !ok || !bp.Enabled {
+		return false
+	}
+
+	if lastStop.set && lastStop.pc == comp.CPU.PC && lastStop.cycles == comp.CPU.CycleCounter() {
+		return false
+	}
+
+	bp.Hits++
+
+	if bp.Cond != nil {
+		ok, err := bp.Cond.True(env{comp: comp, hits: bp.Hits})
+		if err != nil {
+			say(fmt.Sprintf("breakpoint at $%04X: couldn't evaluate condition: %v", bp.Addr, err))
+		} else if !ok {
+			return false
+		}
+	}
+
+	if bp.ignored < bp.Ignore {
+		bp.ignored++
+		return false
+	}
+
+	if bp.Log != "" {
+		say(interpolate(comp, bp.Log, bp.Hits))
+		return false
+	}
+
+	lastStop.pc = comp.CPU.PC
+	lastStop.cycles = comp.CPU.CycleCounter()
+	lastStop.set = true
+
+	say(fmt.Sprintf("stopped at breakpoint %v (hit %d times)", bp, bp.Hits))
+
+	if len(bp.Commands) > 0 && runningCommands == nil {
+		return runCommands(comp, bp)
+	}
+
+	return true
+}
+
+// runCommands runs the commands of the breakpoint we've stopped at. It
+// returns true if we should stay stopped, which we do unless one of the
+// commands resumed emulation.
+func runCommands(comp *a2.Computer, bp *Breakpoint) bool {
+	runningCommands = bp
+	defer func() { runningCommands = nil }()
+
+	// If we were already in the debugger (say, because we hit the breakpoint
+	// while running next), resuming just lets that command carry on
+	wasStopped := comp.State.Bool(a2state.Debugger)
+	comp.State.SetBool(a2state.Debugger, true)
+
+	for _, cmd := range bp.Commands {
+		execute(comp, cmd)
+
+		if !comp.State.Bool(a2state.Debugger) {
+			comp.State.SetBool(a2state.Debugger, wasStopped)
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseBreakpoints parses a comma-separated list of breakpoints and adds
+// them. Each is written as the break command would take it, e.g. "0801" or
+// "0801 if A == $C1" (so the commands of a breakpoint can't have a comma).
+// These breakpoints are not saved to the breakpoint file. It returns an error
+// if any breakpoint is invalid.
 func ParseBreakpoints(flagVal string) error {
-	for addrStr := range strings.SplitSeq(flagVal, ",") {
-		addrStr = strings.TrimSpace(addrStr)
-		if addrStr == "" {
+	for spec := range strings.SplitSeq(flagVal, ",") {
+		fields := strings.Fields(spec)
+		if len(fields) == 0 {
 			continue
 		}
-		addr, err := strconv.ParseUint(addrStr, 16, 16)
+
+		bp, err := parseBreakpoint(fields)
 		if err != nil {
-			return fmt.Errorf("invalid breakpoint address %q: %v", addrStr, err)
+			return fmt.Errorf("invalid breakpoint %q: %w", strings.TrimSpace(spec), err)
 		}
-		AddBreakpoint(int(addr))
+
+		bp.transient = true
+		addBreakpoint(bp)
 	}
+
 	return nil
 }
+
+// parseBreakpoint parses the fields of a breakpoint, which are an address,
+// then optionally a condition (if <cond>), an ignore count (ignore <n>), and
+// either a message to log (log <message>) or commands to run (do <command>;
+// <command>...), in that order.
+func parseBreakpoint(fields []string) (*Breakpoint, error) {
+	addr, err := address(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %w", err)
+	}
+
+	var (
+		bp   = &Breakpoint{Addr: addr, Enabled: true}
+		rest = fields[1:]
+	)
+
+	if len(rest) > 0 && rest[0] == "if" {
+		end := 1
+		for end < len(rest) && !slices.Contains([]string{"ignore", "log", "do"}, rest[end]) {
+			end++
+		}
+
+		bp.Cond, err = expr.Parse(strings.Join(rest[1:end], " "))
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition: %w", err)
+		}
+
+		rest = rest[end:]
+	}
+
+	if len(rest) > 0 && rest[0] == "ignore" {
+		if len(rest) < 2 {
+			return nil, fmt.Errorf("ignore requires a count")
+		}
+
+		bp.Ignore, err = integer(rest[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore count: %w", err)
+		}
+
+		rest = rest[2:]
+	}
+
+	if len(rest) > 0 && rest[0] == "log" {
+		if len(rest) < 2 {
+			return nil, fmt.Errorf("log requires a message")
+		}
+
+		bp.Log = strings.Join(rest[1:], " ")
+		rest = nil
+	}
+
+	if len(rest) > 0 && rest[0] == "do" {
+		for cmd := range strings.SplitSeq(strings.Join(rest[1:], " "), ";") {
+			if cmd = strings.TrimSpace(cmd); cmd != "" {
+				bp.Commands = append(bp.Commands, cmd)
+			}
+		}
+
+		if len(bp.Commands) == 0 {
+			return nil, fmt.Errorf("do requires a command")
+		}
+
+		rest = nil
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected %q", rest[0])
+	}
+
+	return bp, nil
+}
+
+func breakCmd(_ *a2.Computer, tokens []string) {
+	if len(tokens) < 2 {
+		say("invalid command: 'break' requires an address, like: break <addr> [if <cond>] [ignore <n>] [log <message> | do <commands>]")
+		return
+	}
+
+	bp, err := parseBreakpoint(tokens[1:])
+	if err != nil {
+		say(fmt.Sprintf("invalid breakpoint: %v", err))
+		return
+	}
+
+	addBreakpoint(bp)
+	say(fmt.Sprintf("breakpoint %d set at %v", bp.ID, bp))
+	saveBreakpoints()
+}
+
+func deleteCmd(_ *a2.Computer, tokens []string) {
+	if len(tokens) == 1 {
+		breakpoints = make(map[int]*Breakpoint)
+		say("deleted all breakpoints")
+		saveBreakpoints()
+
+		return
+	}
+
+	bps, err := breakpointsByID(tokens[1:])
+	if err != nil {
+		say(err.Error())
+		return
+	}
+
+	for _, bp := range bps {
+		delete(breakpoints, bp.Addr)
+		say(fmt.Sprintf("deleted breakpoint %d at %04X", bp.ID, bp.Addr))
+	}
+
+	saveBreakpoints()
+}
+
+func enable(_ *a2.Computer, tokens []string) {
+	setEnabled(tokens, true)
+}
+
+func disable(_ *a2.Computer, tokens []string) {
+	setEnabled(tokens, false)
+}
+
+// setEnabled enables or disables the breakpoints whose numbers are given in
+// tokens, or all of them if no numbers are given.
+func setEnabled(tokens []string, enabled bool) {
+	verb := "disabled"
+	if enabled {
+		verb = "enabled"
+	}
+
+	bps := sortedBreakpoints()
+	if len(tokens) > 1 {
+		var err error
+
+		bps, err = breakpointsByID(tokens[1:])
+		if err != nil {
+			say(err.Error())
+			return
+		}
+	} else if len(bps) == 0 {
+		say("no breakpoints are set")
+		return
+	}
+
+	for _, bp := range bps {
+		bp.Enabled = enabled
+		say(fmt.Sprintf("%v breakpoint %d at %04X", verb, bp.ID, bp.Addr))
+	}
+
+	saveBreakpoints()
+}
+
+// breakpointsByID returns the breakpoints with the numbers given in tokens.
+// It returns an error if any number is invalid or doesn't belong to a
+// breakpoint.
+func breakpointsByID(tokens []string) ([]*Breakpoint, error) {
+	byID := make(map[int]*Breakpoint)
+	for _, bp := range breakpoints {
+		byID[bp.ID] = bp
+	}
+
+	bps := make([]*Breakpoint, 0, len(tokens))
+	for _, token := range tokens {
+		id, err := integer(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid breakpoint number: %w", err)
+		}
+
+		bp, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("no breakpoint number %d", id)
+		}
+
+		bps = append(bps, bp)
+	}
+
+	return bps, nil
+}
+
+func listBreakpoints() {
+	bps := sortedBreakpoints()
+	if len(bps) == 0 {
+		say("no breakpoints are set")
+		return
+	}
+
+	say("num  enabled  hits  breakpoint")
+
+	for _, bp := range bps {
+		enabled := "n"
+		if bp.Enabled {
+			enabled = "y"
+		}
+
+		say(fmt.Sprintf("%3d  %-7s  %4d  %v", bp.ID, enabled, bp.Hits, bp))
+	}
+}
+
+// interpolate returns the message with each {expr} in it replaced by the
+// value of the expression.
+func interpolate(comp *a2.Computer, message string, hits int) string {
+	var sb strings.Builder
+
+	for {
+		start := strings.Index(message, "{")
+		end := strings.Index(message, "}")
+		if start < 0 || end < start {
+			sb.WriteString(message)
+			return sb.String()
+		}
+
+		sb.WriteString(message[:start])
+
+		val := "?"
+		if e, err := expr.Parse(message[start+1 : end]); err == nil {
+			if n, err := e.Eval(env{comp: comp, hits: hits}); err == nil {
+				val = formatValue(n)
+			}
+		}
+
+		sb.WriteString(val)
+		message = message[end+1:]
+	}
+}
@@ -0,0 +1,52 @@
+package debug
+
+import (
+	"fmt"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/a2/a2sym"
+)
+
+// bt shows the calls that the CPU has made and not yet returned from, from
+// the newest to the oldest. Each is checked against the return address on
+// the stack page, in case the subroutine changed it.
+func bt(comp *a2.Computer, _ []string) {
+	var (
+		calls = comp.CPU.Calls.Calls()
+		pc    = comp.CPU.PC
+	)
+
+	for frame := 0; frame <= len(calls); frame++ {
+		line := fmt.Sprintf("#%-2d %04X", frame, pc)
+
+		// The frame is in the subroutine that the call before it went to,
+		// if we saw that call
+		if idx := len(calls) - frame - 1; idx >= 0 {
+			line += " in " + routineName(calls[idx].To)
+		}
+
+		if frame < len(calls) {
+			call := calls[len(calls)-frame-1]
+
+			onStack := uint16(comp.Peek(0x100+int(call.S)))<<8 |
+				uint16(comp.Peek(0x100+int(call.S-1)))
+			if onStack != call.Pushed() {
+				line += fmt.Sprintf(" (returns to $%04X, not $%04X)", onStack+1, call.Return())
+			}
+
+			pc = call.From
+		}
+
+		say(line)
+	}
+}
+
+// routineName returns the name of the subroutine at addr, or the address
+// itself if it has no name.
+func routineName(addr uint16) string {
+	if name := a2sym.Subroutine(int(addr)); name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("$%04X", addr)
+}
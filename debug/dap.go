@@ -0,0 +1,152 @@
+package debug
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/dap"
+	"github.com/pevans/erc/debug/expr"
+)
+
+// A dapTarget lets a DAP client (like VS Code) debug the computer.
+type dapTarget struct {
+	comp *a2.Computer
+}
+
+// DAPTarget returns the computer as something that a DAP client can debug.
+func DAPTarget(comp *a2.Computer) dap.Target {
+	return &dapTarget{comp: comp}
+}
+
+func (t *dapTarget) PC() uint16 {
+	return t.comp.CPU.PC
+}
+
+func (t *dapTarget) Step() error {
+	_, err := t.comp.Process()
+	return err
+}
+
+func (t *dapTarget) Depth() int {
+	return len(t.comp.CPU.Calls.Calls())
+}
+
+// Frames returns the same frames that bt shows.
+func (t *dapTarget) Frames() []dap.Frame {
+	var (
+		calls  = t.comp.CPU.Calls.Calls()
+		pc     = t.comp.CPU.PC
+		frames = make([]dap.Frame, 0, len(calls)+1)
+	)
+
+	for frame := 0; frame <= len(calls); frame++ {
+		name := "(top)"
+		if idx := len(calls) - frame - 1; idx >= 0 {
+			name = routineName(calls[idx].To)
+		}
+
+		frames = append(frames, dap.Frame{Addr: pc, Name: name})
+
+		if frame < len(calls) {
+			pc = calls[len(calls)-frame-1].From
+		}
+	}
+
+	return frames
+}
+
+func (t *dapTarget) Scopes() []dap.Scope {
+	return []dap.Scope{
+		{Name: "Registers", Variables: t.registers()},
+		{Name: "Flags", Variables: t.flags()},
+		{Name: "Zero Page", Variables: t.zeroPage()},
+		{Name: "Soft Switches", Variables: t.softSwitches()},
+	}
+}
+
+func (t *dapTarget) registers() []dap.Variable {
+	cpu := t.comp.CPU
+
+	return []dap.Variable{
+		{Name: "A", Value: fmt.Sprintf("$%02X", cpu.A)},
+		{Name: "X", Value: fmt.Sprintf("$%02X", cpu.X)},
+		{Name: "Y", Value: fmt.Sprintf("$%02X", cpu.Y)},
+		{Name: "S", Value: fmt.Sprintf("$%02X", cpu.S)},
+		{Name: "P", Value: fmt.Sprintf("$%02X", cpu.P)},
+		{Name: "PC", Value: fmt.Sprintf("$%04X", cpu.PC)},
+	}
+}
+
+// flags returns the flags of the P register, in the order that status
+// shows them.
+func (t *dapTarget) flags() []dap.Variable {
+	vars := make([]dap.Variable, 0, len(flags))
+
+	for _, name := range []string{"N", "V", "B", "D", "I", "Z", "C"} {
+		val := "0"
+		if t.comp.CPU.P&flags[name] > 0 {
+			val = "1"
+		}
+
+		vars = append(vars, dap.Variable{Name: name, Value: val})
+	}
+
+	return vars
+}
+
+// zeroPage returns the zero page in rows of 16 bytes, as dump would show
+// them.
+func (t *dapTarget) zeroPage() []dap.Variable {
+	vars := make([]dap.Variable, 0, 16)
+
+	for row := 0; row < 0x100; row += 16 {
+		bytes := make([]string, 16)
+		for i := range bytes {
+			bytes[i] = fmt.Sprintf("%02X", t.comp.Peek(row+i))
+		}
+
+		vars = append(vars, dap.Variable{
+			Name:  fmt.Sprintf("$%02X", row),
+			Value: strings.Join(bytes, " "),
+		})
+	}
+
+	return vars
+}
+
+// softSwitches returns the state of the computer that has a plain value,
+// like a soft switch or a bank, but not (for instance) a memory segment.
+func (t *dapTarget) softSwitches() []dap.Variable {
+	var (
+		state = t.comp.State.Map(a2state.KeyToString)
+		vars  []dap.Variable
+	)
+
+	for _, key := range slices.Sorted(maps.Keys(state)) {
+		switch val := state[key].(type) {
+		case bool, int, int64, uint8, uint16:
+			vars = append(vars, dap.Variable{Name: key, Value: fmt.Sprintf("%v", val)})
+		}
+	}
+
+	return vars
+}
+
+// Evaluate returns the value of an expression, as print shows it.
+func (t *dapTarget) Evaluate(s string) (string, error) {
+	e, err := expr.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid expression: %w", err)
+	}
+
+	val, err := e.Eval(env{comp: t.comp})
+	if err != nil {
+		return "", fmt.Errorf("couldn't evaluate expression: %w", err)
+	}
+
+	return fmt.Sprintf("%v (%d)", formatValue(val), val), nil
+}
@@ -0,0 +1,65 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/mos/disasm"
+)
+
+// defaultDisCount is how many instructions we disassemble if we aren't told.
+const defaultDisCount = 10
+
+func dis(comp *a2.Computer, tokens []string) {
+	if len(tokens) < 2 || len(tokens) > 3 {
+		say("invalid command: 'dis' requires an address, like: dis <addr> [count]")
+		return
+	}
+
+	loc, err := parseLocation(tokens[1])
+	if err != nil {
+		say(fmt.Sprintf("invalid address: %v", err))
+		return
+	}
+
+	count := defaultDisCount
+	if len(tokens) == 3 {
+		count, err = integer(tokens[2])
+		if err != nil {
+			say(fmt.Sprintf("invalid count: %v", err))
+			return
+		}
+	}
+
+	// Every instruction is at least a byte long, so no more than this many
+	// can fit before the end of memory, which is where we stop
+	count = min(count, 0x10000-loc.addr)
+
+	var (
+		d     = disasm.New(comp.CPU.Variant, nil)
+		mem   = bankMemory{comp: comp, bank: loc.bank}
+		insts = make([]disasm.Instruction, 0, count)
+		addr  = loc.addr
+	)
+
+	for range count {
+		inst := d.Decode(mem, uint16(addr))
+		insts = append(insts, inst)
+
+		addr += len(inst.Bytes)
+		if addr >= 0x10000 {
+			break
+		}
+	}
+
+	var sb strings.Builder
+	if err := disasm.WriteListing(&sb, insts); err != nil {
+		say(fmt.Sprintf("couldn't disassemble: %v", err))
+		return
+	}
+
+	for line := range strings.Lines(sb.String()) {
+		say(strings.TrimRight(line, "\n"))
+	}
+}
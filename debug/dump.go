@@ -0,0 +1,55 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+)
+
+// bytesPerLine is how many bytes we show on each line of a dump.
+const bytesPerLine = 16
+
+func dump(comp *a2.Computer, tokens []string) {
+	if len(tokens) != 3 {
+		say("invalid command: 'dump' requires a first and last address")
+		return
+	}
+
+	from, to, err := parseSpan(tokens[1], tokens[2])
+	if err != nil {
+		say(fmt.Sprintf("invalid address: %v", err))
+		return
+	}
+
+	for addr := from.addr; addr <= to.addr; addr += bytesPerLine {
+		var (
+			hexCol   []string
+			asciiCol strings.Builder
+		)
+
+		for i := addr; i < addr+bytesPerLine && i <= to.addr; i++ {
+			val, _ := comp.BankGet(from.bank, i)
+
+			hexCol = append(hexCol, fmt.Sprintf("%02X", val))
+			asciiCol.WriteByte(appleChar(val))
+		}
+
+		say(fmt.Sprintf(
+			"%-11v %-47s |%s|",
+			location{bank: from.bank, addr: addr}, strings.Join(hexCol, " "), asciiCol.String(),
+		))
+	}
+}
+
+// appleChar returns the character that an Apple II would show for the
+// given byte, once its high bit is stripped, or a dot if it's a control
+// character.
+func appleChar(val uint8) byte {
+	ch := val & 0x7F
+	if ch < 0x20 || ch == 0x7F {
+		return '.'
+	}
+
+	return ch
+}
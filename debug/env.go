@@ -0,0 +1,98 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/mos"
+)
+
+// flags maps the name of each flag to its bit in the P register.
+var flags = map[string]uint8{
+	"C": mos.CARRY,
+	"Z": mos.ZERO,
+	"I": mos.INTERRUPT,
+	"D": mos.DECIMAL,
+	"B": mos.BREAK,
+	"V": mos.OVERFLOW,
+	"N": mos.NEGATIVE,
+}
+
+// An env is where the expressions we evaluate find their values. It gives
+// them the registers and flags of the CPU, the memory and state of the
+// computer, and the hit count of the breakpoint we're evaluating a
+// condition for (if we are).
+type env struct {
+	comp *a2.Computer
+	hits int
+}
+
+// Var returns the value of a register (A, X, Y, S, P or PC), a flag (N, V,
+// B, D, I, Z or C), or the hit count (hits).
+func (e env) Var(name string) (int, error) {
+	cpu := e.comp.CPU
+
+	switch strings.ToUpper(name) {
+	case "A":
+		return int(cpu.A), nil
+	case "X":
+		return int(cpu.X), nil
+	case "Y":
+		return int(cpu.Y), nil
+	case "S":
+		return int(cpu.S), nil
+	case "P":
+		return int(cpu.P), nil
+	case "PC":
+		return int(cpu.PC), nil
+	case "HITS":
+		return e.hits, nil
+	}
+
+	if flag, ok := flags[strings.ToUpper(name)]; ok {
+		if cpu.P&flag > 0 {
+			return 1, nil
+		}
+
+		return 0, nil
+	}
+
+	return 0, fmt.Errorf("unknown name %q", name)
+}
+
+// State returns the value of the a2state key with the given name. Bools are
+// 1 if true and 0 if not.
+func (e env) State(name string) (int, error) {
+	key, ok := a2state.StringToKey(name)
+	if !ok {
+		return 0, fmt.Errorf("unknown state %q", name)
+	}
+
+	switch val := e.comp.State.Any(key).(type) {
+	case bool:
+		if val {
+			return 1, nil
+		}
+
+		return 0, nil
+	case int:
+		return val, nil
+	case int64:
+		return int(val), nil
+	case uint8:
+		return int(val), nil
+	case uint16:
+		return int(val), nil
+	case nil:
+		return 0, nil
+	}
+
+	return 0, fmt.Errorf("state %q has no numeric value", name)
+}
+
+// Mem returns the byte at addr, without setting off any soft switches.
+func (e env) Mem(addr int) uint8 {
+	return e.comp.Peek(addr)
+}
@@ -6,9 +6,6 @@ import (
 	"strings"
 
 	"github.com/pevans/erc/a2"
-	"github.com/pevans/erc/a2/a2state"
-	"github.com/pevans/erc/gfx"
-	"github.com/pevans/erc/obj"
 )
 
 func execute(comp *a2.Computer, cmd string) {
@@ -23,6 +20,20 @@ func execute(comp *a2.Computer, cmd string) {
 	// data
 	case "get":
 		get(comp, tokens)
+	case "dump":
+		dump(comp, tokens)
+	case "dis":
+		dis(comp, tokens)
+	case "find":
+		find(comp, tokens)
+	case "fill":
+		fill(comp, tokens)
+	case "asm":
+		asm(comp, tokens)
+	case "copy":
+		copyCmd(comp, tokens)
+	case "print":
+		printCmd(comp, tokens)
 	case "reg":
 		reg(comp, tokens)
 	case "set":
@@ -35,10 +46,20 @@ func execute(comp *a2.Computer, cmd string) {
 		// execution
 	case "step":
 		step(comp, tokens)
+	case "next":
+		next(comp, tokens)
+	case "finish":
+		finish(comp, tokens)
 	case "until":
 		until(comp, tokens)
 	case "runfor":
 		runfor(comp, tokens)
+	case "history":
+		history(comp, tokens)
+	case "back":
+		back(comp, tokens)
+	case "bt":
+		bt(comp, tokens)
 
 		// simulation
 	case "keypress":
@@ -47,6 +68,20 @@ func execute(comp *a2.Computer, cmd string) {
 		// debugging
 	case "dbatch":
 		dbatch(comp, tokens)
+	case "break":
+		breakCmd(comp, tokens)
+	case "delete":
+		deleteCmd(comp, tokens)
+	case "enable":
+		enable(comp, tokens)
+	case "disable":
+		disable(comp, tokens)
+	case "info":
+		info(comp, tokens)
+	case "watch":
+		watch(comp, tokens)
+	case "unwatch":
+		unwatch(comp, tokens)
 
 		// the rest
 	case "disk":
@@ -62,9 +97,13 @@ func execute(comp *a2.Computer, cmd string) {
 		}
 		os.Exit(0)
 	case "resume":
-		comp.State.SetBool(a2state.Debugger, false)
-		gfx.ShowStatus(obj.ResumePNG())
-		say("resuming emulation")
+		resume(comp)
+	case "continue":
+		continueCmd(comp, tokens)
+	case "source":
+		source(comp, tokens)
+	case "repeat":
+		repeat(comp, tokens)
 
 	default:
 		say(fmt.Sprintf("unknown command: \"%v\"", tokens[0]))
@@ -76,23 +115,62 @@ func help() {
 	say("list of commands")
 	say("  [data]")
 	say("    get <addr> ......... print the value at address <addr>")
+	say("    dump <from> <to> ... show memory from <from> to <to> in hex and text")
+	say("    dis <addr> <n> ..... disassemble <n> instructions (or 10) from <addr>")
+	say("    find <from> <to> <bytes> | \"<text>\"")
+	say("                         find some bytes or text from <from> to <to>")
+	say("    fill <from> <to> <bytes>")
+	say("                         fill memory from <from> to <to> with <bytes>")
+	say("    copy <from> <to> <dest>")
+	say("                         copy memory from <from> to <to> into <dest>")
+	say("    asm <addr> ......... assemble lines into memory at <addr>, until an")
+	say("                         empty line (or give one line after <addr>)")
+	say("    print <expr> ....... print the value of <expr>, like: mem[$00FE] + X")
 	say("    reg <r> <val> ...... write <val> to register <r>")
 	say("    set <addr> <val> ... write <val> at address <addr>")
 	say("    state .............. print the apple II state")
 	say("    status ............. show registers and next execution")
+	say("    (addresses for dump, dis, find, fill, copy and asm may name a bank, like")
+	say("    aux:0400; the banks are main, aux, lc1, lc2, auxlc1, auxlc2 and rom)")
+	say("    (any address may also be a symbol, like COUT, or one from --symbols;")
+	say("    a symbol wins over hex of the same name, so write $ADD to mean hex ADD)")
 	say("  [execution]")
 	say("    step <times> ....... execute <times> instructions")
+	say("    next <times> ....... execute <times> instructions, treating JSR as one")
+	say("    finish ............. execute until the current subroutine returns")
 	say("    until <instruction>  execute until <instruction>")
 	say("    runfor <seconds> ... run for <seconds>, then reenter the debugger")
+	say("    history <n> ........ show the last <n> instructions executed")
+	say("    back <n> ........... undo the last <n> instructions executed")
+	say("    bt ................. show the subroutines we're in, newest first")
 	say("  [simulation]")
 	say("    keypress <val> ..... simulate a keypress with hex ascii code <val>")
 	say("  [debugging]")
 	say("    dbatch start ....... start recording assembly instructions")
 	say("    dbatch stop ........ stop recording and write to file")
+	say("    break <addr> ....... enter the debugger when the PC reaches <addr>;")
+	say("                         add 'if <expr>' to stop only when <expr> is true,")
+	say("                         'ignore <n>' to let it pass <n> times, or")
+	say("                         'log <message>' to show <message> and carry on,")
+	say("                         or 'do <command>; <command>...' to run commands")
+	say("                         when it stops")
+	say("    delete <n> ......... delete breakpoint <n> (or all, if no <n>)")
+	say("    enable <n> ......... enable breakpoint <n> (or all, if no <n>)")
+	say("    disable <n> ........ disable breakpoint <n> (or all, if no <n>)")
+	say("    info breakpoints ... list the breakpoints")
+	say("    watch .............. list the watchpoints")
+	say("    watch <kind> <addr>  enter the debugger on a read, write or change")
+	say("                         of <addr> (or a range, like 0300-03FF)")
+	say("    unwatch <addr> ..... remove the watchpoints on <addr> (or a range)")
 	say("  [the rest]")
 	say("    disk <file> ........ load <file> into drive")
 	say("    writeprotect ....... toggle write protect on drive 1")
 	say("    help ............... print this message")
 	say("    quit ............... quit the emulator")
 	say("    resume ............. resume emulation")
+	say("    continue if <expr>   resume emulation if <expr> is true (or always, if")
+	say("                         no condition is given)")
+	say("    repeat <n> <command> run <command> <n> times")
+	say("    source <file> ...... run the commands in <file>, one per line; the first")
+	say("                         disk's <disk>.ercdbg is run when the emulator starts")
 }
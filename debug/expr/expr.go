@@ -0,0 +1,75 @@
+// Package expr implements the small expression language that the debugger
+// uses for breakpoint conditions and its print command. Expressions work on
+// integers, and look like those in C; for example:
+//
+//	A == $C1 && mem[$00FE] > 3
+//
+// Numbers are decimal, or hex if they begin with $. Names refer to
+// registers, flags and the like, mem[addr] is the byte at addr, word[addr]
+// is the 16-bit word there, and state.Name is the value of some part of the
+// computer's state. Comparisons and logical operators give 1 for true and 0
+// for false.
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An Env is where an expression gets the values it refers to.
+type Env interface {
+	// Var returns the value of the variable with the given name (e.g. a
+	// register), or an error if there's no such variable.
+	Var(name string) (int, error)
+
+	// State returns the value of the state with the given name, or an
+	// error if there's no such state or it has no numeric value.
+	State(name string) (int, error)
+
+	// Mem returns the byte at the given address.
+	Mem(addr int) uint8
+}
+
+// An Expr is an expression that we've parsed, and which we can evaluate as
+// many times as we like.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse returns the expression in src, or an error if it isn't one.
+func Parse(src string) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks}
+
+	root, err := p.expr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+	}
+
+	return &Expr{src: strings.TrimSpace(src), root: root}, nil
+}
+
+// Eval returns the value of the expression in the given environment.
+func (e *Expr) Eval(env Env) (int, error) {
+	return e.root.eval(env)
+}
+
+// True returns true if the expression has a value other than zero.
+func (e *Expr) True(env Env) (bool, error) {
+	val, err := e.Eval(env)
+	return val != 0, err
+}
+
+// String returns the expression as it was written.
+func (e *Expr) String() string {
+	return e.src
+}
@@ -0,0 +1,108 @@
+package expr_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pevans/erc/debug/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEnv struct {
+	vars  map[string]int
+	state map[string]int
+	mem   map[int]uint8
+}
+
+func (e testEnv) Var(name string) (int, error) {
+	if val, ok := e.vars[name]; ok {
+		return val, nil
+	}
+
+	return 0, fmt.Errorf("unknown variable %q", name)
+}
+
+func (e testEnv) State(name string) (int, error) {
+	if val, ok := e.state[name]; ok {
+		return val, nil
+	}
+
+	return 0, fmt.Errorf("unknown state %q", name)
+}
+
+func (e testEnv) Mem(addr int) uint8 {
+	return e.mem[addr]
+}
+
+func TestEval(t *testing.T) {
+	env := testEnv{
+		vars:  map[string]int{"A": 0xC1, "X": 3, "C": 1},
+		state: map[string]int{"DisplayPage2": 1},
+		mem:   map[int]uint8{0xFE: 4, 0xFF: 0x12, 0x0000: 0x34, 0xFFFF: 0x56},
+	}
+
+	cases := []struct {
+		src  string
+		want int
+	}{
+		{"A == $C1 && mem[$00FE] > 3", 1},
+		{"A == $C1 && mem[$00FE] > 4", 0},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"word[$FE]", 0x1204},
+		{"word[$FFFF]", 0x3456},
+		{"mem[$FC + X]", 0x12},
+		{"-X", -3},
+		{"!C", 0},
+		{"~0 & $FF", 0xFF},
+		{"1 << 4 | 1", 0x11},
+		{"state.DisplayPage2 || 0", 1},
+		{"7 % 4 == 3", 1},
+		{"X >= 3 && X <= 3 && X != 4", 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.src, func(t *testing.T) {
+			e, err := expr.Parse(c.src)
+			require.NoError(t, err)
+
+			val, err := e.Eval(env)
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, val)
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := testEnv{}
+
+	// We don't look at the right side if the left side is enough
+	e, err := expr.Parse("0 && Q")
+	require.NoError(t, err)
+	ok, err := e.True(env)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	for _, src := range []string{"Q", "1 / 0", "state.Nope"} {
+		e, err := expr.Parse(src)
+		require.NoError(t, err)
+
+		_, err = e.Eval(env)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{"", "1 +", "(1", "mem[1", "state.", "1 2", "A @ 1", "$G1", "3a"} {
+		_, err := expr.Parse(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestString(t *testing.T) {
+	e, err := expr.Parse("  A == $C1 ")
+	require.NoError(t, err)
+	assert.Equal(t, "A == $C1", e.String())
+}
@@ -0,0 +1,105 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNum
+	tokIdent
+	tokOp
+)
+
+// A token is one piece of an expression: a number, a name, or some
+// operator or punctuation.
+type token struct {
+	kind tokenKind
+	text string
+	num  int
+	pos  int
+}
+
+// operators are the operators and punctuation we know, longest first so
+// that we match (say) <= before <.
+var operators = []string{
+	"&&", "||", "==", "!=", "<=", ">=", "<<", ">>",
+	"+", "-", "*", "/", "%", "&", "|", "^", "!", "~", "<", ">",
+	"(", ")", "[", "]", ".",
+}
+
+// lex breaks src into tokens. The last token is always tokEOF.
+func lex(src string) ([]token, error) {
+	var toks []token
+
+	for i := 0; i < len(src); {
+		ch := src[i]
+
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+
+		case ch == '$' || isDigit(ch):
+			start := i
+			base := 10
+			if ch == '$' {
+				base = 16
+				i++
+			}
+
+			digits := i
+			for i < len(src) && isHexDigit(src[i]) {
+				i++
+			}
+
+			num, err := strconv.ParseInt(src[digits:i], base, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", src[start:i], start+1)
+			}
+
+			toks = append(toks, token{kind: tokNum, text: src[start:i], num: int(num), pos: start})
+
+		case isIdentStart(ch):
+			start := i
+			for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i])) {
+				i++
+			}
+
+			toks = append(toks, token{kind: tokIdent, text: src[start:i], pos: start})
+
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at position %d", ch, i+1)
+			}
+
+			toks = append(toks, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(toks, token{kind: tokEOF, text: "end of expression", pos: len(src)}), nil
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
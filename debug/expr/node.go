@@ -0,0 +1,144 @@
+package expr
+
+import "errors"
+
+// A node is some part of an expression that has a value.
+type node interface {
+	eval(env Env) (int, error)
+}
+
+type (
+	numNode   int
+	varNode   string
+	stateNode string
+
+	memNode struct {
+		addr node
+		size int
+	}
+
+	unaryNode struct {
+		op      string
+		operand node
+	}
+
+	binaryNode struct {
+		op          string
+		left, right node
+	}
+)
+
+func (n numNode) eval(Env) (int, error) {
+	return int(n), nil
+}
+
+func (n varNode) eval(env Env) (int, error) {
+	return env.Var(string(n))
+}
+
+func (n stateNode) eval(env Env) (int, error) {
+	return env.State(string(n))
+}
+
+func (n memNode) eval(env Env) (int, error) {
+	addr, err := n.addr.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	val := 0
+	for i := n.size - 1; i >= 0; i-- {
+		val = val<<8 | int(env.Mem((addr+i)&0xFFFF))
+	}
+
+	return val, nil
+}
+
+func (n unaryNode) eval(env Env) (int, error) {
+	val, err := n.operand.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case "-":
+		return -val, nil
+	case "~":
+		return ^val, nil
+	}
+
+	return truth(val == 0), nil
+}
+
+func (n binaryNode) eval(env Env) (int, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	// Like C, we don't bother with the right side of a logical operator if
+	// the left side is enough to tell what the answer is
+	switch {
+	case n.op == "&&" && left == 0:
+		return 0, nil
+	case n.op == "||" && left != 0:
+		return 1, nil
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return truth(right != 0), nil
+	case "|":
+		return left | right, nil
+	case "^":
+		return left ^ right, nil
+	case "&":
+		return left & right, nil
+	case "==":
+		return truth(left == right), nil
+	case "!=":
+		return truth(left != right), nil
+	case "<":
+		return truth(left < right), nil
+	case "<=":
+		return truth(left <= right), nil
+	case ">":
+		return truth(left > right), nil
+	case ">=":
+		return truth(left >= right), nil
+	case "<<":
+		return left << (right & 63), nil
+	case ">>":
+		return left >> (right & 63), nil
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	}
+
+	if right == 0 {
+		return 0, errors.New("division by zero")
+	}
+
+	if n.op == "/" {
+		return left / right, nil
+	}
+
+	return left % right, nil
+}
+
+// truth returns 1 if cond is true, and 0 if not.
+func truth(cond bool) int {
+	if cond {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,155 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// precedence is how tightly each binary operator binds; the higher, the
+// tighter. These follow C.
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"^":  4,
+	"&":  5,
+	"==": 6, "!=": 6,
+	"<": 7, "<=": 7, ">": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+// A parser turns tokens into a tree of nodes.
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+// expect consumes the next token, which must be the given operator.
+func (p *parser) expect(op string) error {
+	if tok := p.next(); tok.kind != tokOp || tok.text != op {
+		return fmt.Errorf("expected %q but found %q at position %d", op, tok.text, tok.pos+1)
+	}
+
+	return nil
+}
+
+// expr parses a run of binary operations whose operators bind more tightly
+// than minPrec.
+func (p *parser) expr(minPrec int) (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		prec, isBinary := precedence[tok.text]
+		if tok.kind != tokOp || !isBinary || prec <= minPrec {
+			return left, nil
+		}
+
+		p.next()
+
+		right, err := p.expr(prec)
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryNode{op: tok.text, left: left, right: right}
+	}
+}
+
+// unary parses a unary operation, or failing that, a primary.
+func (p *parser) unary() (node, error) {
+	tok := p.peek()
+	if tok.kind == tokOp && (tok.text == "-" || tok.text == "!" || tok.text == "~") {
+		p.next()
+
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return unaryNode{op: tok.text, operand: operand}, nil
+	}
+
+	return p.primary()
+}
+
+// primary parses a number, a name, some memory, some state, or an
+// expression in parentheses.
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokNum:
+		return numNode(tok.num), nil
+
+	case tokIdent:
+		switch strings.ToLower(tok.text) {
+		case "mem":
+			return p.memory(tok, 1)
+		case "word":
+			return p.memory(tok, 2)
+		case "state":
+			if err := p.expect("."); err != nil {
+				return nil, err
+			}
+
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, fmt.Errorf("expected a state name but found %q at position %d", name.text, name.pos+1)
+			}
+
+			return stateNode(name.text), nil
+		}
+
+		return varNode(tok.text), nil
+
+	case tokOp:
+		if tok.text == "(" {
+			inner, err := p.expr(0)
+			if err != nil {
+				return nil, err
+			}
+
+			return inner, p.expect(")")
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+}
+
+// memory parses the address of a mem[...] or word[...], which is size bytes
+// long.
+func (p *parser) memory(tok token, size int) (node, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+
+	addr, err := p.expr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+
+	return memNode{addr: addr, size: size}, nil
+}
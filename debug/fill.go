@@ -0,0 +1,76 @@
+package debug
+
+import (
+	"fmt"
+
+	"github.com/pevans/erc/a2"
+)
+
+func fill(comp *a2.Computer, tokens []string) {
+	if len(tokens) < 4 {
+		say("invalid command: 'fill' requires a first and last address, and the bytes to fill with")
+		return
+	}
+
+	from, to, err := parseSpan(tokens[1], tokens[2])
+	if err != nil {
+		say(fmt.Sprintf("invalid address: %v", err))
+		return
+	}
+
+	pattern, err := parseBytes(tokens[3:])
+	if err != nil {
+		say(fmt.Sprintf("invalid value: %v", err))
+		return
+	}
+
+	for addr := from.addr; addr <= to.addr; addr++ {
+		if err := comp.BankSet(from.bank, addr, pattern[(addr-from.addr)%len(pattern)]); err != nil {
+			say(fmt.Sprintf("couldn't fill: %v", err))
+			return
+		}
+	}
+
+	say(fmt.Sprintf("filled %v-%04X", from, to.addr))
+}
+
+func copyCmd(comp *a2.Computer, tokens []string) {
+	if len(tokens) != 4 {
+		say("invalid command: 'copy' requires a first and last address, and an address to copy to")
+		return
+	}
+
+	from, to, err := parseSpan(tokens[1], tokens[2])
+	if err != nil {
+		say(fmt.Sprintf("invalid address: %v", err))
+		return
+	}
+
+	dest, err := parseLocation(tokens[3])
+	if err != nil {
+		say(fmt.Sprintf("invalid address: %v", err))
+		return
+	}
+
+	size := to.addr - from.addr + 1
+	if !dest.bank.Contains(dest.addr + size - 1) {
+		say(fmt.Sprintf("invalid address: %d bytes from %v would run past the end of memory", size, dest))
+		return
+	}
+
+	// We read everything before we write anything, so that it doesn't
+	// matter if the source and destination overlap
+	bytes := make([]uint8, size)
+	for i := range bytes {
+		bytes[i], _ = comp.BankGet(from.bank, from.addr+i)
+	}
+
+	for i, val := range bytes {
+		if err := comp.BankSet(dest.bank, dest.addr+i, val); err != nil {
+			say(fmt.Sprintf("couldn't copy: %v", err))
+			return
+		}
+	}
+
+	say(fmt.Sprintf("copied %d bytes from %v to %v", size, from, dest))
+}
@@ -0,0 +1,103 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+)
+
+// maxFindResults is the most matches that find will show.
+const maxFindResults = 32
+
+func find(comp *a2.Computer, tokens []string) {
+	if len(tokens) < 4 {
+		say(`invalid command: 'find' requires a first and last address, and bytes or "text" to find`)
+		return
+	}
+
+	from, to, err := parseSpan(tokens[1], tokens[2])
+	if err != nil {
+		say(fmt.Sprintf("invalid address: %v", err))
+		return
+	}
+
+	pattern, isText, err := parsePattern(tokens[3:])
+	if err != nil {
+		say(fmt.Sprintf("invalid pattern: %v", err))
+		return
+	}
+
+	var matches []location
+	for addr := from.addr; addr+len(pattern)-1 <= to.addr; addr++ {
+		if matchAt(comp, from.bank, addr, pattern, isText) {
+			matches = append(matches, location{bank: from.bank, addr: addr})
+		}
+	}
+
+	if len(matches) == 0 {
+		say("not found")
+		return
+	}
+
+	for i, loc := range matches {
+		if i == maxFindResults {
+			say(fmt.Sprintf("...and %d more", len(matches)-maxFindResults))
+			break
+		}
+
+		say(fmt.Sprintf("found at %v", loc))
+	}
+}
+
+// parsePattern parses either some hex bytes (e.g. A9 C1) or some text in
+// double quotes. It returns true if it's text.
+func parsePattern(tokens []string) ([]uint8, bool, error) {
+	joined := strings.Join(tokens, " ")
+
+	if strings.HasPrefix(joined, `"`) {
+		if len(joined) < 3 || !strings.HasSuffix(joined, `"`) {
+			return nil, true, fmt.Errorf("text must be in double quotes")
+		}
+
+		return []uint8(joined[1 : len(joined)-1]), true, nil
+	}
+
+	bytes, err := parseBytes(tokens)
+
+	return bytes, false, err
+}
+
+// parseBytes parses each token as a hex byte.
+func parseBytes(tokens []string) ([]uint8, error) {
+	bytes := make([]uint8, len(tokens))
+
+	for i, token := range tokens {
+		val, err := hex(token, 8)
+		if err != nil {
+			return nil, err
+		}
+
+		bytes[i] = uint8(val)
+	}
+
+	return bytes, nil
+}
+
+// matchAt returns true if the pattern is in memory at addr. Text matches
+// whether or not the high bit of each byte is set, since the Apple II
+// usually sets it.
+func matchAt(comp *a2.Computer, bank a2.Bank, addr int, pattern []uint8, isText bool) bool {
+	for i, want := range pattern {
+		val, _ := comp.BankGet(bank, addr+i)
+		if isText {
+			val &= 0x7F
+		}
+
+		if val != want {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,152 @@
+package debug
+
+import (
+	"slices"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/gdb"
+	"github.com/pevans/erc/memory"
+)
+
+// A gdbTarget lets a GDB client debug the computer.
+type gdbTarget struct {
+	comp *a2.Computer
+
+	// watches are the watchpoints that the client asked for. We keep them
+	// so that we can tell which of them an access hit, since an access
+	// watchpoint is two of the computer's (a read and a write).
+	watches []gdb.Point
+}
+
+// GDBTarget returns the computer as something that a GDB client can debug.
+func GDBTarget(comp *a2.Computer) gdb.Target {
+	return &gdbTarget{comp: comp}
+}
+
+func (t *gdbTarget) Registers() gdb.Registers {
+	cpu := t.comp.CPU
+
+	return gdb.Registers{A: cpu.A, X: cpu.X, Y: cpu.Y, P: cpu.P, S: cpu.S, PC: cpu.PC}
+}
+
+func (t *gdbTarget) SetRegisters(regs gdb.Registers) {
+	cpu := t.comp.CPU
+
+	cpu.A, cpu.X, cpu.Y, cpu.P, cpu.S, cpu.PC = regs.A, regs.X, regs.Y, regs.P, regs.S, regs.PC
+}
+
+// ReadMemory peeks at memory, so that a client looking around doesn't flip
+// any soft switch.
+func (t *gdbTarget) ReadMemory(addr int) uint8 {
+	return t.comp.Peek(addr)
+}
+
+func (t *gdbTarget) WriteMemory(addr int, val uint8) {
+	t.comp.Set(addr, val)
+}
+
+func (t *gdbTarget) Step() error {
+	_, err := t.comp.Process()
+	return err
+}
+
+func (t *gdbTarget) AddWatch(p gdb.Point) error {
+	for _, kind := range watchKinds(p.Kind) {
+		wp := memory.Watchpoint{Kind: kind, From: p.Addr, To: p.Addr + p.Len - 1}
+		if err := t.comp.Watchpoints().Add(wp); err != nil {
+			return err
+		}
+	}
+
+	t.watches = append(t.watches, p)
+
+	return nil
+}
+
+// RemoveWatch removes the computer's watchpoints for p. The computer can
+// only remove every watchpoint on a range, so we add back any others that
+// were on the same one.
+func (t *gdbTarget) RemoveWatch(p gdb.Point) error {
+	var (
+		from, to = p.Addr, p.Addr + p.Len - 1
+		removed  = watchKinds(p.Kind)
+		keep     []memory.Watchpoint
+	)
+
+	for _, wp := range t.comp.Watchpoints().List() {
+		if wp.From != from || wp.To != to {
+			continue
+		}
+
+		if i := slices.Index(removed, wp.Kind); i >= 0 {
+			removed = slices.Delete(removed, i, i+1)
+			continue
+		}
+
+		keep = append(keep, wp)
+	}
+
+	t.comp.Watchpoints().Remove(from, to)
+
+	for _, wp := range keep {
+		if err := t.comp.Watchpoints().Add(wp); err != nil {
+			return err
+		}
+	}
+
+	if i := slices.Index(t.watches, p); i >= 0 {
+		t.watches = slices.Delete(t.watches, i, i+1)
+	}
+
+	return nil
+}
+
+// Detach removes the computer's watchpoints for those that the client asked
+// for, since a client that's gone can't be told about their hits.
+func (t *gdbTarget) Detach() {
+	for _, p := range slices.Clone(t.watches) {
+		// This can only fail if we can't add back a watchpoint that we
+		// had before, which we could add the first time
+		_ = t.RemoveWatch(p)
+	}
+
+	t.watches = nil
+}
+
+// WatchHit returns the first watchpoint that was hit since we last asked.
+// If it's not one the client asked for, we call it a write watchpoint,
+// which is the nearest thing to the change watchpoints that only our own
+// debugger sets.
+func (t *gdbTarget) WatchHit() (gdb.Point, int, bool) {
+	hits := t.comp.Watchpoints().Hits()
+	if len(hits) == 0 {
+		return gdb.Point{}, 0, false
+	}
+
+	hit := hits[0]
+
+	for _, p := range t.watches {
+		if hit.Addr < p.Addr || hit.Addr >= p.Addr+p.Len {
+			continue
+		}
+
+		if slices.Contains(watchKinds(p.Kind), hit.Watchpoint.Kind) {
+			return p, hit.Addr, true
+		}
+	}
+
+	return gdb.Point{Kind: gdb.WriteWatch, Addr: hit.Addr, Len: 1}, hit.Addr, true
+}
+
+// watchKinds returns the kinds of the computer's watchpoints that make up
+// a GDB watchpoint of the given kind.
+func watchKinds(kind gdb.PointKind) []memory.WatchKind {
+	switch kind {
+	case gdb.ReadWatch:
+		return []memory.WatchKind{memory.WatchRead}
+	case gdb.AccessWatch:
+		return []memory.WatchKind{memory.WatchRead, memory.WatchWrite}
+	}
+
+	return []memory.WatchKind{memory.WatchWrite}
+}
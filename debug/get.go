@@ -12,7 +12,7 @@ func get(comp *a2.Computer, tokens []string) {
 		return
 	}
 
-	addr, err := hex(tokens[1], 16)
+	addr, err := address(tokens[1])
 	if err != nil {
 		say(fmt.Sprintf("invalid address: %v", err))
 		return
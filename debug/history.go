@@ -0,0 +1,82 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+)
+
+func history(comp *a2.Computer, tokens []string) {
+	var (
+		count = 10
+		err   error
+	)
+
+	if comp.CPU.History == nil {
+		say("no history is being kept (see the --history flag)")
+		return
+	}
+
+	if len(tokens) >= 2 {
+		count, err = integer(tokens[1])
+		if err != nil {
+			say(fmt.Sprintf("invalid command: %v", err))
+			return
+		}
+	}
+
+	entries := comp.CPU.History.Recent(count)
+	if len(entries) == 0 {
+		say("no instructions have been executed")
+		return
+	}
+
+	for _, entry := range entries {
+		line := comp.CPU.HistoryLine(entry)
+
+		say(fmt.Sprintf(
+			"%s A:$%02X X:$%02X Y:$%02X S:$%02X P:$%02X",
+			line.ShortString(),
+			entry.A, entry.X, entry.Y, entry.S, entry.P,
+		))
+	}
+
+	say(fmt.Sprintf(
+		"showing %v of %v instructions (newest last)",
+		len(entries), comp.CPU.History.Len(),
+	))
+}
+
+func back(comp *a2.Computer, tokens []string) {
+	var (
+		count = 1
+		err   error
+	)
+
+	if comp.CPU.History == nil {
+		say("no history is being kept (see the --history flag)")
+		return
+	}
+
+	if len(tokens) >= 2 {
+		count, err = integer(tokens[1])
+		if err != nil {
+			say(fmt.Sprintf("invalid command: %v", err))
+			return
+		}
+	}
+
+	undone := comp.CPU.Back(count)
+	if undone < count {
+		say(fmt.Sprintf("history only went back %v instructions", undone))
+	}
+
+	say(fmt.Sprintf("went back %v instructions, current state is now", undone))
+	say(fmt.Sprintf(
+		"registers .......... %s", strings.TrimSpace(comp.CPU.Status()),
+	))
+	say(fmt.Sprintf(
+		"next instruction ... %s", strings.TrimSpace(comp.CPU.NextInstruction()),
+	))
+}
@@ -0,0 +1,21 @@
+package debug
+
+import (
+	"fmt"
+
+	"github.com/pevans/erc/a2"
+)
+
+func info(_ *a2.Computer, tokens []string) {
+	if len(tokens) != 2 {
+		say("invalid command: 'info' requires a subject, like: info breakpoints")
+		return
+	}
+
+	switch tokens[1] {
+	case "breakpoints", "break":
+		listBreakpoints()
+	default:
+		say(fmt.Sprintf("unknown subject for info: \"%v\"", tokens[1]))
+	}
+}
@@ -0,0 +1,99 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+)
+
+// A location is an address in some bank of memory. It's written like
+// aux:0400, or just 0400 for whatever memory is mapped in.
+type location struct {
+	bank a2.Bank
+	addr int
+}
+
+func (l location) String() string {
+	if l.bank == a2.BankMapped {
+		return fmt.Sprintf("%04X", l.addr)
+	}
+
+	return fmt.Sprintf("%v:%04X", l.bank, l.addr)
+}
+
+// parseLocation parses an address that may be qualified with a bank, like
+// aux:0400, lc1:D000 or rom:F800.
+func parseLocation(token string) (location, error) {
+	var (
+		loc     location
+		addrStr = token
+	)
+
+	if bankStr, rest, ok := strings.Cut(token, ":"); ok {
+		bank, ok := a2.ParseBank(bankStr)
+		if !ok {
+			return loc, fmt.Errorf("unknown bank: \"%v\"", bankStr)
+		}
+
+		loc.bank = bank
+		addrStr = rest
+	}
+
+	addr, err := address(addrStr)
+	if err != nil {
+		return loc, err
+	}
+
+	if !loc.bank.Contains(addr) {
+		return loc, fmt.Errorf("%v has nothing at $%04X", loc.bank, addr)
+	}
+
+	loc.addr = addr
+
+	return loc, nil
+}
+
+// parseSpan parses the first and last location of some span of memory. The
+// last location need not name the bank again, but if it does, it must be
+// the same one.
+func parseSpan(fromToken, toToken string) (location, location, error) {
+	from, err := parseLocation(fromToken)
+	if err != nil {
+		return from, from, err
+	}
+
+	if !strings.Contains(toToken, ":") && from.bank != a2.BankMapped {
+		toToken = from.bank.String() + ":" + toToken
+	}
+
+	to, err := parseLocation(toToken)
+	if err != nil {
+		return from, to, err
+	}
+
+	switch {
+	case to.bank != from.bank:
+		return from, to, fmt.Errorf("%v and %v are in different banks", from, to)
+	case to.addr < from.addr:
+		return from, to, fmt.Errorf("%v comes before %v", to, from)
+	}
+
+	return from, to, nil
+}
+
+// A bankMemory reads from a bank of memory, so that we can disassemble it.
+// Anything outside the bank reads as zero.
+type bankMemory struct {
+	comp *a2.Computer
+	bank a2.Bank
+}
+
+func (m bankMemory) Get(addr int) uint8 {
+	val, _ := m.comp.BankGet(m.bank, addr&0xFFFF)
+	return val
+}
+
+func (m bankMemory) Get16(addr int) uint16 {
+	return uint16(m.Get(addr)) | uint16(m.Get(addr+1))<<8
+}
@@ -0,0 +1,90 @@
+package debug
+
+import (
+	"fmt"
+
+	"github.com/pevans/erc/a2"
+)
+
+// next executes the next instruction, but if it's a JSR, it carries on until
+// the subroutine returns, as though the JSR were one instruction.
+func next(comp *a2.Computer, tokens []string) {
+	var (
+		times = 1
+		err   error
+	)
+
+	if len(tokens) >= 2 {
+		times, err = integer(tokens[1])
+		if err != nil {
+			say(fmt.Sprintf("invalid command: %v", err))
+			return
+		}
+	}
+
+	executed := 0
+	for range times {
+		n, ok := runUntilDepth(comp, comp.CPU.Calls.Depth())
+		executed += n
+
+		if !ok {
+			break
+		}
+	}
+
+	say(fmt.Sprintf("executed %v instructions, current state is now", executed))
+	status(comp)
+}
+
+// finish carries on until the subroutine that we're in returns.
+func finish(comp *a2.Computer, _ []string) {
+	calls := comp.CPU.Calls.Calls()
+	if len(calls) == 0 {
+		say("not in a subroutine (that we saw called)")
+		return
+	}
+
+	call := calls[len(calls)-1]
+
+	executed, ok := runUntilDepth(comp, len(calls)-1)
+	if ok {
+		say(fmt.Sprintf(
+			"returned from %v to %04X after %v instructions, current state is now",
+			routineName(call.To), comp.CPU.PC, executed,
+		))
+	} else {
+		say(fmt.Sprintf("stopped in %v after %v instructions, current state is now", routineName(call.To), executed))
+	}
+
+	status(comp)
+}
+
+// runUntilDepth executes instructions until there are no more than depth
+// calls on the call stack, unless we hit a breakpoint or watchpoint first.
+// It always executes at least one instruction. It returns the number of
+// instructions it executed, and true if it got to the given depth.
+func runUntilDepth(comp *a2.Computer, depth int) (int, bool) {
+	const maxIterations = 100_000_000
+
+	for i := range maxIterations {
+		if _, err := comp.Process(); err != nil {
+			panic(fmt.Sprintf("could not step instruction: %v", err))
+		}
+
+		if CheckWatchpoints(comp) {
+			return i + 1, false
+		}
+
+		if comp.CPU.Calls.Depth() <= depth {
+			return i + 1, true
+		}
+
+		if CheckBreakpoint(comp) {
+			return i + 1, false
+		}
+	}
+
+	say(fmt.Sprintf("stopped after %v instructions without returning", maxIterations))
+
+	return maxIterations, false
+}
@@ -3,6 +3,9 @@ package debug
 import (
 	"fmt"
 	"strconv"
+	"strings"
+
+	"github.com/pevans/erc/a2/a2sym"
 )
 
 func hex(token string, bits int) (int, error) {
@@ -14,6 +17,26 @@ func hex(token string, bits int) (int, error) {
 	return int(ui64), nil
 }
 
+// address parses a 16-bit address, which may be given in hex or as the name
+// of a symbol (e.g. COUT, or any symbol loaded with --symbols). Some names
+// are also hex (like ADD), and for those the symbol wins; to mean the hex
+// address, it can be written with a $ in front of it (like $ADD).
+func address(token string) (int, error) {
+	if digits, ok := strings.CutPrefix(token, "$"); ok {
+		return hex(digits, 16)
+	}
+
+	if addr, ok := a2sym.Labels()[token]; ok {
+		return addr, nil
+	}
+
+	if addr, err := hex(token, 16); err == nil {
+		return addr, nil
+	}
+
+	return 0, fmt.Errorf("\"%v\" is not hex or a known symbol", token)
+}
+
 func integer(token string) (int, error) {
 	ui64, err := strconv.ParseUint(token, 10, 64)
 	if err != nil {
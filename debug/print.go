@@ -0,0 +1,43 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/debug/expr"
+)
+
+func printCmd(comp *a2.Computer, tokens []string) {
+	if len(tokens) < 2 {
+		say("invalid command: 'print' requires an expression")
+		return
+	}
+
+	e, err := expr.Parse(strings.Join(tokens[1:], " "))
+	if err != nil {
+		say(fmt.Sprintf("invalid expression: %v", err))
+		return
+	}
+
+	val, err := e.Eval(env{comp: comp})
+	if err != nil {
+		say(fmt.Sprintf("couldn't evaluate expression: %v", err))
+		return
+	}
+
+	say(fmt.Sprintf("%v = %v (%d)", e, formatValue(val), val))
+}
+
+// formatValue returns val in hex, as a byte or word (whichever it fits in),
+// or failing that, in decimal.
+func formatValue(val int) string {
+	switch {
+	case val >= 0 && val < 0x100:
+		return fmt.Sprintf("$%02X", val)
+	case val >= 0 && val < 0x10000:
+		return fmt.Sprintf("$%04X", val)
+	}
+
+	return fmt.Sprintf("%d", val)
+}
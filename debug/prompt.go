@@ -6,12 +6,30 @@ import (
 )
 
 func Prompt(comp *a2.Computer, line *liner.State) {
-	cmd, err := line.Prompt("debug> ")
+	prompt := "debug> "
+	if asmState.active {
+		prompt = asmPrompt()
+	}
+
+	cmd, err := line.Prompt(prompt)
 	if err != nil {
 		say("couldn't read input")
+		asmState.active = false
+
 		return
 	}
 
 	line.AppendHistory(cmd)
+	input(comp, cmd)
+}
+
+// input handles a line that was entered at the prompt (or read from a
+// script): it's either a command, or a line for asm to assemble.
+func input(comp *a2.Computer, cmd string) {
+	if asmState.active {
+		asmInput(comp, cmd)
+		return
+	}
+
 	execute(comp, cmd)
 }
@@ -0,0 +1,156 @@
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/debug/expr"
+	"github.com/pevans/erc/gfx"
+	"github.com/pevans/erc/obj"
+)
+
+// maxSourceDepth is how many scripts may be sourced inside one another, so
+// that a script that sources itself doesn't go on forever.
+const maxSourceDepth = 16
+
+// sourceDepth is how many scripts we're in the middle of running.
+var sourceDepth int
+
+func source(comp *a2.Computer, tokens []string) {
+	if len(tokens) != 2 {
+		say("invalid command: 'source' requires a file")
+		return
+	}
+
+	if err := runScript(comp, tokens[1]); err != nil {
+		say(err.Error())
+	}
+}
+
+// RunStartupScript runs the debugger commands saved for the given disk
+// image, which are kept in a file alongside it (e.g. game.dsk.ercdbg). It
+// returns true if there was a script to run; it's not an error if there
+// isn't.
+func RunStartupScript(comp *a2.Computer, image string) (bool, error) {
+	script := fmt.Sprintf("%v.ercdbg", image)
+
+	if _, err := os.Stat(script); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	say(fmt.Sprintf("running startup script %v", script))
+
+	return true, runScript(comp, script)
+}
+
+// runScript runs each line of the file at path as though it were entered
+// at the prompt. Empty lines and lines that begin with # are skipped,
+// unless we're assembling lines with asm, where an empty line stops us.
+func runScript(comp *a2.Computer, path string) error {
+	if sourceDepth >= maxSourceDepth {
+		return fmt.Errorf("could not source %v: scripts are nested too deeply", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not source script: %w", err)
+	}
+
+	sourceDepth++
+	defer func() { sourceDepth-- }()
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if !asmState.active {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+		}
+
+		input(comp, line)
+	}
+
+	// Whatever is entered at the prompt after the script wasn't meant to
+	// be assembled
+	if asmState.active {
+		asmInput(comp, "")
+	}
+
+	return nil
+}
+
+func repeat(comp *a2.Computer, tokens []string) {
+	if len(tokens) < 3 {
+		say("invalid command: 'repeat' requires a count and a command, like: repeat <n> <command>")
+		return
+	}
+
+	times, err := integer(tokens[1])
+	if err != nil {
+		say(fmt.Sprintf("invalid count: %v", err))
+		return
+	}
+
+	var (
+		cmd        = strings.Join(tokens[2:], " ")
+		wasStopped = comp.State.Bool(a2state.Debugger)
+	)
+
+	for range times {
+		execute(comp, cmd)
+
+		// Once the command resumes emulation, there's no point in running it
+		// again
+		if wasStopped && !comp.State.Bool(a2state.Debugger) {
+			return
+		}
+	}
+}
+
+// continueCmd resumes emulation, or with a condition (continue if <expr>),
+// only resumes it if the condition is true. In the commands of a
+// breakpoint, the condition can use the breakpoint's hit count.
+func continueCmd(comp *a2.Computer, tokens []string) {
+	if len(tokens) > 1 {
+		if tokens[1] != "if" || len(tokens) < 3 {
+			say("invalid command: 'continue' takes only a condition, like: continue if <expr>")
+			return
+		}
+
+		e, err := expr.Parse(strings.Join(tokens[2:], " "))
+		if err != nil {
+			say(fmt.Sprintf("invalid condition: %v", err))
+			return
+		}
+
+		ev := env{comp: comp}
+		if runningCommands != nil {
+			ev.hits = runningCommands.Hits
+		}
+
+		ok, err := e.True(ev)
+		if err != nil {
+			say(fmt.Sprintf("couldn't evaluate condition: %v", err))
+			return
+		}
+
+		if !ok {
+			return
+		}
+	}
+
+	resume(comp)
+}
+
+// resume leaves the debugger, and lets emulation carry on.
+func resume(comp *a2.Computer) {
+	comp.State.SetBool(a2state.Debugger, false)
+	gfx.ShowStatus(obj.ResumePNG())
+	say("resuming emulation")
+}
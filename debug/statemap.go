@@ -21,6 +21,11 @@ func stateMap(comp *a2.Computer) {
 			statefmt = "%20v | %p"
 		}
 
+		// Printing every segment would be a bit much
+		if segs, isSegs := val.([]*memory.Segment); isSegs {
+			val = fmt.Sprintf("%v segments", len(segs))
+		}
+
 		say(fmt.Sprintf(statefmt, key, val))
 	}
 }
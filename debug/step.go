@@ -25,12 +25,19 @@ func step(comp *a2.Computer, tokens []string) {
 		return
 	}
 
+	executed := 0
 	for range step {
 		if _, err := comp.Process(); err != nil {
 			panic(fmt.Sprintf("could not step instruction: %v", err))
 		}
+
+		executed++
+
+		if CheckWatchpoints(comp) {
+			break
+		}
 	}
 
-	say(fmt.Sprintf("executed %v times, current state is now", step))
+	say(fmt.Sprintf("executed %v times, current state is now", executed))
 	status(comp)
 }
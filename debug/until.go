@@ -35,6 +35,11 @@ func until(comp *a2.Computer, tokens []string) {
 
 		say(comp.CPU.LastInstruction())
 
+		if CheckWatchpoints(comp) {
+			say(fmt.Sprintf("stepped over %v instructions", i+1))
+			return
+		}
+
 		if strings.Contains(comp.CPU.LastInstruction(), instruction) {
 			say(fmt.Sprintf("stepped over %v instructions", i+1))
 			return
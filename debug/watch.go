@@ -0,0 +1,156 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pevans/erc/a2"
+	"github.com/pevans/erc/memory"
+)
+
+// ParseWatchpoints parses a comma-separated list of watchpoints, each of the
+// form kind:addr or kind:from-to (e.g. write:0300-03FF), and adds them to
+// the computer. It returns an error if any of them is invalid.
+func ParseWatchpoints(comp *a2.Computer, flagVal string) error {
+	for spec := range strings.SplitSeq(flagVal, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		kindStr, rangeStr, ok := strings.Cut(spec, ":")
+		if !ok {
+			return fmt.Errorf("invalid watchpoint %q: must be kind:addr or kind:from-to", spec)
+		}
+
+		wp, err := parseWatchpoint(kindStr, rangeStr)
+		if err != nil {
+			return fmt.Errorf("invalid watchpoint %q: %w", spec, err)
+		}
+
+		if err := comp.Watchpoints().Add(wp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckWatchpoints shows each watchpoint that has been hit since we last
+// checked, and returns true if there were any.
+func CheckWatchpoints(comp *a2.Computer) bool {
+	hits := comp.Watchpoints().Hits()
+	if len(hits) == 0 {
+		return false
+	}
+
+	for _, hit := range hits {
+		say(fmt.Sprintf("watchpoint %v", hit))
+	}
+
+	say(fmt.Sprintf(
+		"accessed by ........ %s", strings.TrimSpace(comp.CPU.LastInstruction()),
+	))
+
+	return true
+}
+
+func watch(comp *a2.Computer, tokens []string) {
+	if len(tokens) == 1 {
+		listWatchpoints(comp)
+		return
+	}
+
+	if len(tokens) != 3 {
+		say("invalid command: 'watch' requires a kind (read, write or change) and an address or range")
+		return
+	}
+
+	wp, err := parseWatchpoint(tokens[1], tokens[2])
+	if err != nil {
+		say(fmt.Sprintf("invalid watchpoint: %v", err))
+		return
+	}
+
+	if err := comp.Watchpoints().Add(wp); err != nil {
+		say(err.Error())
+		return
+	}
+
+	say(fmt.Sprintf("watching %v", wp))
+}
+
+func unwatch(comp *a2.Computer, tokens []string) {
+	if len(tokens) != 2 {
+		say("invalid command: 'unwatch' requires an address or range")
+		return
+	}
+
+	from, to, err := parseRange(tokens[1])
+	if err != nil {
+		say(fmt.Sprintf("invalid address: %v", err))
+		return
+	}
+
+	removed := comp.Watchpoints().Remove(from, to)
+	if removed == 0 {
+		say(fmt.Sprintf("no watchpoint on %v", tokens[1]))
+		return
+	}
+
+	say(fmt.Sprintf("removed %v watchpoints", removed))
+}
+
+func listWatchpoints(comp *a2.Computer) {
+	wps := comp.Watchpoints().List()
+	if len(wps) == 0 {
+		say("no watchpoints are set")
+		return
+	}
+
+	for _, wp := range wps {
+		say(fmt.Sprintf("watching %v", wp))
+	}
+}
+
+// parseWatchpoint returns the watchpoint of the given kind (read, write or
+// change) and address range.
+func parseWatchpoint(kindStr, rangeStr string) (memory.Watchpoint, error) {
+	kind, err := memory.ParseWatchKind(kindStr)
+	if err != nil {
+		return memory.Watchpoint{}, err
+	}
+
+	from, to, err := parseRange(rangeStr)
+	if err != nil {
+		return memory.Watchpoint{}, err
+	}
+
+	return memory.Watchpoint{Kind: kind, From: from, To: to}, nil
+}
+
+// parseRange parses a hex address (e.g. 0300) or range of them (e.g.
+// 0300-03FF), and returns the first and last address in it.
+func parseRange(token string) (int, int, error) {
+	fromStr, toStr, isRange := strings.Cut(token, "-")
+
+	from, err := address(fromStr)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !isRange {
+		return from, from, nil
+	}
+
+	to, err := address(toStr)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if to < from {
+		return 0, 0, fmt.Errorf("range ends before it begins: \"%v\"", token)
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,451 @@
+package gdb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// An action is what Debug should do after we handle a packet.
+type action int
+
+const (
+	stayStopped action = iota
+	resumeTarget
+	detachClient
+)
+
+// targetXML describes our registers to the client, since GDB doesn't know
+// the 6502 on its own. The numbers of the registers are their order here.
+const targetXML = `<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+  <feature name="erc.6502">
+    <reg name="a" bitsize="8" type="uint8"/>
+    <reg name="x" bitsize="8" type="uint8"/>
+    <reg name="y" bitsize="8" type="uint8"/>
+    <reg name="p" bitsize="8" type="uint8"/>
+    <reg name="sp" bitsize="8" type="uint8"/>
+    <reg name="pc" bitsize="16" type="code_ptr"/>
+  </feature>
+</target>
+`
+
+// packetSize is the size of the largest packet we tell the client that it
+// can send. We keep our replies to that size as well.
+const packetSize = 0x4000
+
+// The replies that many packets share.
+const (
+	replyOK    = "OK"
+	replyError = "E01"
+)
+
+// handle answers one packet from the client, and returns what we should do
+// next.
+func (s *Stub) handle(sess *session, t Target, data string) action {
+	if data == "" {
+		sess.reply("")
+		return stayStopped
+	}
+
+	args := data[1:]
+
+	switch data[0] {
+	case '?':
+		sess.reply(sess.last.reply())
+
+	case 'g':
+		sess.reply(encodeRegisters(t.Registers()))
+
+	case 'G':
+		regs, err := decodeRegisters(args)
+		if err != nil {
+			sess.reply(replyError)
+			break
+		}
+
+		t.SetRegisters(regs)
+		sess.reply(replyOK)
+
+	case 'p':
+		sess.reply(readRegister(t, args))
+
+	case 'P':
+		sess.reply(writeRegister(t, args))
+
+	case 'm':
+		sess.reply(readMemory(t, args))
+
+	case 'M':
+		sess.reply(writeMemory(t, args, false))
+
+	case 'X':
+		sess.reply(writeMemory(t, args, true))
+
+	case 'Z', 'z':
+		sess.reply(s.point(sess, t, data[0] == 'Z', args))
+
+	case 'c':
+		return s.resume(sess, t, args)
+
+	case 's':
+		if !setPC(t, args) {
+			sess.reply(replyError)
+			break
+		}
+
+		sess.last = stepTarget(t)
+		sess.reply(sess.last.reply())
+
+	case 'v':
+		return s.handleV(sess, t, args)
+
+	case 'q':
+		sess.reply(query(args))
+
+	case 'Q':
+		if args == "StartNoAckMode" {
+			sess.reply(replyOK)
+			sess.noAck.Store(true)
+
+			break
+		}
+
+		sess.reply("")
+
+	case 'H', 'T':
+		// We have only the one thread
+		sess.reply(replyOK)
+
+	case 'D':
+		sess.reply(replyOK)
+		return detachClient
+
+	case 'k':
+		return detachClient
+
+	default:
+		sess.reply("")
+	}
+
+	return stayStopped
+}
+
+// handleV answers the v packets, of which we know vCont.
+func (s *Stub) handleV(sess *session, t Target, args string) action {
+	switch {
+	case args == "Cont?":
+		sess.reply("vCont;c;C;s;S")
+
+	case strings.HasPrefix(args, "Cont;"):
+		// We have one thread, so the first action is the one that applies
+		// to it
+		act, _, _ := strings.Cut(strings.TrimPrefix(args, "Cont;"), ";")
+		act, _, _ = strings.Cut(act, ":")
+
+		switch act[:min(len(act), 1)] {
+		case "c", "C":
+			return s.resume(sess, t, "")
+		case "s", "S":
+			sess.last = stepTarget(t)
+			sess.reply(sess.last.reply())
+		default:
+			sess.reply(replyError)
+		}
+
+	default:
+		sess.reply("")
+	}
+
+	return stayStopped
+}
+
+// resume continues the target (from the address in args, if there is one).
+// If the target is sitting on a breakpoint, we step past it first, or else
+// we would stop there again straight away.
+func (s *Stub) resume(sess *session, t Target, args string) action {
+	if !setPC(t, args) {
+		sess.reply(replyError)
+		return stayStopped
+	}
+
+	if sess.breakpoints[int(t.Registers().PC)] {
+		if st := stepTarget(t); st.watched || st.signal != sigTrap {
+			sess.last = st
+			sess.reply(st.reply())
+
+			return stayStopped
+		}
+	}
+
+	return resumeTarget
+}
+
+// setPC sets the PC to the hex address in args, if there is one, and
+// returns false if it isn't valid.
+func setPC(t Target, args string) bool {
+	if args == "" {
+		return true
+	}
+
+	addr, err := strconv.ParseUint(args, 16, 16)
+	if err != nil {
+		return false
+	}
+
+	regs := t.Registers()
+	regs.PC = uint16(addr)
+	t.SetRegisters(regs)
+
+	return true
+}
+
+// query answers the q packets.
+func query(args string) string {
+	name, rest, _ := strings.Cut(args, ":")
+
+	switch name {
+	case "Supported":
+		return fmt.Sprintf("PacketSize=%x;qXfer:features:read+;QStartNoAckMode+", packetSize)
+	case "Attached":
+		return "1"
+	case "C":
+		return "QC1"
+	case "fThreadInfo":
+		return "m1"
+	case "sThreadInfo":
+		return "l"
+	case "Xfer":
+		return readFeatures(rest)
+	}
+
+	return ""
+}
+
+// readFeatures answers a request for part of our target description, which
+// looks like features:read:target.xml:<offset>,<length>.
+func readFeatures(args string) string {
+	annex, span, ok := strings.Cut(strings.TrimPrefix(args, "features:read:"), ":")
+	if !ok || annex != "target.xml" {
+		return replyError
+	}
+
+	offset, length, err := parseSpan(span)
+	if err != nil {
+		return replyError
+	}
+
+	if offset >= len(targetXML) {
+		return "l"
+	}
+
+	end := min(offset+length, len(targetXML))
+	if end == len(targetXML) {
+		return "l" + targetXML[offset:end]
+	}
+
+	return "m" + targetXML[offset:end]
+}
+
+// point adds or removes the breakpoint or watchpoint in args, which looks
+// like <type>,<addr>,<kind>.
+func (s *Stub) point(sess *session, t Target, add bool, args string) string {
+	fields := strings.Split(args, ",")
+	if len(fields) < 3 {
+		return replyError
+	}
+
+	kind, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return replyError
+	}
+
+	addr, length, err := parseSpan(fields[1] + "," + fields[2])
+	if err != nil || addr > 0xFFFF {
+		return replyError
+	}
+
+	p := Point{Kind: PointKind(kind), Addr: addr, Len: max(length, 1)}
+
+	switch p.Kind {
+	case SoftwareBreak, HardwareBreak:
+		if add {
+			sess.breakpoints[addr] = true
+		} else {
+			delete(sess.breakpoints, addr)
+		}
+
+	case WriteWatch, ReadWatch, AccessWatch:
+		if add {
+			err = t.AddWatch(p)
+		} else {
+			err = t.RemoveWatch(p)
+		}
+
+		if err != nil {
+			return replyError
+		}
+
+	default:
+		// An empty reply says we don't support this kind
+		return ""
+	}
+
+	return replyOK
+}
+
+// encodeRegisters returns the registers as the g packet wants them: each
+// in turn, in hex, with the PC (as the 6502 does) little-endian.
+func encodeRegisters(regs Registers) string {
+	return hex.EncodeToString([]byte{
+		regs.A, regs.X, regs.Y, regs.P, regs.S,
+		uint8(regs.PC), uint8(regs.PC >> 8),
+	})
+}
+
+// decodeRegisters returns the registers that a G packet sets.
+func decodeRegisters(args string) (Registers, error) {
+	b, err := hex.DecodeString(args)
+	if err != nil {
+		return Registers{}, err
+	}
+
+	if len(b) != 7 {
+		return Registers{}, fmt.Errorf("expected 7 bytes of registers, got %d", len(b))
+	}
+
+	return Registers{
+		A: b[0], X: b[1], Y: b[2], P: b[3], S: b[4],
+		PC: uint16(b[5]) | uint16(b[6])<<8,
+	}, nil
+}
+
+// readRegister answers a p packet, whose args are the number of a register.
+func readRegister(t Target, args string) string {
+	n, err := strconv.ParseUint(args, 16, 8)
+	if err != nil || n > 5 {
+		return replyError
+	}
+
+	enc := encodeRegisters(t.Registers())
+	if n == 5 {
+		return enc[10:]
+	}
+
+	return enc[n*2 : n*2+2]
+}
+
+// writeRegister answers a P packet, whose args look like <n>=<value>.
+func writeRegister(t Target, args string) string {
+	numStr, valStr, ok := strings.Cut(args, "=")
+	if !ok {
+		return replyError
+	}
+
+	n, err := strconv.ParseUint(numStr, 16, 8)
+	if err != nil || n > 5 {
+		return replyError
+	}
+
+	val, err := hex.DecodeString(valStr)
+	if err != nil || len(val) == 0 {
+		return replyError
+	}
+
+	regs := t.Registers()
+
+	switch n {
+	case 0:
+		regs.A = val[0]
+	case 1:
+		regs.X = val[0]
+	case 2:
+		regs.Y = val[0]
+	case 3:
+		regs.P = val[0]
+	case 4:
+		regs.S = val[0]
+	case 5:
+		regs.PC = uint16(val[0])
+		if len(val) > 1 {
+			regs.PC |= uint16(val[1]) << 8
+		}
+	}
+
+	t.SetRegisters(regs)
+
+	return replyOK
+}
+
+// readMemory answers an m packet, whose args look like <addr>,<length>.
+// Anything past the end of memory isn't read, and neither is anything that
+// wouldn't fit in a packet (in hex, each byte takes up two characters).
+func readMemory(t Target, args string) string {
+	addr, length, err := parseSpan(args)
+	if err != nil || addr > 0xFFFF {
+		return replyError
+	}
+
+	length = min(length, 0x10000-addr, packetSize/2)
+
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = t.ReadMemory(addr + i)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// writeMemory answers an M packet (<addr>,<length>:<hex>), or an X packet
+// (<addr>,<length>:<binary>) if binary is true.
+func writeMemory(t Target, args string, binary bool) string {
+	span, data, ok := strings.Cut(args, ":")
+	if !ok {
+		return replyError
+	}
+
+	addr, length, err := parseSpan(span)
+	if err != nil {
+		return replyError
+	}
+
+	var b []byte
+	if binary {
+		b = unescape(data)
+	} else if b, err = hex.DecodeString(data); err != nil {
+		return replyError
+	}
+
+	if len(b) != length || addr+length > 0x10000 {
+		return replyError
+	}
+
+	for i, val := range b {
+		t.WriteMemory(addr+i, val)
+	}
+
+	return replyOK
+}
+
+// parseSpan parses the <addr>,<length> (both in hex) that many packets
+// have.
+func parseSpan(span string) (int, int, error) {
+	addrStr, lenStr, ok := strings.Cut(span, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected <addr>,<length>: %q", span)
+	}
+
+	addr, err := strconv.ParseUint(addrStr, 16, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	length, err := strconv.ParseUint(lenStr, 16, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(addr), int(length), nil
+}
@@ -0,0 +1,111 @@
+package gdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// interruptByte is what a client sends, outside of any packet, to stop a
+// target that's running.
+const interruptByte = 0x03
+
+// checksum returns the sum (modulo 256) of the bytes in a packet's data.
+func checksum(data string) uint8 {
+	var sum uint8
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+
+	return sum
+}
+
+// frame returns the data as a packet, $data#cs, with any bytes in the data
+// that mean something to the protocol escaped.
+func frame(data string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '$', '#', '}', '*':
+			sb.WriteByte('}')
+			sb.WriteByte(c ^ 0x20)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	escaped := sb.String()
+
+	return fmt.Sprintf("$%s#%02x", escaped, checksum(escaped))
+}
+
+// unescape returns the binary data of an X packet, in which the bytes $, #,
+// } and * are sent as } followed by the byte xor 0x20.
+func unescape(data string) []byte {
+	out := make([]byte, 0, len(data))
+
+	for i := 0; i < len(data); i++ {
+		if data[i] == '}' && i+1 < len(data) {
+			i++
+			out = append(out, data[i]^0x20)
+			continue
+		}
+
+		out = append(out, data[i])
+	}
+
+	return out
+}
+
+// A message is what we read from a client: either a packet, or an
+// interrupt that came outside of any packet.
+type message struct {
+	data      string
+	interrupt bool
+
+	// bad is true if the packet's checksum was wrong
+	bad bool
+}
+
+// readMessage reads the next packet or interrupt from r. Acknowledgements
+// (+ and -) are skipped.
+func readMessage(r *bufio.Reader) (message, error) {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return message{}, err
+		}
+
+		switch c {
+		case interruptByte:
+			return message{interrupt: true}, nil
+		case '$':
+			return readPacket(r)
+		}
+	}
+}
+
+// readPacket reads the rest of a packet (after its $), up to and including
+// its checksum.
+func readPacket(r *bufio.Reader) (message, error) {
+	data, err := r.ReadString('#')
+	if err != nil {
+		return message{}, err
+	}
+
+	data = strings.TrimSuffix(data, "#")
+
+	var cs [2]byte
+	if _, err := io.ReadFull(r, cs[:]); err != nil {
+		return message{}, err
+	}
+
+	var sum uint8
+	if _, err := fmt.Sscanf(string(cs[:]), "%02x", &sum); err != nil || sum != checksum(data) {
+		return message{data: data, bad: true}, nil
+	}
+
+	return message{data: data}, nil
+}
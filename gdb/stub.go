@@ -0,0 +1,348 @@
+// Package gdb is a stub for the GDB remote serial protocol. It lets GDB, or
+// any debugger or IDE front end that speaks the protocol, attach to the
+// emulator over a local TCP port, and from there read and write registers
+// and memory, set breakpoints and watchpoints, step, and continue.
+package gdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+)
+
+// Registers are the registers of the 6502, in the order that we number
+// them for the client (see targetXML).
+type Registers struct {
+	A, X, Y, P, S uint8
+	PC            uint16
+}
+
+// A PointKind is a kind of breakpoint or watchpoint. The values are the
+// ones that the client uses in its Z and z packets.
+type PointKind int
+
+const (
+	SoftwareBreak PointKind = iota
+	HardwareBreak
+	WriteWatch
+	ReadWatch
+	AccessWatch
+)
+
+// A Point is a breakpoint or watchpoint on Len bytes beginning at Addr.
+type Point struct {
+	Kind PointKind
+	Addr int
+	Len  int
+}
+
+// A Target is something that the stub can debug. Its methods are only
+// called while it is stopped, or (for Check) between instructions.
+type Target interface {
+	Registers() Registers
+	SetRegisters(regs Registers)
+
+	// ReadMemory and WriteMemory get and set a byte at an address as the
+	// CPU sees it, although reading should not have any side effect that
+	// the CPU's reading would.
+	ReadMemory(addr int) uint8
+	WriteMemory(addr int, val uint8)
+
+	// Step executes one instruction.
+	Step() error
+
+	// AddWatch and RemoveWatch add and remove a watchpoint, and WatchHit
+	// returns one that was hit (and the address that was accessed) since
+	// we last asked, if any was.
+	AddWatch(p Point) error
+	RemoveWatch(p Point) error
+	WatchHit() (Point, int, bool)
+
+	// Detach is called when the client detaches, is killed, or goes away,
+	// so that the target can remove the watchpoints it added for the
+	// client. (The stub keeps the breakpoints itself, and those go with
+	// the client.)
+	Detach()
+}
+
+// The signals that we report to the client as the reason the target
+// stopped.
+const (
+	sigInt  = 2
+	sigIll  = 4
+	sigTrap = 5
+)
+
+// A stop is the reason the target stopped.
+type stop struct {
+	signal int
+
+	// watched is true if we stopped for a watchpoint of the given kind,
+	// which was hit by an access to addr.
+	watched bool
+	kind    PointKind
+	addr    int
+}
+
+// reply returns the stop reply packet that tells the client why we
+// stopped.
+func (st stop) reply() string {
+	if !st.watched {
+		return fmt.Sprintf("T%02x", st.signal)
+	}
+
+	name := "watch"
+	switch st.kind {
+	case ReadWatch:
+		name = "rwatch"
+	case AccessWatch:
+		name = "awatch"
+	}
+
+	return fmt.Sprintf("T%02x%s:%x;", st.signal, name, st.addr)
+}
+
+// A session is a connection with a client. Only the fields that are
+// atomic are touched by the goroutine that reads from the client; the rest
+// belong to whoever calls Check and Debug.
+type session struct {
+	conn    io.ReadWriteCloser
+	packets chan message
+
+	interrupt atomic.Bool
+	closed    atomic.Bool
+	noAck     atomic.Bool
+
+	// running is true if the client resumed the target, and is waiting to
+	// hear why it stopped.
+	running     bool
+	last        stop
+	breakpoints map[int]bool
+}
+
+// A Stub listens for a client, and when one is attached, answers its
+// requests while the target is stopped.
+type Stub struct {
+	listener net.Listener
+	session  atomic.Pointer[session]
+}
+
+// Listen returns a stub that listens for a client on the given port of the
+// loopback interface. (Port 0 picks any free port; see Addr.)
+func Listen(port int) (*Stub, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stub{listener: listener}, nil
+}
+
+// Addr returns the address that the stub is listening on.
+func (s *Stub) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Accept waits for a client to connect, and attaches it, which stops the
+// target at its next Check. Clients that connect while another one is
+// attached are turned away.
+func (s *Stub) Accept() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		if s.Attached() {
+			_ = conn.Close()
+			continue
+		}
+
+		s.attach(conn)
+
+		return nil
+	}
+}
+
+// AcceptAll attaches each client that connects, one at a time, until the
+// stub is closed.
+func (s *Stub) AcceptAll() {
+	for s.Accept() == nil {
+	}
+}
+
+// Close stops listening, and hangs up on any client that's attached. Since
+// it may be called while the target runs, we leave it to the next Check or
+// Debug to detach the client from the target.
+func (s *Stub) Close() error {
+	if sess := s.session.Load(); sess != nil {
+		_ = sess.conn.Close()
+	}
+
+	return s.listener.Close()
+}
+
+// Attached returns true if a client is attached.
+func (s *Stub) Attached() bool {
+	return s.session.Load() != nil
+}
+
+// attach begins a session with a client on the given connection.
+func (s *Stub) attach(conn io.ReadWriteCloser) {
+	sess := &session{
+		conn:        conn,
+		packets:     make(chan message, 16),
+		last:        stop{signal: sigTrap},
+		breakpoints: make(map[int]bool),
+	}
+
+	// The target should stop as soon as it can, so that the client can see
+	// what state it's in
+	sess.interrupt.Store(true)
+
+	go sess.read()
+
+	s.session.Store(sess)
+}
+
+// detach ends the session, if it's still the one we have, and lets the
+// target forget what the client asked of it.
+func (s *Stub) detach(sess *session, t Target) {
+	if s.session.CompareAndSwap(sess, nil) {
+		t.Detach()
+	}
+
+	_ = sess.conn.Close()
+}
+
+// read reads packets from the client until the connection is closed,
+// acknowledging each one (unless we've agreed not to) and passing it on to
+// Debug. An interrupt is noted for Check to find.
+func (sess *session) read() {
+	defer close(sess.packets)
+	defer sess.closed.Store(true)
+
+	r := bufio.NewReader(sess.conn)
+
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			return
+		}
+
+		if msg.interrupt {
+			sess.interrupt.Store(true)
+			continue
+		}
+
+		if !sess.noAck.Load() {
+			ack := "+"
+			if msg.bad {
+				ack = "-"
+			}
+
+			if _, err := io.WriteString(sess.conn, ack); err != nil {
+				return
+			}
+		}
+
+		if !msg.bad {
+			sess.packets <- msg
+		}
+	}
+}
+
+// reply sends a packet with the given data to the client.
+func (sess *session) reply(data string) {
+	// If this fails, the connection is gone, and read will tell us so
+	_, _ = io.WriteString(sess.conn, frame(data))
+}
+
+// Check returns true if a client is attached and the target should stop,
+// because the client interrupted it or because it reached a breakpoint or
+// hit a watchpoint. It's meant to be called before each instruction that
+// the target executes; if it returns true, call Debug.
+func (s *Stub) Check(t Target) bool {
+	sess := s.session.Load()
+	if sess == nil {
+		return false
+	}
+
+	if sess.closed.Load() {
+		s.detach(sess, t)
+		return false
+	}
+
+	if sess.interrupt.Swap(false) {
+		// When we're stopped on attaching, the client doesn't expect to
+		// hear about it until it asks
+		if sess.running {
+			sess.last = stop{signal: sigInt}
+		}
+
+		return true
+	}
+
+	if sess.breakpoints[int(t.Registers().PC)] {
+		sess.last = stop{signal: sigTrap}
+		return true
+	}
+
+	if p, addr, ok := t.WatchHit(); ok {
+		sess.last = watchStop(p, addr)
+		return true
+	}
+
+	return false
+}
+
+// watchStop returns the stop for a hit of the given watchpoint at addr.
+func watchStop(p Point, addr int) stop {
+	return stop{signal: sigTrap, watched: true, kind: p.Kind, addr: addr}
+}
+
+// Debug answers the client's requests while the target is stopped, and
+// returns when the client resumes the target or detaches from it.
+func (s *Stub) Debug(t Target) {
+	sess := s.session.Load()
+	if sess == nil {
+		return
+	}
+
+	if sess.running {
+		sess.running = false
+		sess.reply(sess.last.reply())
+	}
+
+	for msg := range sess.packets {
+		switch s.handle(sess, t, msg.data) {
+		case resumeTarget:
+			sess.running = true
+
+			return
+
+		case detachClient:
+			s.detach(sess, t)
+			return
+		}
+	}
+
+	// The client went away without detaching
+	s.detach(sess, t)
+}
+
+// stepTarget executes one instruction, and returns why the target stopped
+// after it.
+func stepTarget(t Target) stop {
+	if err := t.Step(); err != nil {
+		return stop{signal: sigIll}
+	}
+
+	if p, addr, ok := t.WatchHit(); ok {
+		return watchStop(p, addr)
+	}
+
+	return stop{signal: sigTrap}
+}
@@ -0,0 +1,345 @@
+package gdb
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTarget is a machine whose every instruction is a one-byte NOP, which
+// (like a real one) reads the byte at the PC.
+type fakeTarget struct {
+	regs    Registers
+	mem     [0x10000]uint8
+	watches []Point
+	hits    []Point
+	hitAddr int
+}
+
+func (f *fakeTarget) Registers() Registers        { return f.regs }
+func (f *fakeTarget) SetRegisters(regs Registers) { f.regs = regs }
+func (f *fakeTarget) ReadMemory(addr int) uint8   { return f.mem[addr] }
+
+func (f *fakeTarget) WriteMemory(addr int, val uint8) {
+	f.mem[addr] = val
+}
+
+func (f *fakeTarget) Step() error {
+	addr := int(f.regs.PC)
+	f.regs.PC++
+
+	for _, p := range f.watches {
+		if addr >= p.Addr && addr < p.Addr+p.Len && p.Kind != WriteWatch {
+			f.hits = append(f.hits, p)
+			f.hitAddr = addr
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeTarget) AddWatch(p Point) error {
+	f.watches = append(f.watches, p)
+	return nil
+}
+
+func (f *fakeTarget) RemoveWatch(p Point) error {
+	for i, w := range f.watches {
+		if w == p {
+			f.watches = append(f.watches[:i], f.watches[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeTarget) Detach() {
+	f.watches = nil
+}
+
+func (f *fakeTarget) WatchHit() (Point, int, bool) {
+	if len(f.hits) == 0 {
+		return Point{}, 0, false
+	}
+
+	p := f.hits[0]
+	f.hits = nil
+
+	return p, f.hitAddr, true
+}
+
+// A client talks to a stub as GDB would.
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// newClient attaches a client to a new stub, which debugs a fake target
+// that runs (as the emulator would) until the client stops it.
+func newClient(t *testing.T) *client {
+	var (
+		stub         = &Stub{}
+		target       = &fakeTarget{}
+		ours, theirs = net.Pipe()
+		done         atomic.Bool
+	)
+
+	target.regs = Registers{S: 0xFF, PC: 0x0800}
+
+	stub.attach(ours)
+
+	go func() {
+		for !done.Load() {
+			if stub.Check(target) {
+				stub.Debug(target)
+				continue
+			}
+
+			_ = target.Step()
+		}
+	}()
+
+	t.Cleanup(func() {
+		done.Store(true)
+		_ = theirs.Close()
+	})
+
+	return &client{t: t, conn: theirs, r: bufio.NewReader(theirs)}
+}
+
+// send sends a packet, and waits for the stub to acknowledge it.
+func (c *client) send(data string) {
+	_, err := c.conn.Write([]byte(frame(data)))
+	require.NoError(c.t, err)
+
+	ack, err := c.r.ReadByte()
+	require.NoError(c.t, err)
+	require.Equal(c.t, byte('+'), ack)
+}
+
+// receive returns the data of the next packet from the stub.
+func (c *client) receive() string {
+	msg, err := readMessage(c.r)
+	require.NoError(c.t, err)
+	require.False(c.t, msg.bad)
+
+	_, err = c.conn.Write([]byte("+"))
+	require.NoError(c.t, err)
+
+	return msg.data
+}
+
+// ask sends a packet, and returns the stub's reply.
+func (c *client) ask(data string) string {
+	c.send(data)
+	return c.receive()
+}
+
+func TestFrame(t *testing.T) {
+	assert.Equal(t, "$OK#9a", frame("OK"))
+	assert.Equal(t, "$a}]b#9d", frame("a}b"))
+	assert.Equal(t, []byte("a}b"), unescape("a}]b"))
+}
+
+func TestQueries(t *testing.T) {
+	c := newClient(t)
+
+	assert.Contains(t, c.ask("qSupported:multiprocess+;swbreak+"), "qXfer:features:read+")
+	assert.Contains(t, c.ask("qSupported"), "PacketSize=4000")
+	assert.Equal(t, "1", c.ask("qAttached"))
+	assert.Equal(t, "T05", c.ask("?"))
+	assert.Equal(t, "", c.ask("qSomethingElse"))
+
+	xml := c.ask("qXfer:features:read:target.xml:0,4000")
+	assert.True(t, strings.HasPrefix(xml, "l<?xml"))
+	assert.Contains(t, xml, `<reg name="pc" bitsize="16"`)
+
+	assert.Equal(t, "m<?xm", c.ask("qXfer:features:read:target.xml:0,4"))
+}
+
+func TestRegisters(t *testing.T) {
+	c := newClient(t)
+
+	assert.Equal(t, "00000000ff0008", c.ask("g"))
+	assert.Equal(t, "OK", c.ask("G0102033004c103"))
+	assert.Equal(t, "0102033004c103", c.ask("g"))
+	assert.Equal(t, "30", c.ask("p3"))
+	assert.Equal(t, "c103", c.ask("p5"))
+	assert.Equal(t, "OK", c.ask("P5=0008"))
+	assert.Equal(t, "0008", c.ask("p5"))
+	assert.Equal(t, "E01", c.ask("p6"))
+	assert.Equal(t, "E01", c.ask("G01"))
+}
+
+func TestMemory(t *testing.T) {
+	c := newClient(t)
+
+	assert.Equal(t, "OK", c.ask("M300,3:a9c160"))
+	assert.Equal(t, "a9c160", c.ask("m300,3"))
+	assert.Equal(t, "OK", c.ask("X303,2:}#"))
+	assert.Equal(t, "7d23", c.ask("m303,2"))
+	assert.Equal(t, "0000", c.ask("mfffe,10"))
+	assert.Len(t, c.ask("m0,ffffffff"), 0x4000)
+	assert.Equal(t, "E01", c.ask("M300,2:a9"))
+}
+
+func TestBreakpoint(t *testing.T) {
+	c := newClient(t)
+
+	assert.Equal(t, "OK", c.ask("Z0,810,1"))
+
+	c.send("c")
+	assert.Equal(t, "T05", c.receive())
+	assert.Equal(t, "1008", c.ask("p5"))
+
+	// Continuing from a breakpoint doesn't stop at it again
+	assert.Equal(t, "OK", c.ask("Z1,820,1"))
+	c.send("vCont;c")
+	assert.Equal(t, "T05", c.receive())
+	assert.Equal(t, "2008", c.ask("p5"))
+
+	assert.Equal(t, "OK", c.ask("z0,810,1"))
+	assert.Equal(t, "", c.ask("Z9,810,1"))
+}
+
+func TestWatchpoint(t *testing.T) {
+	c := newClient(t)
+
+	assert.Equal(t, "OK", c.ask("P5=0008"))
+	assert.Equal(t, "OK", c.ask("Z3,900,2"))
+
+	c.send("c")
+	assert.Equal(t, "T05rwatch:900;", c.receive())
+	assert.Equal(t, "0109", c.ask("p5"))
+
+	assert.Equal(t, "OK", c.ask("z3,900,2"))
+	assert.Equal(t, "OK", c.ask("Z4,a00,1"))
+	c.send("c")
+	assert.Equal(t, "T05awatch:a00;", c.receive())
+}
+
+func TestStep(t *testing.T) {
+	c := newClient(t)
+
+	assert.Equal(t, "T05", c.ask("s"))
+	assert.Equal(t, "0108", c.ask("p5"))
+	assert.Equal(t, "T05", c.ask("s300"))
+	assert.Equal(t, "0103", c.ask("p5"))
+	assert.Equal(t, "T05", c.ask("vCont;s:1"))
+	assert.Equal(t, "0203", c.ask("p5"))
+}
+
+func TestInterrupt(t *testing.T) {
+	c := newClient(t)
+
+	c.send("c")
+
+	_, err := c.conn.Write([]byte{interruptByte})
+	require.NoError(t, err)
+
+	assert.Equal(t, "T02", c.receive())
+	assert.Equal(t, "T02", c.ask("?"))
+}
+
+func TestNoAckMode(t *testing.T) {
+	c := newClient(t)
+
+	assert.Equal(t, "OK", c.ask("QStartNoAckMode"))
+
+	// Now neither of us acknowledges packets
+	_, err := c.conn.Write([]byte(frame("p4")))
+	require.NoError(t, err)
+
+	msg, err := readMessage(c.r)
+	require.NoError(t, err)
+	assert.Equal(t, "ff", msg.data)
+}
+
+func TestDetach(t *testing.T) {
+	var (
+		stub         = &Stub{}
+		target       = &fakeTarget{}
+		ours, theirs = net.Pipe()
+	)
+
+	stub.attach(ours)
+	assert.True(t, stub.Attached())
+
+	c := &client{t: t, conn: theirs, r: bufio.NewReader(theirs)}
+
+	require.True(t, stub.Check(target))
+
+	go stub.Debug(target)
+
+	// The stub hangs up after it replies, so we don't acknowledge the reply
+	c.send("D")
+
+	msg, err := readMessage(c.r)
+	require.NoError(t, err)
+	assert.Equal(t, "OK", msg.data)
+
+	assert.Eventually(t, func() bool {
+		return !stub.Attached()
+	}, time.Second, time.Millisecond)
+}
+
+func TestDetachRemovesWatches(t *testing.T) {
+	// Each way the client can leave takes its watchpoints with it
+	cases := map[string]func(c *client){
+		"detach": func(c *client) {
+			c.send("D")
+			_, _ = readMessage(c.r)
+		},
+		"kill": func(c *client) {
+			c.send("k")
+		},
+		"disconnect": func(c *client) {
+			_ = c.conn.Close()
+		},
+	}
+
+	for name, leave := range cases {
+		t.Run(name, func(t *testing.T) {
+			var (
+				stub         = &Stub{}
+				target       = &fakeTarget{}
+				ours, theirs = net.Pipe()
+				done         = make(chan struct{})
+			)
+
+			stub.attach(ours)
+			c := &client{t: t, conn: theirs, r: bufio.NewReader(theirs)}
+
+			require.True(t, stub.Check(target))
+
+			go func() {
+				stub.Debug(target)
+				close(done)
+			}()
+
+			assert.Equal(t, "OK", c.ask("Z2,900,1"))
+			assert.Equal(t, "OK", c.ask("Z3,a00,2"))
+
+			leave(c)
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("the stub didn't stop debugging")
+			}
+
+			assert.False(t, stub.Attached())
+			assert.Empty(t, target.watches)
+
+			_ = theirs.Close()
+		})
+	}
+}
@@ -6,14 +6,36 @@ import (
 	"os"
 	"slices"
 	"sync"
+	"sync/atomic"
 )
 
 var (
 	metricMap   = map[string]int{}
 	metricMutex sync.Mutex
+
+	// disabled is true if no one cares to see our metrics, in which case we
+	// don't bother to keep them.
+	disabled atomic.Bool
 )
 
+// Enable turns the collection of metrics on or off. Metrics are collected by
+// default, but since Increment has to take a lock, it's worth turning them
+// off if they won't be written anywhere.
+func Enable(on bool) {
+	disabled.Store(!on)
+}
+
+// Enabled returns true if we're collecting metrics. If you have to do any
+// work to build a key, you can check this first to avoid it.
+func Enabled() bool {
+	return !disabled.Load()
+}
+
 func Increment(key string, num int) {
+	if disabled.Load() {
+		return
+	}
+
 	metricMutex.Lock()
 	defer metricMutex.Unlock()
 
@@ -45,3 +45,19 @@ func TestClear(t *testing.T) {
 	Clear()
 	assert.Empty(t, metricMap)
 }
+
+func TestEnable(t *testing.T) {
+	defer Enable(true)
+
+	Enable(false)
+	assert.False(t, Enabled())
+
+	Increment("disabled", 1)
+	assert.NotContains(t, metricMap, "disabled")
+
+	Enable(true)
+	assert.True(t, Enabled())
+
+	Increment("disabled", 1)
+	assert.Contains(t, metricMap, "disabled")
+}
@@ -15,6 +15,12 @@ type SoftMap struct {
 	reads  []SoftRead
 	writes []SoftWrite
 	state  *StateMap
+
+	// watch, if set, is told about each access to a soft switch, which
+	// are the addresses from switchFrom up to (but not including) switchTo.
+	watch      *Watchpoints
+	switchFrom int
+	switchTo   int
 }
 
 // NewSoftMap returns a newly allocated softmap with valid maps for reads and
@@ -30,6 +36,17 @@ func (sm *SoftMap) UseState(st *StateMap) {
 	sm.state = st
 }
 
+// UseWatchpoints tells the given watchpoints about each access to a soft
+// switch in the softmap, so that they know which accesses weren't to
+// memory. The soft switches are those from..to; other addresses may have
+// entries in the softmap, but those only pick which memory an access goes
+// to, and still count as memory.
+func (sm *SoftMap) UseWatchpoints(w *Watchpoints, from, to int) {
+	sm.watch = w
+	sm.switchFrom = from
+	sm.switchTo = to
+}
+
 // SetRead will assign a read function to a given address in the softmap.
 func (sm *SoftMap) SetRead(addr int, fn SoftRead) {
 	sm.reads[addr] = fn
@@ -49,6 +66,10 @@ func (sm *SoftMap) Read(addr int) (uint8, bool) {
 		return 0, false
 	}
 
+	if sm.isSwitch(addr) {
+		sm.watch.softSwitch(addr)
+	}
+
 	return fn(addr, sm.state), true
 }
 
@@ -60,6 +81,16 @@ func (sm *SoftMap) Write(addr int, val uint8) bool {
 		return false
 	}
 
+	if sm.isSwitch(addr) {
+		sm.watch.softSwitch(addr)
+	}
+
 	fn(addr, val, sm.state)
 	return true
 }
+
+// isSwitch returns true if addr is a soft switch that our watchpoints want
+// to hear about.
+func (sm *SoftMap) isSwitch(addr int) bool {
+	return sm.watch != nil && addr >= sm.switchFrom && addr < sm.switchTo
+}
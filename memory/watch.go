@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+)
+
+// A WatchKind is the kind of access that a watchpoint looks for.
+type WatchKind uint8
+
+const (
+	// WatchRead triggers when an address is read.
+	WatchRead WatchKind = 1 << iota
+
+	// WatchWrite triggers when an address is written, whether or not the
+	// value there changes.
+	WatchWrite
+
+	// WatchChange triggers when an address is written with a value that's
+	// different from the one it had. Soft switches don't hold a value that
+	// we can compare, so on those, it triggers on any access.
+	WatchChange
+)
+
+// watchSpace is the number of addresses that a watchpoint can cover.
+const watchSpace = 0x10000
+
+// String returns the name of the kind of watchpoint.
+func (k WatchKind) String() string {
+	switch k {
+	case WatchRead:
+		return "read"
+	case WatchWrite:
+		return "write"
+	case WatchChange:
+		return "change"
+	}
+
+	return fmt.Sprintf("WatchKind(%d)", uint8(k))
+}
+
+// ParseWatchKind returns the kind of watchpoint with the given name.
+func ParseWatchKind(name string) (WatchKind, error) {
+	switch name {
+	case "read":
+		return WatchRead, nil
+	case "write":
+		return WatchWrite, nil
+	case "change":
+		return WatchChange, nil
+	}
+
+	return 0, fmt.Errorf("unknown watchpoint kind %q (must be read, write or change)", name)
+}
+
+// A Watchpoint looks for some kind of access to any address from From to To
+// (inclusive).
+type Watchpoint struct {
+	Kind     WatchKind
+	From, To int
+}
+
+// String returns the watchpoint as its kind and range, e.g. "write
+// 0300-03FF" or "change 00FE".
+func (wp Watchpoint) String() string {
+	if wp.From == wp.To {
+		return fmt.Sprintf("%v %04X", wp.Kind, wp.From)
+	}
+
+	return fmt.Sprintf("%v %04X-%04X", wp.Kind, wp.From, wp.To)
+}
+
+// covers returns true if the watchpoint covers the given address.
+func (wp Watchpoint) covers(addr int) bool {
+	return addr >= wp.From && addr <= wp.To
+}
+
+// A WatchHit is an access that triggered a watchpoint. PC is the address of
+// the instruction that made the access. Old is the value at Addr before a
+// write, and New is the value read or written. If SoftSwitch is true, the
+// access went to a soft switch, and Old means nothing.
+type WatchHit struct {
+	Watchpoint Watchpoint
+	PC         int
+	Addr       int
+	Old, New   uint8
+	Write      bool
+	SoftSwitch bool
+}
+
+// String describes the hit, e.g. "write 0300-03FF: $0302 written by $0812
+// ($00 -> $41)".
+func (h WatchHit) String() string {
+	verb := "read"
+	if h.Write {
+		verb = "written"
+	}
+
+	desc := fmt.Sprintf(
+		"%v: $%04X %v by $%04X", h.Watchpoint, h.Addr, verb, h.PC,
+	)
+
+	switch {
+	case h.SoftSwitch:
+		return desc + fmt.Sprintf(" (soft switch, $%02X)", h.New)
+	case h.Write:
+		return desc + fmt.Sprintf(" ($%02X -> $%02X)", h.Old, h.New)
+	}
+
+	return desc + fmt.Sprintf(" ($%02X)", h.New)
+}
+
+// Watchpoints is a set of watchpoints, along with the hits they've had that
+// nobody has yet looked at. Accesses are reported by whoever makes them (in
+// practice, the CPU); a SoftMap that uses the watchpoints lets them know
+// which of those accesses went to soft switches.
+type Watchpoints struct {
+	points []Watchpoint
+
+	// watched is true for each address that some watchpoint covers, so that
+	// we can tell quickly if an address is watched at all. It's nil if there
+	// are no watchpoints.
+	watched []bool
+
+	hits []WatchHit
+
+	// switchAddr is the address of the last soft switch that was accessed,
+	// or -1 if the last access we were told about wasn't to one.
+	switchAddr int
+}
+
+// NewWatchpoints returns a set with no watchpoints in it.
+func NewWatchpoints() *Watchpoints {
+	return &Watchpoints{switchAddr: -1}
+}
+
+// Add adds a watchpoint to the set.
+func (w *Watchpoints) Add(wp Watchpoint) error {
+	if wp.From < 0 || wp.To >= watchSpace || wp.From > wp.To {
+		return fmt.Errorf("invalid watchpoint range: %04X-%04X", wp.From, wp.To)
+	}
+
+	w.points = append(w.points, wp)
+	w.index()
+
+	return nil
+}
+
+// Remove removes every watchpoint with the given range, and returns the
+// number that it removed.
+func (w *Watchpoints) Remove(from, to int) int {
+	before := len(w.points)
+	w.points = slices.DeleteFunc(w.points, func(wp Watchpoint) bool {
+		return wp.From == from && wp.To == to
+	})
+
+	w.index()
+
+	return before - len(w.points)
+}
+
+// List returns the watchpoints in the set, in the order they were added.
+func (w *Watchpoints) List() []Watchpoint {
+	return slices.Clone(w.points)
+}
+
+// Watched returns true if any watchpoint covers the given address. This is
+// cheap enough to call on every access.
+func (w *Watchpoints) Watched(addr int) bool {
+	return w.watched != nil && w.watched[addr&(watchSpace-1)]
+}
+
+// Read reports that the instruction at pc read val from addr.
+func (w *Watchpoints) Read(pc, addr int, val uint8) {
+	if !w.Watched(addr) {
+		return
+	}
+
+	w.check(WatchHit{PC: pc, Addr: addr, New: val})
+}
+
+// Write reports that the instruction at pc wrote val to addr, where the
+// value had been old.
+func (w *Watchpoints) Write(pc, addr int, old, val uint8) {
+	if !w.Watched(addr) {
+		return
+	}
+
+	w.check(WatchHit{PC: pc, Addr: addr, Old: old, New: val, Write: true})
+}
+
+// Hits returns the hits since the last time we were asked, and forgets
+// them.
+func (w *Watchpoints) Hits() []WatchHit {
+	hits := w.hits
+	w.hits = nil
+
+	return hits
+}
+
+// check records a hit for the first watchpoint that the access triggers,
+// if any do.
+func (w *Watchpoints) check(hit WatchHit) {
+	hit.Addr &= watchSpace - 1
+	hit.SoftSwitch = w.switchAddr == hit.Addr
+	w.switchAddr = -1
+
+	for _, wp := range w.points {
+		if !wp.covers(hit.Addr) || !triggers(wp.Kind, hit) {
+			continue
+		}
+
+		hit.Watchpoint = wp
+		w.hits = append(w.hits, hit)
+
+		return
+	}
+}
+
+// triggers returns true if a watchpoint of the given kind would be
+// triggered by the hit.
+func triggers(kind WatchKind, hit WatchHit) bool {
+	switch kind {
+	case WatchRead:
+		return !hit.Write
+	case WatchWrite:
+		return hit.Write
+	case WatchChange:
+		return hit.SoftSwitch || (hit.Write && hit.Old != hit.New)
+	}
+
+	return false
+}
+
+// softSwitch notes that a soft switch at the given address was accessed,
+// which we'll want to know when the access is reported to us.
+func (w *Watchpoints) softSwitch(addr int) {
+	addr &= watchSpace - 1
+	if w.Watched(addr) {
+		w.switchAddr = addr
+	}
+}
+
+// index rebuilds the set of addresses that are watched.
+func (w *Watchpoints) index() {
+	if len(w.points) == 0 {
+		w.watched = nil
+		return
+	}
+
+	w.watched = make([]bool, watchSpace)
+	for _, wp := range w.points {
+		for addr := wp.From; addr <= wp.To; addr++ {
+			w.watched[addr] = true
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchpoints(t *testing.T) {
+	w := NewWatchpoints()
+	assert.False(t, w.Watched(0x300))
+
+	require.NoError(t, w.Add(Watchpoint{Kind: WatchWrite, From: 0x300, To: 0x3FF}))
+	assert.Error(t, w.Add(Watchpoint{Kind: WatchRead, From: 0x400, To: 0x3FF}))
+	assert.Error(t, w.Add(Watchpoint{Kind: WatchRead, From: 0x400, To: 0x10000}))
+
+	assert.True(t, w.Watched(0x300))
+	assert.True(t, w.Watched(0x3FF))
+	assert.False(t, w.Watched(0x400))
+
+	w.Read(0x800, 0x300, 0x12)
+	assert.Empty(t, w.Hits())
+
+	w.Write(0x800, 0x310, 0x12, 0x12)
+	hits := w.Hits()
+	assert.Len(t, hits, 1)
+	assert.Equal(t, "write 0300-03FF: $0310 written by $0800 ($12 -> $12)", hits[0].String())
+	assert.Empty(t, w.Hits())
+
+	assert.Equal(t, 1, w.Remove(0x300, 0x3FF))
+	assert.Empty(t, w.List())
+	assert.False(t, w.Watched(0x300))
+}
+
+func TestWatchpointsSoftSwitch(t *testing.T) {
+	w := NewWatchpoints()
+	require.NoError(t, w.Add(Watchpoint{Kind: WatchChange, From: 0xC054, To: 0xC055}))
+
+	sm := NewSoftMap(0x10000)
+	sm.UseWatchpoints(w, 0xC000, 0xC100)
+	sm.SetRead(0xC054, func(int, *StateMap) uint8 { return 0x80 })
+	sm.SetRead(0x2000, func(int, *StateMap) uint8 { return 0x00 })
+
+	val, ok := sm.Read(0xC054)
+	assert.True(t, ok)
+	w.Read(0x800, 0xC054, val)
+
+	hits := w.Hits()
+	assert.Len(t, hits, 1)
+	assert.True(t, hits[0].SoftSwitch)
+	assert.Equal(t, "change C054-C055: $C054 read by $0800 (soft switch, $80)", hits[0].String())
+
+	// A read of memory doesn't trigger a change watchpoint
+	w.Read(0x800, 0xC055, 0x00)
+	assert.Empty(t, w.Hits())
+
+	// Nor does a read of memory that the softmap picks the segment for
+	require.NoError(t, w.Add(Watchpoint{Kind: WatchChange, From: 0x2000, To: 0x2000}))
+	val, ok = sm.Read(0x2000)
+	assert.True(t, ok)
+	w.Read(0x800, 0x2000, val)
+	assert.Empty(t, w.Hits())
+}
@@ -2,22 +2,60 @@ package mos
 
 // Get will return the byte at a given address.
 func (c *CPU) Get(addr uint16) uint8 {
-	return c.RMem.Get(int(addr))
+	val := c.RMem.Get(int(addr))
+	c.access(addr, val, false, false)
+
+	if c.watching(addr) {
+		c.Watch.Read(int(c.LastPC), int(addr), val)
+	}
+
+	return val
 }
 
 // Set will set the byte at a given address to the given value.
 func (c *CPU) Set(addr uint16, val uint8) {
+	var (
+		watched = c.watching(addr)
+		old     uint8
+	)
+
+	if watched {
+		old = c.directGet(addr)
+	}
+
+	c.recordWrite(addr)
 	c.WMem.Set(int(addr), val)
+	c.access(addr, val, true, false)
+
+	if watched {
+		c.Watch.Write(int(c.LastPC), int(addr), old, val)
+	}
 }
 
 // Get16 returns a 16-bit value at a given address, which is read in
-// little-endian order.
+// little-endian order. This is two reads on the bus, so two cycles.
 func (c *CPU) Get16(addr uint16) uint16 {
-	return c.RMem.Get16(int(addr))
+	lsb := c.Get(addr)
+	msb := c.Get(addr + 1)
+
+	return (uint16(msb) << 8) | uint16(lsb)
 }
 
 // Set16 sets the two bytes beginning at the given address to the given value.
 // The bytes are set in little-endian order.
 func (c *CPU) Set16(addr uint16, val uint16) {
-	c.WMem.Set16(int(addr), val)
+	c.Set(addr, uint8(val))
+	c.Set(addr+1, uint8(val>>8))
+}
+
+// peek returns the byte at a given address without it counting as an access
+// on the bus. This is for when we want to look at memory for our own reasons
+// (e.g. to speculate on what code we might run).
+func (c *CPU) peek(addr uint16) uint8 {
+	return c.RMem.Get(int(addr))
+}
+
+// peek16 is the 16-bit version of peek.
+func (c *CPU) peek16(addr uint16) uint16 {
+	return c.RMem.Get16(int(addr))
 }
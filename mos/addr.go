@@ -37,6 +37,7 @@ const (
 	AmZPI        // zero page indirect
 	AmZPX        // zero page x-index
 	AmZPY        // zero page y-index
+	AmZPR        // zero page relative (for BBR and BBS)
 )
 
 // addrModeNames maps address mode constants to their string representations
@@ -57,6 +58,7 @@ var addrModeNames = map[int]string{
 	AmZPI: "ZPI",
 	AmZPX: "ZPX",
 	AmZPY: "ZPY",
+	AmZPR: "ZPR",
 }
 
 // AddrModeName returns the string representation of an address mode
@@ -80,18 +82,11 @@ func (m AddrMode) String() string {
 
 // OpcodeAddrMode returns the address mode constant for a given opcode
 func OpcodeAddrMode(opcode uint8) int {
-	return addrModes[opcode]
+	return CMOS65C02.AddrMode(opcode)
 }
 
 func OperandSize(opcode uint8) uint16 {
-	switch addrModes[opcode] {
-	case AmABS, AmABX, AmABY, AmIND:
-		return 2
-	case AmACC, AmBY2, AmBY3, AmIMP:
-		return 0
-	}
-
-	return 1
+	return CMOS65C02.OperandSize(opcode)
 }
 
 // resolveEffVal reads the byte at c.EffAddr into c.EffVal, but only if the
@@ -100,7 +95,7 @@ func OperandSize(opcode uint8) uint16 {
 // skipping the read prevents address-mode resolution from accidentally
 // triggering soft-switch side effects.
 func resolveEffVal(c *CPU) {
-	if c.State.Bool(a2state.InstructionReadOp) {
+	if c.ReadOp {
 		c.EffVal = c.Get(c.EffAddr)
 	}
 }
@@ -116,6 +111,17 @@ func Abs(c *CPU) {
 	resolveEffVal(c)
 }
 
+// Abj resolves the absolute address mode the way that JSR does, which is in
+// a strange order: the processor reads the low byte of its operand, spends
+// three cycles with the stack, and only then reads the high byte. We look
+// ahead at the high byte so that we know where we're going, but it's Jsr
+// that reads it.
+func Abj(c *CPU) {
+	c.AddrMode = AmABS
+	c.EffAddr = uint16(c.Get(c.PC+1)) | (uint16(c.peek(c.PC+2)) << 8)
+	c.Operand = c.EffAddr
+}
+
 // Abx resolves Absolute X address mode, which is like Absolute mode but adds
 // the X register content to the operand.
 //
@@ -123,15 +129,17 @@ func Abs(c *CPU) {
 func Abx(c *CPU) {
 	c.AddrMode = AmABX
 
-	// Indexed instructions can cause _false reads_ when done across page
-	// boundaries, so we simulate that here.
+	// Indexed instructions can cause _false reads_ (see indexDummyRead),
+	// which the language card counts as a read of its soft switches.
 	if !c.State.Bool(a2state.DebuggerLookAhead) {
-		c.State.SetBool(a2state.InstructionReadOp, true)
-		c.State.SetInt(a2state.BankReadAttempts, 1)
+		if c.State.Int(a2state.BankReadAttempts) != 1 {
+			c.State.SetInt(a2state.BankReadAttempts, 1)
+		}
 	}
 
 	c.Operand = c.Get16(c.PC + 1)
 	c.EffAddr = c.Operand + uint16(c.X)
+	c.indexDummyRead(c.Operand, c.PC+2)
 	resolveEffVal(c)
 }
 
@@ -141,15 +149,17 @@ func Abx(c *CPU) {
 func Aby(c *CPU) {
 	c.AddrMode = AmABY
 
-	// Indexed instructions can cause _false reads_ when done across page
-	// boundaries, so we simulate that here.
+	// Indexed instructions can cause _false reads_ (see indexDummyRead),
+	// which the language card counts as a read of its soft switches.
 	if !c.State.Bool(a2state.DebuggerLookAhead) {
-		c.State.SetBool(a2state.InstructionReadOp, true)
-		c.State.SetInt(a2state.BankReadAttempts, 1)
+		if c.State.Int(a2state.BankReadAttempts) != 1 {
+			c.State.SetInt(a2state.BankReadAttempts, 1)
+		}
 	}
 
 	c.Operand = c.Get16(c.PC + 1)
 	c.EffAddr = c.Operand + uint16(c.Y)
+	c.indexDummyRead(c.Operand, c.PC+2)
 	resolveEffVal(c)
 }
 
@@ -162,22 +172,51 @@ func Acc(c *CPU) {
 	c.EffVal = c.A
 	c.EffAddr = 0
 	c.Operand = 0
+	c.impliedDummyRead()
 }
 
-// By2 is a placeholder mode
+// By2 is a placeholder mode, for the 65C02 NOPs that take a one-byte
+// operand. These don't do anything with it, but they do spend the cycles
+// that the address mode they were decoded as would spend: the two-cycle ones
+// are immediate, the three-cycle one is zero page, and the four-cycle ones
+// are zero page X.
 func By2(c *CPU) {
 	c.AddrMode = AmBY2
 	c.EffAddr = 0
 	c.EffVal = 0
 	c.Operand = 0
+
+	operand := c.Get(c.PC + 1)
+
+	switch c.Variant.op(c.opcode).cycles {
+	case 3:
+		c.dummyRead(uint16(operand))
+	case 4:
+		c.zeroPageDummyRead(uint16(operand))
+		c.dummyRead(uint16(operand + c.X))
+	}
 }
 
-// By3 is a placeholder mode
+// By3 is a placeholder mode, for the 65C02 NOPs that take a two-byte
+// operand. Like By2, they spend the cycles of another address mode: the
+// four-cycle ones are absolute, and $5C spends five more cycles reading from
+// the top page of memory.
 func By3(c *CPU) {
 	c.AddrMode = AmBY3
 	c.EffAddr = 0
 	c.EffVal = 0
 	c.Operand = 0
+
+	operand := c.Get16(c.PC + 1)
+
+	switch c.Variant.op(c.opcode).cycles {
+	case 4:
+		c.dummyRead(operand)
+	case 8:
+		for range 5 {
+			c.dummyRead(0xFF00 | (operand & 0x00FF))
+		}
+	}
 }
 
 // Imm resolves Immediate address mode. The operand is the literal effective
@@ -199,6 +238,17 @@ func Imp(c *CPU) {
 	c.EffVal = 0
 	c.EffAddr = 0
 	c.Operand = 0
+	c.impliedDummyRead()
+}
+
+// impliedDummyRead makes the dummy read of an instruction without an
+// operand. The CPU always reads the byte after the opcode, whether it needs
+// it or not. The exceptions are the one-cycle NOPs of the 65C02, which
+// don't spend a cycle on anything but the opcode.
+func (c *CPU) impliedDummyRead() {
+	if c.Variant.op(c.opcode).cycles > 1 {
+		c.dummyRead(c.PC + 1)
+	}
 }
 
 // Ind resolves the indirect address mode. If you can imagine that the ABS
@@ -209,14 +259,52 @@ func Imp(c *CPU) {
 func Ind(c *CPU) {
 	c.AddrMode = AmIND
 
+	c.Operand = c.Get16(c.PC + 1)
+
+	// The 65C02 fixed the page-wrap bug of the 6502 (see Inw), but it spends
+	// a cycle doing so, during which it reads the high byte of the operand
+	// again.
+	c.dummyRead(c.PC + 2)
+
 	// The inner part of the operand `$NNNN` is the address of... yet another
 	// address; so we derefence that `($NNNN)` to get the value.
-	c.Operand = c.Get16(c.PC + 1)
 	c.EffAddr = c.Get16(c.Operand)
+
 	resolveEffVal(c)
+}
+
+// Iax resolves the absolute indexed indirect address mode, which only JMP
+// ($7C) uses, and only on the 65C02. It's like Ind, except that we add X to
+// the operand before we dereference it.
+//
+// Ex. JMP ($1234,X) jumps to the address found at $1234 + X.
+func Iax(c *CPU) {
+	c.AddrMode = AmABX
+	c.Operand = c.Get16(c.PC + 1)
+
+	// The processor spends a cycle adding X, in which it reads the high
+	// byte of the operand again
+	c.dummyRead(c.PC + 2)
+
+	c.EffAddr = c.Get16(c.Operand + uint16(c.X))
+}
+
+// Inw resolves the indirect address mode the way the NMOS 6502 does, which
+// is with a bug: the processor never carries into the high byte of the
+// pointer's address. So if the operand ends in $FF, the high byte of the
+// effective address is read from the start of the same page.
+//
+// Ex. JMP ($12FF) jumps to the address made from $12FF (low) and $1200
+// (high), rather than $1300.
+func Inw(c *CPU) {
+	c.AddrMode = AmIND
+	c.Operand = c.Get16(c.PC + 1)
 
-	// TODO: according to AppleWin, we should be adding a cycle of complexity
-	// if Operand ends in 0xFF
+	lsb := uint16(c.Get(c.Operand))
+	msb := uint16(c.Get((c.Operand & 0xFF00) | ((c.Operand + 1) & 0x00FF)))
+
+	c.EffAddr = (msb << 8) | lsb
+	resolveEffVal(c)
 }
 
 // Idx resolves the indexed indirect address mode, which resolves to a zero
@@ -234,16 +322,11 @@ func Idx(c *CPU) {
 
 	operand := c.Get(c.PC + 1)
 	c.Operand = uint16(operand)
-
-	plusX := uint16(operand+c.X) & 0xFF
-	if plusX == 0xFF {
-		c.EffAddr = (uint16(c.Get(0)) << 8) | uint16(c.Get(0xFF))
-	} else {
-		c.EffAddr = c.Get16(plusX)
-	}
+	c.zeroPageDummyRead(c.Operand)
 
 	// Our effective address is the dereferenced value found at the base
 	// address.
+	c.EffAddr = zpDeref(c, uint16(operand+c.X))
 
 	resolveEffVal(c)
 }
@@ -252,7 +335,8 @@ func Idx(c *CPU) {
 // zero-page address, wrapping correctly at the zero-page boundary ($FF/$00).
 func zpDeref(c *CPU, addr uint16) uint16 {
 	if addr == 0xFF {
-		return (uint16(c.Get(0)) << 8) | uint16(c.Get(0xFF))
+		lsb := uint16(c.Get(0xFF))
+		return (uint16(c.Get(0)) << 8) | lsb
 	}
 	return c.Get16(addr)
 }
@@ -267,7 +351,9 @@ func zpDeref(c *CPU, addr uint16) uint16 {
 func Idy(c *CPU) {
 	c.AddrMode = AmIDY
 	c.Operand = uint16(c.Get(c.PC + 1))
-	c.EffAddr = zpDeref(c, c.Operand) + uint16(c.Y)
+	base := zpDeref(c, c.Operand)
+	c.EffAddr = base + uint16(c.Y)
+	c.indexDummyRead(base, c.PC+1)
 	resolveEffVal(c)
 }
 
@@ -327,6 +413,7 @@ func Zpx(c *CPU) {
 	c.AddrMode = AmZPX
 	operand := c.Get(c.PC + 1)
 	c.Operand = uint16(operand)
+	c.zeroPageDummyRead(c.Operand)
 	c.EffAddr = uint16(operand+c.X) & 0xFF
 	resolveEffVal(c)
 }
@@ -339,6 +426,7 @@ func Zpy(c *CPU) {
 	c.AddrMode = AmZPY
 	operand := c.Get(c.PC + 1)
 	c.Operand = uint16(operand)
+	c.zeroPageDummyRead(c.Operand)
 	c.EffAddr = uint16(operand+c.Y) & 0xFF
 	resolveEffVal(c)
 }
@@ -355,3 +443,32 @@ func Zpi(c *CPU) {
 	c.EffAddr = zpDeref(c, c.Operand)
 	resolveEffVal(c)
 }
+
+// Zpr resolves the zero page relative address mode, which is only used by the
+// BBR and BBS instructions of the Rockwell and WDC 65C02. The operand is two
+// bytes: a zero page address, whose byte is our effective value, and then a
+// signed offset like the one in REL mode. The effective address is where we
+// would branch to.
+//
+// Ex. BBR0 $12,NEXT branches to NEXT if bit 0 of the byte at $12 is clear.
+func Zpr(c *CPU) {
+	c.AddrMode = AmZPR
+
+	// We read the zero page byte (twice, as the processor does) before we
+	// fetch the offset
+	zp := uint16(c.Get(c.PC + 1))
+	c.EffVal = c.Get(zp)
+	c.dummyRead(zp)
+
+	c.Operand = zp | (uint16(c.Get(c.PC+2)) << 8)
+
+	// Like Rel, except that our instruction is three bytes long
+	offset := c.Operand >> 8
+	addr := c.PC + offset + 3
+
+	if offset > 127 {
+		addr -= 256
+	}
+
+	c.EffAddr = addr
+}
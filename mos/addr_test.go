@@ -3,7 +3,6 @@ package mos_test
 import (
 	"testing"
 
-	"github.com/pevans/erc/a2/a2state"
 	"github.com/pevans/erc/memory"
 	"github.com/pevans/erc/mos"
 	"github.com/stretchr/testify/suite"
@@ -23,8 +22,8 @@ func (s *mosSuite) SetupTest() {
 	s.cpu.WMem = seg
 
 	// Address mode tests generally expect EffVal to be populated, which
-	// requires InstructionReadOp to be true.
-	s.cpu.State.SetBool(a2state.InstructionReadOp, true)
+	// requires ReadOp to be true.
+	s.cpu.ReadOp = true
 }
 
 func TestMosSuite(t *testing.T) {
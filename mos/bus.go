@@ -0,0 +1,116 @@
+package mos
+
+import "github.com/pevans/erc/a2/a2state"
+
+// A BusAccess is a single read or write that the CPU makes on the bus.
+type BusAccess struct {
+	// Cycle is the cycle (as CycleCounter would count it) on which the
+	// access happened.
+	Cycle uint64
+
+	Addr  uint16
+	Val   uint8
+	Write bool
+
+	// Dummy is true if the CPU made the access as a side effect of how it
+	// works, rather than because it cared about the value (e.g. the extra
+	// read when an indexed address crosses a page boundary).
+	Dummy bool
+}
+
+// A Bus is something that wants to observe every access the CPU makes, on
+// the cycle that it makes it. Slot cards, the floating bus and disk timing
+// are all things that might care.
+type Bus interface {
+	Observe(access BusAccess)
+}
+
+// Buses is a set of buses that each observe every access.
+type Buses []Bus
+
+// Observe passes the access along to each bus in the set.
+func (bs Buses) Observe(access BusAccess) {
+	for _, b := range bs {
+		b.Observe(access)
+	}
+}
+
+// access records an access on the bus. While we're executing an instruction,
+// each access takes up a cycle; accesses made at any other time (say, by the
+// debugger) don't.
+func (c *CPU) access(addr uint16, val uint8, write, dummy bool) {
+	if !c.executing {
+		return
+	}
+
+	if c.Bus != nil {
+		c.Bus.Observe(BusAccess{
+			Cycle: c.cycleCounter + c.busCycle,
+			Addr:  addr,
+			Val:   val,
+			Write: write,
+			Dummy: dummy,
+		})
+	}
+
+	c.busCycle++
+}
+
+// dummyRead reads from the given address, and throws away what it gets. It's
+// still a read, though, so any soft switch at the address will notice it.
+// (Unless the debugger is only looking ahead, in which case we don't want to
+// touch anything.)
+func (c *CPU) dummyRead(addr uint16) {
+	if c.State.Bool(a2state.DebuggerLookAhead) {
+		return
+	}
+
+	val := c.RMem.Get(int(addr))
+	c.access(addr, val, false, true)
+
+	if c.watching(addr) {
+		c.Watch.Read(int(c.LastPC), int(addr), val)
+	}
+}
+
+// indexDummyRead makes the dummy read that happens when an indexed address
+// (base plus some index) crosses a page boundary, which is the cycle that
+// the CPU spends to fix the high byte of the address. Writes and
+// read-modify-write instructions (those without a page penalty) spend that
+// cycle whether the page was crossed or not. The NMOS 6502 reads from the
+// address it has before the fix; the 65C02 instead reads again from the last
+// byte of the instruction, which is at last.
+func (c *CPU) indexDummyRead(base, last uint16) {
+	crossed := base&0xFF00 != c.EffAddr&0xFF00
+
+	if !crossed && c.Variant.pagePenalty(c.opcode) {
+		return
+	}
+
+	if c.Variant == NMOS6502 {
+		c.dummyRead((base & 0xFF00) | (c.EffAddr & 0x00FF))
+		return
+	}
+
+	c.dummyRead(last)
+}
+
+// zeroPageDummyRead makes the dummy read of the cycle in which the CPU adds
+// an index to a zero page address. The NMOS 6502 reads from the zero page
+// address before the index is added; the 65C02 reads its operand again.
+func (c *CPU) zeroPageDummyRead(base uint16) {
+	if c.Variant == NMOS6502 {
+		c.dummyRead(base)
+		return
+	}
+
+	c.dummyRead(c.PC + 1)
+}
+
+// stackDummyRead makes the dummy read of the cycle in which the CPU
+// increments the stack register (before it pulls from the stack), or in
+// which it spends time with the stack (as JSR does). Either way, it reads
+// from wherever the stack is.
+func (c *CPU) stackDummyRead() {
+	c.dummyRead(c.stackAddr())
+}
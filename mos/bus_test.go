@@ -0,0 +1,302 @@
+package mos_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pevans/erc/mos"
+	"github.com/stretchr/testify/assert"
+)
+
+// busLog is a bus that remembers every access it observes.
+type busLog struct {
+	accesses []mos.BusAccess
+}
+
+func (b *busLog) Observe(access mos.BusAccess) {
+	b.accesses = append(b.accesses, access)
+}
+
+// dummies returns the addresses of the dummy accesses in the log.
+func (b *busLog) dummies() []uint16 {
+	var addrs []uint16
+
+	for _, a := range b.accesses {
+		if a.Dummy {
+			addrs = append(addrs, a.Addr)
+		}
+	}
+
+	return addrs
+}
+
+func TestBusCycles(t *testing.T) {
+	c, seg := newVariantCPU(mos.CMOS65C02)
+	seg.Set(0x300, 0xAD) // LDA $1234
+	seg.Set(0x301, 0x34)
+	seg.Set(0x302, 0x12)
+	seg.Set(0x1234, 0x56)
+	c.PC = 0x300
+
+	bus := new(busLog)
+	c.Bus = bus
+
+	assert.NoError(t, c.Execute())
+	assert.Equal(t, []mos.BusAccess{
+		{Cycle: 0, Addr: 0x300, Val: 0xAD},
+		{Cycle: 1, Addr: 0x301, Val: 0x34},
+		{Cycle: 2, Addr: 0x302, Val: 0x12},
+		{Cycle: 3, Addr: 0x1234, Val: 0x56},
+	}, bus.accesses)
+	assert.Equal(t, uint64(4), c.CycleCounter())
+
+	// Accesses made outside of an instruction aren't on the bus
+	c.Get(0x1234)
+	assert.Len(t, bus.accesses, 4)
+	assert.Equal(t, uint64(4), c.CycleCounter())
+}
+
+func TestBusDummyReads(t *testing.T) {
+	t.Run("indexed reads across a page", func(t *testing.T) {
+		for _, v := range []mos.Variant{mos.CMOS65C02, mos.NMOS6502} {
+			c, seg := newVariantCPU(v)
+			seg.Set(0x300, 0xBD) // LDA $20FF,X
+			seg.Set(0x301, 0xFF)
+			seg.Set(0x302, 0x20)
+			c.X = 1
+			c.PC = 0x300
+
+			bus := new(busLog)
+			c.Bus = bus
+
+			assert.NoError(t, c.Execute())
+
+			if v == mos.NMOS6502 {
+				assert.Equal(t, []uint16{0x2000}, bus.dummies())
+			} else {
+				assert.Equal(t, []uint16{0x302}, bus.dummies())
+			}
+		}
+	})
+
+	t.Run("indexed reads within a page", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.NMOS6502)
+		seg.Set(0x300, 0xB1) // LDA ($10),Y
+		seg.Set(0x301, 0x10)
+		seg.Set(0x10, 0x00)
+		seg.Set(0x11, 0x20)
+		c.Y = 1
+		c.PC = 0x300
+
+		bus := new(busLog)
+		c.Bus = bus
+
+		assert.NoError(t, c.Execute())
+		assert.Empty(t, bus.dummies())
+	})
+
+	t.Run("NMOS indexed stores always read first", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.NMOS6502)
+		seg.Set(0x300, 0x9D) // STA $2000,X
+		seg.Set(0x301, 0x00)
+		seg.Set(0x302, 0x20)
+		c.X = 1
+		c.PC = 0x300
+
+		bus := new(busLog)
+		c.Bus = bus
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, []uint16{0x2001}, bus.dummies())
+	})
+
+	t.Run("read-modify-write reads twice on the 65C02", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.CMOS65C02)
+		seg.Set(0x300, 0xEE) // INC $2000
+		seg.Set(0x301, 0x00)
+		seg.Set(0x302, 0x20)
+		c.PC = 0x300
+
+		bus := new(busLog)
+		c.Bus = bus
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, []uint16{0x2000}, bus.dummies())
+
+		last := bus.accesses[len(bus.accesses)-1]
+		assert.True(t, last.Write)
+		assert.Equal(t, uint8(1), last.Val)
+	})
+
+	t.Run("RMB and SMB read twice before they write", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.WDC65C02)
+		seg.Set(0x300, 0x87) // SMB0 $10
+		seg.Set(0x301, 0x10)
+		c.PC = 0x300
+
+		bus := new(busLog)
+		c.Bus = bus
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, []uint16{0x10}, bus.dummies())
+
+		last := bus.accesses[len(bus.accesses)-1]
+		assert.True(t, last.Write)
+		assert.Equal(t, uint8(1), last.Val)
+	})
+
+	t.Run("JMP indirect reads its operand again on the 65C02", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.CMOS65C02)
+		seg.Set(0x300, 0x6C) // JMP ($1234)
+		seg.Set(0x301, 0x34)
+		seg.Set(0x302, 0x12)
+		c.PC = 0x300
+
+		bus := new(busLog)
+		c.Bus = bus
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, []uint16{0x302}, bus.dummies())
+	})
+}
+
+// TestBusAccessPerCycle checks that every opcode, in each variant, makes one
+// access on the bus for each cycle that it takes, and that the accesses are
+// numbered by the cycle they happen on.
+func TestBusAccessPerCycle(t *testing.T) {
+	// These are the unstable stores of the NMOS 6502, which we treat as
+	// NOPs, and so never make the write they would end with
+	unstable := map[uint8]bool{0x93: true, 0x9B: true, 0x9C: true, 0x9E: true, 0x9F: true}
+
+	for _, v := range []mos.Variant{mos.CMOS65C02, mos.NMOS6502, mos.WDC65C02} {
+		for op := range 256 {
+			opcode := uint8(op)
+			if v == mos.NMOS6502 && unstable[opcode] {
+				continue
+			}
+
+			// We try each opcode with and without an index that crosses a
+			// page, with and without the flags that branches look at, and
+			// in decimal mode
+			for _, index := range []uint8{0, 0xFF} {
+				for _, p := range []uint8{0, mos.NEGATIVE | mos.OVERFLOW | mos.ZERO | mos.CARRY, mos.DECIMAL} {
+					c, seg := newVariantCPU(v)
+					for addr := range 0x10000 {
+						seg.Set(addr, 0x80)
+					}
+
+					seg.Set(0x300, opcode)
+					c.PC = 0x300
+					c.X, c.Y = index, index
+					c.P = p
+					c.S = 0xFF
+
+					bus := new(busLog)
+					c.Bus = bus
+
+					assert.NoError(t, c.Execute())
+
+					name := fmt.Sprintf("%v $%02X (%v) X=$%02X P=$%02X", v, opcode,
+						v.InstructionName(opcode), index, p)
+
+					if !assert.Len(t, bus.accesses, int(c.CycleCounter()), name) {
+						continue
+					}
+
+					for i, a := range bus.accesses {
+						assert.Equal(t, uint64(i), a.Cycle, name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBusSequences(t *testing.T) {
+	type access struct {
+		addr  uint16
+		write bool
+	}
+
+	// sequence executes the program at $300 and returns the accesses it
+	// makes, in order
+	sequence := func(v mos.Variant, prog ...uint8) []access {
+		c, seg := newVariantCPU(v)
+		for i, b := range prog {
+			seg.Set(0x300+i, b)
+		}
+
+		c.PC = 0x300
+		c.S = 0xF0
+
+		bus := new(busLog)
+		c.Bus = bus
+
+		assert.NoError(t, c.Execute())
+
+		var accesses []access
+		for _, a := range bus.accesses {
+			accesses = append(accesses, access{a.Addr, a.Write})
+		}
+
+		return accesses
+	}
+
+	t.Run("implied", func(t *testing.T) {
+		assert.Equal(t, []access{{0x300, false}, {0x301, false}},
+			sequence(mos.CMOS65C02, 0xE8)) // INX
+	})
+
+	t.Run("push and pull", func(t *testing.T) {
+		assert.Equal(t, []access{{0x300, false}, {0x301, false}, {0x1F0, true}},
+			sequence(mos.CMOS65C02, 0x48)) // PHA
+		assert.Equal(t, []access{{0x300, false}, {0x301, false}, {0x1F0, false}, {0x1F1, false}},
+			sequence(mos.CMOS65C02, 0x68)) // PLA
+	})
+
+	t.Run("JSR", func(t *testing.T) {
+		assert.Equal(t, []access{
+			{0x300, false}, {0x301, false}, {0x1F0, false},
+			{0x1F0, true}, {0x1EF, true}, {0x302, false},
+		}, sequence(mos.NMOS6502, 0x20, 0x00, 0x20)) // JSR $2000
+	})
+
+	t.Run("RTS", func(t *testing.T) {
+		assert.Equal(t, []access{
+			{0x300, false}, {0x301, false}, {0x1F0, false},
+			{0x1F1, false}, {0x1F2, false}, {0x0000, false},
+		}, sequence(mos.NMOS6502, 0x60))
+	})
+
+	t.Run("RTI", func(t *testing.T) {
+		assert.Equal(t, []access{
+			{0x300, false}, {0x301, false}, {0x1F0, false},
+			{0x1F1, false}, {0x1F2, false}, {0x1F3, false},
+		}, sequence(mos.CMOS65C02, 0x40))
+	})
+
+	t.Run("zero page indexed", func(t *testing.T) {
+		assert.Equal(t, []access{{0x300, false}, {0x301, false}, {0x10, false}, {0x10, false}},
+			sequence(mos.NMOS6502, 0xB5, 0x10)) // LDA $10,X
+		assert.Equal(t, []access{{0x300, false}, {0x301, false}, {0x301, false}, {0x10, false}},
+			sequence(mos.CMOS65C02, 0xB5, 0x10))
+	})
+
+	t.Run("65C02 indexed store within a page", func(t *testing.T) {
+		assert.Equal(t, []access{{0x300, false}, {0x301, false}, {0x302, false}, {0x302, false}, {0x2000, true}},
+			sequence(mos.CMOS65C02, 0x9D, 0x00, 0x20)) // STA $2000,X
+	})
+
+	t.Run("taken branch across a page", func(t *testing.T) {
+		// BRA -$12 goes from $302 back to $2F0
+		assert.Equal(t, []access{{0x300, false}, {0x301, false}, {0x302, false}, {0x3F0, false}},
+			sequence(mos.CMOS65C02, 0x80, 0xEE))
+	})
+
+	t.Run("JMP indexed indirect", func(t *testing.T) {
+		assert.Equal(t, []access{
+			{0x300, false}, {0x301, false}, {0x302, false},
+			{0x302, false}, {0x2000, false}, {0x2001, false},
+		}, sequence(mos.CMOS65C02, 0x7C, 0x00, 0x20)) // JMP ($2000,X)
+	})
+}
@@ -0,0 +1,80 @@
+package mos
+
+// opJSR is the opcode of JSR, which is how subroutines are called.
+const opJSR = 0x20
+
+// A Call is a subroutine that the CPU went to with JSR, and has not yet
+// returned from.
+type Call struct {
+	// From is the address of the JSR, and To is the address it went to.
+	From, To uint16
+
+	// S is what the stack pointer was before the call. The call has
+	// returned once the stack pointer is back there.
+	S uint8
+}
+
+// Pushed returns the address that the JSR pushed onto the stack, which is
+// that of its last byte.
+func (c Call) Pushed() uint16 {
+	return c.From + 2
+}
+
+// Return returns the address that the call will return to, which is that
+// of the instruction after the JSR.
+func (c Call) Return() uint16 {
+	return c.From + 3
+}
+
+// A CallStack keeps track of the calls that the CPU has made and not yet
+// returned from. We don't watch for RTS as such; a call has returned
+// when the stack pointer goes back to where it was before the call, which
+// also covers code that pulls the return address off the stack itself, or
+// resets the stack pointer.
+type CallStack struct {
+	calls []Call
+}
+
+// NewCallStack returns a call stack with no calls in it.
+func NewCallStack() *CallStack {
+	return &CallStack{}
+}
+
+// Depth returns the number of calls that haven't returned.
+func (s *CallStack) Depth() int {
+	return len(s.calls)
+}
+
+// Calls returns the calls that haven't returned, from the oldest to the
+// newest.
+func (s *CallStack) Calls() []Call {
+	return append([]Call(nil), s.calls...)
+}
+
+// Reset forgets every call, as when the computer is reset.
+func (s *CallStack) Reset() {
+	s.calls = s.calls[:0]
+}
+
+// unwind removes the calls that have returned, given that the stack
+// pointer is now sp.
+func (s *CallStack) unwind(sp uint8) {
+	for len(s.calls) > 0 && s.calls[len(s.calls)-1].S <= sp {
+		s.calls = s.calls[:len(s.calls)-1]
+	}
+}
+
+// trackCall updates the call stack, if we have one, for the instruction we
+// just executed.
+func (c *CPU) trackCall() {
+	if c.Calls == nil {
+		return
+	}
+
+	if c.opcode == opJSR {
+		c.Calls.calls = append(c.Calls.calls, Call{From: c.LastPC, To: c.PC, S: c.S + 2})
+		return
+	}
+
+	c.Calls.unwind(c.S)
+}
@@ -0,0 +1,87 @@
+package mos_test
+
+import (
+	"testing"
+
+	"github.com/pevans/erc/mos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallStack(t *testing.T) {
+	c, seg := newVariantCPU(mos.CMOS65C02)
+	c.Calls = mos.NewCallStack()
+	c.S = 0xFF
+
+	seg.Set(0x300, 0x20) // JSR $0400
+	seg.Set(0x301, 0x00)
+	seg.Set(0x302, 0x04)
+	seg.Set(0x400, 0x48) // PHA
+	seg.Set(0x401, 0x20) // JSR $0500
+	seg.Set(0x402, 0x00)
+	seg.Set(0x403, 0x05)
+	seg.Set(0x500, 0x60) // RTS
+
+	c.PC = 0x300
+	for range 3 {
+		require.NoError(t, c.Execute())
+	}
+
+	calls := c.Calls.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, mos.Call{From: 0x300, To: 0x400, S: 0xFF}, calls[0])
+	assert.Equal(t, mos.Call{From: 0x401, To: 0x500, S: 0xFC}, calls[1])
+	assert.Equal(t, uint16(0x302), calls[0].Pushed())
+	assert.Equal(t, uint16(0x303), calls[0].Return())
+
+	// RTS returns from the inner call, but not from the outer one, which
+	// still has A pushed on top of its return address
+	require.NoError(t, c.Execute())
+	assert.Equal(t, 1, c.Calls.Depth())
+	assert.Equal(t, uint16(0x404), c.PC)
+
+	c.Calls.Reset()
+	assert.Equal(t, 0, c.Calls.Depth())
+}
+
+func TestCallStackPull(t *testing.T) {
+	c, seg := newVariantCPU(mos.CMOS65C02)
+	c.Calls = mos.NewCallStack()
+	c.S = 0xFF
+
+	seg.Set(0x300, 0x20) // JSR $0400
+	seg.Set(0x301, 0x00)
+	seg.Set(0x302, 0x04)
+	seg.Set(0x400, 0x68) // PLA
+	seg.Set(0x401, 0x68) // PLA
+
+	c.PC = 0x300
+	require.NoError(t, c.Execute())
+	require.NoError(t, c.Execute())
+	assert.Equal(t, 1, c.Calls.Depth())
+
+	// Once the return address is pulled off the stack, the call is over
+	require.NoError(t, c.Execute())
+	assert.Equal(t, 0, c.Calls.Depth())
+}
+
+func TestCallStackBack(t *testing.T) {
+	c, seg := newVariantCPU(mos.CMOS65C02)
+	c.Calls = mos.NewCallStack()
+	c.History = mos.NewHistory(10)
+	c.S = 0xFF
+
+	seg.Set(0x300, 0x20) // JSR $0400
+	seg.Set(0x301, 0x00)
+	seg.Set(0x302, 0x04)
+	seg.Set(0x400, 0xEA) // NOP
+
+	c.PC = 0x300
+	require.NoError(t, c.Execute())
+	require.NoError(t, c.Execute())
+	assert.Equal(t, 1, c.Calls.Depth())
+
+	// Going back to before the JSR forgets the call
+	assert.Equal(t, 2, c.Back(2))
+	assert.Equal(t, 0, c.Calls.Depth())
+}
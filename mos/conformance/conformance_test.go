@@ -0,0 +1,218 @@
+package conformance_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pevans/erc/mos"
+	"github.com/pevans/erc/mos/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The reference tests are large, and aren't kept in this repository. To run
+// them, set ERC_CONFORMANCE_DIR to a directory laid out like so:
+//
+//	6502/                             single-step tests for the NMOS 6502
+//	synertek65c02/                    ...for the 65C02 (without bit ops)
+//	wdc65c02/                         ...for the WDC 65C02
+//	6502_functional_test.bin          Klaus Dormann's functional test
+//	65C02_extended_opcodes_test.bin   ...and his 65C02 extended test
+//	6502_decimal_test.bin             ...and his decimal test
+//
+// Any of these that are missing are skipped.
+const conformanceDirEnv = "ERC_CONFORMANCE_DIR"
+
+// maxProblems is the number of failing single-step tests we report for each
+// opcode before we move on (since one bug can fail thousands of them).
+const maxProblems = 5
+
+func conformanceDir(t *testing.T) string {
+	dir := os.Getenv(conformanceDirEnv)
+	if dir == "" {
+		t.Skipf("%s is not set", conformanceDirEnv)
+	}
+
+	return dir
+}
+
+func TestSingleSteps(t *testing.T) {
+	dir := conformanceDir(t)
+
+	suites := []struct {
+		dir     string
+		variant mos.Variant
+
+		// skip are the opcodes that we don't try to emulate exactly
+		skip []string
+	}{
+		{
+			dir:     "6502",
+			variant: mos.NMOS6502,
+			skip: []string{
+				// JAM
+				"02", "12", "22", "32", "42", "52", "62", "72", "92", "b2",
+				"d2", "f2",
+				// The unstable opcodes, which we treat as NOPs
+				"8b", "93", "9b", "9c", "9e", "9f", "ab",
+			},
+		},
+		{dir: "synertek65c02", variant: mos.CMOS65C02},
+		{
+			dir:     "wdc65c02",
+			variant: mos.WDC65C02,
+			skip:    []string{"cb", "db"}, // WAI and STP
+		},
+	}
+
+	for _, suite := range suites {
+		t.Run(suite.dir, func(t *testing.T) {
+			files, _ := filepath.Glob(filepath.Join(dir, suite.dir, "*.json"))
+			if len(files) == 0 {
+				t.Skipf("no tests in %s", suite.dir)
+			}
+
+			skip := make(map[string]bool)
+			for _, op := range suite.skip {
+				skip[op+".json"] = true
+			}
+
+			for _, file := range files {
+				if skip[filepath.Base(file)] {
+					continue
+				}
+
+				t.Run(filepath.Base(file), func(t *testing.T) {
+					tests, err := conformance.LoadSingleSteps(file)
+					require.NoError(t, err)
+
+					failed := 0
+					for _, test := range tests {
+						problems := test.Run(suite.variant)
+						if len(problems) == 0 {
+							continue
+						}
+
+						t.Errorf("%s: %v", test.Name, problems)
+
+						if failed++; failed >= maxProblems {
+							return
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestPrograms(t *testing.T) {
+	dir := conformanceDir(t)
+
+	// The success addresses are those of the binaries that Klaus Dormann
+	// ships with his tests. The decimal test should be assembled for the
+	// 65C02 (cputype = 1), which ends it with STP; if it passed, it leaves
+	// zero in ERROR ($0B).
+	programs := []struct {
+		file    string
+		variant mos.Variant
+		origin  uint16
+		start   uint16
+		success uint16
+	}{
+		{"6502_functional_test.bin", mos.NMOS6502, 0, 0x400, 0x3469},
+		{"6502_functional_test.bin", mos.CMOS65C02, 0, 0x400, 0x3469},
+		{"65C02_extended_opcodes_test.bin", mos.WDC65C02, 0, 0x400, 0x24F1},
+		{"6502_decimal_test.bin", mos.WDC65C02, 0x200, 0x200, 0},
+	}
+
+	for _, prog := range programs {
+		t.Run(prog.file+"/"+prog.variant.String(), func(t *testing.T) {
+			file := filepath.Join(dir, prog.file)
+			if _, err := os.Stat(file); err != nil {
+				t.Skipf("%s is missing", prog.file)
+			}
+
+			p, err := conformance.LoadProgram(file, prog.origin, prog.start)
+			require.NoError(t, err)
+
+			m, err := p.Run(prog.variant, 200_000_000)
+			require.NoError(t, err)
+
+			if prog.success != 0 {
+				assert.Equal(t, prog.success, m.CPU.PC, "trapped at the wrong place")
+				return
+			}
+
+			assert.Equal(t, uint8(0), m.RAM.Get(0x0B), "ERROR is set")
+		})
+	}
+}
+
+func TestSingleStepRun(t *testing.T) {
+	// This is how a test for LDA #$00 would look
+	data := `[{
+		"name": "a9 00",
+		"initial": {"pc": 512, "s": 253, "a": 1, "x": 0, "y": 0, "p": 36,
+			"ram": [[512, 169], [513, 0]]},
+		"final": {"pc": 514, "s": 253, "a": 0, "x": 0, "y": 0, "p": 38,
+			"ram": [[512, 169], [513, 0]]},
+		"cycles": [[512, 169, "read"], [513, 0, "read"]]
+	}]`
+
+	file := filepath.Join(t.TempDir(), "a9.json")
+	require.NoError(t, os.WriteFile(file, []byte(data), 0o644))
+
+	tests, err := conformance.LoadSingleSteps(file)
+	require.NoError(t, err)
+	require.Len(t, tests, 1)
+
+	assert.Empty(t, tests[0].Run(mos.CMOS65C02))
+
+	// The accesses have to happen in the order we expect them
+	test := tests[0]
+	test.Cycles = []conformance.StepBusOp{test.Cycles[1], test.Cycles[0]}
+	assert.Equal(t, []string{
+		"read of $A9 at $0200 on cycle 0, expected read of $00 at $0201 (expected ours on cycle 1)",
+		"read of $00 at $0201 on cycle 1, expected read of $A9 at $0200 (expected ours on cycle 0)",
+	}, test.Run(mos.CMOS65C02))
+
+	// And we should hear about any we didn't make
+	test = tests[0]
+	test.Cycles = append(test.Cycles, conformance.StepBusOp{Addr: 514, Val: 0})
+	assert.Equal(t, []string{
+		"took 2 cycles, expected 3",
+		"missing read of $00 at $0202 on cycle 2",
+	}, test.Run(mos.CMOS65C02))
+
+	// If we expect something else, we should hear about it
+	tests[0].Final.A = 1
+	tests[0].Cycles = tests[0].Cycles[:1]
+	assert.Equal(t, []string{
+		"A is $00, expected $01",
+		"took 2 cycles, expected 1",
+		"unexpected read of $00 at $0201 on cycle 1",
+	}, tests[0].Run(mos.CMOS65C02))
+}
+
+func TestProgramRun(t *testing.T) {
+	p := &conformance.Program{
+		Image: []byte{
+			0xA9, 0x42, // LDA #$42
+			0x85, 0x10, // STA $10
+			0x4C, 0x04, 0x02, // JMP *
+		},
+		Origin: 0x200,
+		Start:  0x200,
+	}
+
+	m, err := p.Run(mos.CMOS65C02, 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x204), m.CPU.PC)
+	assert.Equal(t, uint8(0x42), m.RAM.Get(0x10))
+
+	// A program that never traps should give up eventually
+	p.Image = []byte{0xEA, 0x4C, 0x00, 0x02} // NOP; JMP $0200
+	_, err = p.Run(mos.CMOS65C02, 100)
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+// Package conformance runs reference test suites against mos.CPU: the
+// per-opcode single-step tests (in the JSON format of Tom Harte's
+// ProcessorTests), and the functional and decimal test programs written by
+// Klaus Dormann. The tests themselves are not included here; they're loaded
+// from files on disk.
+package conformance
+
+import (
+	"github.com/pevans/erc/memory"
+	"github.com/pevans/erc/mos"
+)
+
+// A Machine is a CPU attached to 64k of flat RAM, with nothing else on the
+// bus (no soft switches, no ROM).
+type Machine struct {
+	CPU *mos.CPU
+	RAM *memory.Segment
+
+	// Log holds each access that the CPU has made on the bus.
+	Log []mos.BusAccess
+}
+
+// NewMachine returns a machine with a CPU of the given variant.
+func NewMachine(v mos.Variant) *Machine {
+	m := &Machine{
+		RAM: memory.NewSegment(0x10000),
+		CPU: new(mos.CPU),
+	}
+
+	m.CPU.Variant = v
+	m.CPU.RMem = m.RAM
+	m.CPU.WMem = m.RAM
+	m.CPU.State = memory.NewStateMap()
+	m.CPU.Bus = m
+
+	return m
+}
+
+// Observe records an access on the machine's bus log.
+func (m *Machine) Observe(access mos.BusAccess) {
+	m.Log = append(m.Log, access)
+}
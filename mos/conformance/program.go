@@ -0,0 +1,74 @@
+package conformance
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pevans/erc/mos"
+)
+
+// A Program is a test program, like Klaus Dormann's functional test, that
+// runs until it traps itself in a loop (e.g. with JMP *). Where it traps
+// tells you whether it passed or failed.
+type Program struct {
+	// Image is the binary image of the program, and Origin is the address at
+	// which it's loaded.
+	Image  []byte
+	Origin uint16
+
+	// Start is where execution begins.
+	Start uint16
+}
+
+// LoadProgram returns a program whose image is read from the given file.
+func LoadProgram(file string, origin, start uint16) (*Program, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file %s: %w", file, err)
+	}
+
+	if int(origin)+len(data) > 0x10000 {
+		return nil, fmt.Errorf(
+			"image %s is too large to load at $%04X", file, origin,
+		)
+	}
+
+	return &Program{
+		Image:  data,
+		Origin: origin,
+		Start:  start,
+	}, nil
+}
+
+// Run loads and executes the program with a CPU of the given variant until it
+// traps, and returns the machine as it was at that point. Some instructions
+// (like STP and JAM) count as traps, since they also leave the PC where it
+// is. If the program doesn't trap within maxCycles, an error is returned.
+func (p *Program) Run(v mos.Variant, maxCycles uint64) (*Machine, error) {
+	m := NewMachine(v)
+
+	// The programs run for many millions of cycles, and we don't care to
+	// keep a log of all of them
+	m.CPU.Bus = nil
+
+	for i, b := range p.Image {
+		m.RAM.Set(int(p.Origin)+i, b)
+	}
+
+	m.CPU.PC = p.Start
+
+	for m.CPU.CycleCounter() < maxCycles {
+		if err := m.CPU.Execute(); err != nil {
+			return m, err
+		}
+
+		if m.CPU.PC == m.CPU.LastPC {
+			return m, nil
+		}
+	}
+
+	return m, fmt.Errorf(
+		"program did not trap within %d cycles (PC is $%04X)",
+		maxCycles, m.CPU.PC,
+	)
+}
@@ -0,0 +1,231 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pevans/erc/mos"
+)
+
+// A SingleStep is a test of one instruction: given some initial state, the
+// CPU should execute one instruction and arrive at the final state, having
+// made the given accesses on the bus.
+type SingleStep struct {
+	Name    string      `json:"name"`
+	Initial StepState   `json:"initial"`
+	Final   StepState   `json:"final"`
+	Cycles  []StepBusOp `json:"cycles"`
+}
+
+// A StepState is the state of the registers and of some bytes of RAM.
+type StepState struct {
+	PC  uint16      `json:"pc"`
+	S   uint8       `json:"s"`
+	A   uint8       `json:"a"`
+	X   uint8       `json:"x"`
+	Y   uint8       `json:"y"`
+	P   uint8       `json:"p"`
+	RAM [][2]uint16 `json:"ram"`
+}
+
+// A StepBusOp is the access made on one cycle of a test.
+type StepBusOp struct {
+	Addr  uint16
+	Val   uint8
+	Write bool
+}
+
+// UnmarshalJSON decodes a bus op, which is written as an array of address,
+// value and kind of access (e.g. [512, 169, "read"]).
+func (op *StepBusOp) UnmarshalJSON(data []byte) error {
+	var (
+		fields []json.RawMessage
+		kind   string
+	)
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if len(fields) != 3 {
+		return fmt.Errorf("bus op has %d fields, expected 3", len(fields))
+	}
+
+	if err := json.Unmarshal(fields[0], &op.Addr); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(fields[1], &op.Val); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(fields[2], &kind); err != nil {
+		return err
+	}
+
+	op.Write = kind == "write"
+
+	return nil
+}
+
+// String returns a description of the access, like "read of $A9 at $0200".
+func (op StepBusOp) String() string {
+	kind := "read"
+	if op.Write {
+		kind = "write"
+	}
+
+	return fmt.Sprintf("%s of $%02X at $%04X", kind, op.Val, op.Addr)
+}
+
+// LoadSingleSteps returns the tests in the given file, which is a JSON array
+// of tests (this is usually all of the tests for one opcode).
+func LoadSingleSteps(file string) ([]SingleStep, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file %s: %w", file, err)
+	}
+
+	var tests []SingleStep
+	if err := json.Unmarshal(data, &tests); err != nil {
+		return nil, fmt.Errorf("could not parse file %s: %w", file, err)
+	}
+
+	return tests, nil
+}
+
+// Run executes the test with a CPU of the given variant, and returns a list
+// of the ways in which it didn't do what the test expected. If the list is
+// empty, the test passed.
+//
+// The B and unused bits of P aren't compared, since they aren't really part
+// of the register (the CPU only sets them in the copy of P that it pushes).
+//
+// The CPU makes one access on each cycle, so we compare the bus log with the
+// test cycle by cycle: each access must be the one the test expects, on the
+// cycle it expects it.
+func (t SingleStep) Run(v mos.Variant) []string {
+	m := NewMachine(v)
+	t.Initial.apply(m)
+
+	var problems []string
+
+	if err := m.CPU.Execute(); err != nil {
+		return []string{fmt.Sprintf("execute failed: %v", err)}
+	}
+
+	problems = append(problems, t.Final.compare(m)...)
+
+	if cycles := m.CPU.CycleCounter(); cycles != uint64(len(t.Cycles)) {
+		problems = append(problems, fmt.Sprintf(
+			"took %d cycles, expected %d", cycles, len(t.Cycles),
+		))
+	}
+
+	return append(problems, t.compareBus(m.Log)...)
+}
+
+// compareBus returns the ways in which the accesses in log differ from the
+// ones that the test expects.
+func (t SingleStep) compareBus(log []mos.BusAccess) []string {
+	var problems []string
+
+	for i := range max(len(log), len(t.Cycles)) {
+		if i >= len(log) {
+			problems = append(problems, fmt.Sprintf(
+				"missing %s on cycle %d", t.Cycles[i], i,
+			))
+
+			continue
+		}
+
+		access := log[i]
+		got := StepBusOp{Addr: access.Addr, Val: access.Val, Write: access.Write}
+
+		if access.Cycle != uint64(i) {
+			problems = append(problems, fmt.Sprintf(
+				"%s was numbered cycle %d, but was made on cycle %d",
+				got, access.Cycle, i,
+			))
+		}
+
+		if i >= len(t.Cycles) {
+			problems = append(problems, fmt.Sprintf(
+				"unexpected %s on cycle %d", got, i,
+			))
+
+			continue
+		}
+
+		if want := t.Cycles[i]; got != want {
+			problem := fmt.Sprintf("%s on cycle %d, expected %s", got, i, want)
+
+			// If we were going to make the access anyway, it's just that we
+			// made it at the wrong time
+			if j := t.cycleOf(got); j >= 0 {
+				problem += fmt.Sprintf(" (expected ours on cycle %d)", j)
+			}
+
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems
+}
+
+// cycleOf returns the first cycle on which the test expects the given
+// access, or -1 if it doesn't expect it at all.
+func (t SingleStep) cycleOf(op StepBusOp) int {
+	for i, want := range t.Cycles {
+		if want == op {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (s StepState) apply(m *Machine) {
+	m.CPU.PC = s.PC
+	m.CPU.S = s.S
+	m.CPU.A = s.A
+	m.CPU.X = s.X
+	m.CPU.Y = s.Y
+	m.CPU.P = s.P
+
+	for _, pair := range s.RAM {
+		m.RAM.Set(int(pair[0]), uint8(pair[1]))
+	}
+}
+
+func (s StepState) compare(m *Machine) []string {
+	var problems []string
+
+	check := func(reg string, got, want uint16) {
+		if got != want {
+			problems = append(problems, fmt.Sprintf(
+				"%s is $%02X, expected $%02X", reg, got, want,
+			))
+		}
+	}
+
+	const flags = ^(mos.BREAK | mos.UNUSED)
+
+	check("PC", m.CPU.PC, s.PC)
+	check("S", uint16(m.CPU.S), uint16(s.S))
+	check("A", uint16(m.CPU.A), uint16(s.A))
+	check("X", uint16(m.CPU.X), uint16(s.X))
+	check("Y", uint16(m.CPU.Y), uint16(s.Y))
+	check("P", uint16(m.CPU.P&flags), uint16(s.P&flags))
+
+	for _, pair := range s.RAM {
+		if got := m.RAM.Get(int(pair[0])); got != uint8(pair[1]) {
+			problems = append(problems, fmt.Sprintf(
+				"$%04X is $%02X, expected $%02X", pair[0], got, pair[1],
+			))
+		}
+	}
+
+	return problems
+}
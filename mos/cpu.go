@@ -5,8 +5,13 @@ import (
 	"github.com/pevans/erc/memory"
 )
 
-// A CPU is an implementation of an MOS 65c02 processor.
+// A CPU is an implementation of an MOS 65c02 processor (or, optionally, the
+// NMOS 6502 that came before it).
 type CPU struct {
+	// Variant is the member of the 6502 family that the CPU behaves as. By
+	// default, that's the 65C02.
+	Variant Variant
+
 	// RMem and WMem are ways for us to access memory from some segment (we
 	// don't know or care where).
 	RMem memory.Getter
@@ -16,13 +21,42 @@ type CPU struct {
 	// debugging an image file).
 	State *memory.StateMap
 
+	// Bus, if set, observes each access that the CPU makes to memory while
+	// it executes an instruction.
+	Bus Bus
+
 	// cycleCounter is a count of how many cycles we've ever executed.
 	cycleCounter uint64
 
+	// busCycle is the cycle within the current instruction on which the next
+	// access will happen, and executing is true while we're in the middle of
+	// an instruction.
+	busCycle  uint64
+	executing bool
+
+	// branched is true if the instruction we executed was a branch that we
+	// took.
+	branched bool
+
+	// Watch, if set, is told about each read and write of data that the CPU
+	// makes while it executes an instruction, so that it can look for those
+	// that trigger a watchpoint.
+	Watch *memory.Watchpoints
+
+	// Calls, if set, keeps track of the subroutines that we've called and
+	// not yet returned from.
+	Calls *CallStack
+
 	// A map of instructions that we have executed. This is only used when
 	// we're debugging an image.
 	InstructionMap *elog.InstructionMap
 
+	// History, if set, keeps a record of the instructions we most recently
+	// executed, which lets us go back to before we executed them.
+	// historyEntry is the record of the instruction we're executing now.
+	History      *History
+	historyEntry *HistoryEntry
+
 	// A channel of instructions we're sending to interested listeners (e.g.
 	// if they wish to log them).
 	InstructionChannel chan *elog.Instruction
@@ -37,7 +71,8 @@ type CPU struct {
 	opcode uint8
 
 	// ReadOp will be true if the last operation was a "read", as opposed to a
-	// "write". This is important for some soft switch logic.
+	// "write". This is important for some soft switch logic, and for address
+	// modes, which only read the effective value if it's true.
 	ReadOp bool
 
 	// The Operand is the one or two bytes which is an argument to the opcode.
@@ -86,7 +121,7 @@ type CPU struct {
 }
 
 func (c *CPU) CycleCounter() uint64 {
-	return c.cycleCounter
+	return c.cycleCounter + c.busCycle
 }
 
 func (c *CPU) Opcode() uint8 {
@@ -28,3 +28,24 @@ func TestCycleCounter(t *testing.T) {
 	assert.NoError(t, c.Execute())
 	assert.Equal(t, uint64(4), c.CycleCounter())
 }
+
+func TestDecimalCycles(t *testing.T) {
+	c := new(mos.CPU)
+	seg := memory.NewSegment(0x10000)
+	c.RMem = seg
+	c.WMem = seg
+	c.State = new(memory.StateMap)
+	c.P = mos.DECIMAL
+
+	// LDA #$01 takes 2 cycles, decimal or not
+	seg.Set(0, 0xA9)
+	seg.Set(1, 0x01)
+	assert.NoError(t, c.Execute())
+	assert.Equal(t, uint64(2), c.CycleCounter())
+
+	// But ADC #$01 takes an extra cycle in decimal mode
+	seg.Set(2, 0x69)
+	seg.Set(3, 0x01)
+	assert.NoError(t, c.Execute())
+	assert.Equal(t, uint64(5), c.CycleCounter())
+}
@@ -64,3 +64,72 @@ func (d *Decimal) Binary() int {
 
 	return (high4 << 4) | low4
 }
+
+// adcDecimalNMOS performs ADC in decimal mode the way the NMOS 6502 does.
+// The result is the same as the 65C02 for valid BCD, but the N, V and Z
+// flags are not: N and V come from the result before the high digit is
+// adjusted, and Z comes from the binary sum. Software has been known to test
+// for an NMOS chip by looking at these.
+func (c *CPU) adcDecimalNMOS() {
+	var (
+		a     = uint16(c.A)
+		val   = uint16(c.EffVal)
+		carry = uint16(c.P & CARRY)
+	)
+
+	c.ApplyZ(uint8(a + val + carry))
+
+	res := (a & 0xF) + (val & 0xF) + carry
+	if res > 9 {
+		res += 6
+	}
+
+	if res <= 0xF {
+		res = (res & 0xF) + (a & 0xF0) + (val & 0xF0)
+	} else {
+		res = (res & 0xF) + (a & 0xF0) + (val & 0xF0) + 0x10
+	}
+
+	c.ApplyN(uint8(res))
+	c.ApplyStatus((a^res)&0x80 > 0 && (a^val)&0x80 == 0, OVERFLOW)
+
+	if res&0x1F0 > 0x90 {
+		res += 0x60
+	}
+
+	c.ApplyStatus(res&0xFF0 > 0xF0, CARRY)
+	c.A = uint8(res)
+}
+
+// sbcDecimalNMOS performs SBC in decimal mode the way the NMOS 6502 does,
+// which is to set every flag as though the subtraction were binary.
+func (c *CPU) sbcDecimalNMOS() {
+	var (
+		a      = int(c.A)
+		val    = int(c.EffVal)
+		borrow = 0
+	)
+
+	if c.P&CARRY == 0 {
+		borrow = 1
+	}
+
+	bin := a - val - borrow
+
+	c.ApplyNZ(uint8(bin))
+	c.ApplyStatus((a^bin)&0x80 > 0 && (a^val)&0x80 > 0, OVERFLOW)
+	c.ApplyStatus(bin >= 0, CARRY)
+
+	res := (a & 0xF) - (val & 0xF) - borrow
+	if res&0x10 > 0 {
+		res = ((res - 6) & 0xF) | ((a & 0xF0) - (val & 0xF0) - 0x10)
+	} else {
+		res = (res & 0xF) | ((a & 0xF0) - (val & 0xF0))
+	}
+
+	if res&0x100 > 0 {
+		res -= 0x60
+	}
+
+	c.A = uint8(res)
+}
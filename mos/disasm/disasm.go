@@ -0,0 +1,179 @@
+// Package disasm decodes the code in some range of memory into instructions,
+// without needing a CPU to do so. It can annotate each instruction with the
+// names of the routines, variables and soft switches it refers to, and it
+// can write out what it decodes either as a listing or as source that
+// erc-assembler can assemble again.
+package disasm
+
+import (
+	"github.com/pevans/erc/a2/a2sym"
+	"github.com/pevans/erc/assembler"
+	"github.com/pevans/erc/memory"
+	"github.com/pevans/erc/mos"
+)
+
+// An Instruction is one instruction that we decoded from memory.
+type Instruction struct {
+	// Addr is the address of the opcode, and Bytes are the opcode and its
+	// operand, as they are in memory.
+	Addr  uint16
+	Bytes []uint8
+
+	Opcode uint8
+	Name   string
+
+	// Mode is the address mode of the opcode, as one of the mos constants
+	// (e.g. mos.AmABS).
+	Mode int
+
+	// Operand is the value that follows the opcode, which is one or two
+	// bytes (or none).
+	Operand uint16
+
+	// Target is the address that a branch, JMP or JSR goes to, if HasTarget
+	// is true.
+	Target    uint16
+	HasTarget bool
+
+	// Symbol is the name of whatever the operand refers to, if it has one
+	// (e.g. COUT for JSR $FDED). Comment describes any soft switch the
+	// instruction touches.
+	Symbol  string
+	Comment string
+
+	// Data is true if the bytes aren't code (so far as we can tell), in
+	// which case only Addr and Bytes are set.
+	Data bool
+}
+
+// A Disassembler decodes instructions for some variant of the 6502.
+type Disassembler struct {
+	variant mos.Variant
+	symbols map[int]string
+}
+
+// New returns a disassembler for the given variant. Addresses are named
+// with the given symbols (which may be nil) if they have one, or failing
+// that, with the names documented in a2sym.
+func New(variant mos.Variant, symbols map[int]string) *Disassembler {
+	return &Disassembler{
+		variant: variant,
+		symbols: symbols,
+	}
+}
+
+// Decode returns the instruction at the given address in memory.
+func (d *Disassembler) Decode(mem memory.Getter, addr uint16) Instruction {
+	opcode := mem.Get(int(addr))
+	size := d.variant.InstructionSize(opcode)
+
+	inst := Instruction{
+		Addr:   addr,
+		Opcode: opcode,
+		Name:   assembler.Mnemonic(d.variant.InstructionName(opcode)),
+		Mode:   d.variant.AddrMode(opcode),
+		Bytes:  make([]uint8, size),
+	}
+
+	for i := range size {
+		inst.Bytes[i] = mem.Get(int(addr + i))
+	}
+
+	switch d.variant.OperandSize(opcode) {
+	case 1:
+		inst.Operand = uint16(inst.Bytes[1])
+	case 2:
+		inst.Operand = uint16(inst.Bytes[1]) | uint16(inst.Bytes[2])<<8
+	}
+
+	d.target(&inst)
+	d.annotate(&inst)
+
+	return inst
+}
+
+// Range decodes each instruction beginning at start, and up to (but not
+// including) end. The last instruction may run past end, if its operand
+// does.
+func (d *Disassembler) Range(mem memory.Getter, start, end uint16) []Instruction {
+	var insts []Instruction
+
+	for addr := int(start); addr < int(end); {
+		inst := d.Decode(mem, uint16(addr))
+		insts = append(insts, inst)
+		addr += len(inst.Bytes)
+	}
+
+	return insts
+}
+
+// target works out where the instruction goes to, if it's one that changes
+// the flow of control to some known address.
+func (d *Disassembler) target(inst *Instruction) {
+	switch inst.Mode {
+	case mos.AmREL:
+		inst.Target = inst.Addr + 2 + uint16(int8(inst.Operand))
+		inst.HasTarget = true
+
+	case mos.AmZPR:
+		// BBR and BBS keep their branch offset in the second byte of the
+		// operand
+		inst.Target = inst.Addr + 3 + uint16(int8(inst.Operand>>8))
+		inst.HasTarget = true
+
+	case mos.AmABS:
+		switch inst.Name {
+		case "JMP", "JSR":
+			inst.Target = inst.Operand
+			inst.HasTarget = true
+		}
+	}
+}
+
+// annotate fills in the symbol and comment of the instruction.
+func (d *Disassembler) annotate(inst *Instruction) {
+	if inst.HasTarget {
+		inst.Symbol = d.routine(int(inst.Target))
+		return
+	}
+
+	addr := int(inst.Operand)
+
+	switch inst.Mode {
+	case mos.AmZPG, mos.AmZPX, mos.AmZPY, mos.AmIDX, mos.AmIDY, mos.AmZPI,
+		mos.AmABS, mos.AmABX, mos.AmABY, mos.AmIND:
+		inst.Symbol = d.variable(addr)
+	default:
+		return
+	}
+
+	if d.variant.ReadsMemory(inst.Opcode) {
+		if rs := a2sym.ReadSwitch(addr); rs.Mode != a2sym.ModeNone {
+			inst.Comment = rs.String()
+		}
+	}
+
+	if ws := a2sym.WriteSwitch(addr); ws.Mode != a2sym.ModeNone {
+		inst.Comment = ws.String()
+	}
+}
+
+// routine returns the name of the routine at the given address, if there's
+// one we know about.
+func (d *Disassembler) routine(addr int) string {
+	if name := d.symbols[addr]; name != "" {
+		return name
+	}
+
+	return a2sym.Subroutine(addr)
+}
+
+// variable returns the name of the variable at the given address, if
+// there's one we know about.
+func (d *Disassembler) variable(addr int) string {
+	if name := d.symbols[addr]; name != "" {
+		return name
+	}
+
+	return a2sym.Variable(addr)
+}
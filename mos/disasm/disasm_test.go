@@ -0,0 +1,136 @@
+package disasm_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pevans/erc/assembler"
+	"github.com/pevans/erc/memory"
+	"github.com/pevans/erc/mos"
+	"github.com/pevans/erc/mos/disasm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// load returns a segment of memory with the given bytes at the given
+// address.
+func load(addr int, code ...uint8) *memory.Segment {
+	seg := memory.NewSegment(0x10000)
+	for i, b := range code {
+		seg.Set(addr+i, b)
+	}
+
+	return seg
+}
+
+func TestDecode(t *testing.T) {
+	d := disasm.New(mos.CMOS65C02, map[int]string{0x0300: "BUFFER"})
+	seg := load(0x0800,
+		0x20, 0xED, 0xFD, // JSR COUT
+		0xD0, 0xFB, // BNE $0800
+		0xBD, 0x00, 0x03, // LDA BUFFER,X
+		0xAD, 0x00, 0xC0, // LDA $C000
+		0x89, 0x80, // BIT #$80
+		0xB2, 0x10, // LDA ($10)
+	)
+
+	insts := d.Range(seg, 0x0800, 0x080F)
+	require.Len(t, insts, 6)
+
+	assert.Equal(t, "JSR $FDED", insts[0].String())
+	assert.Equal(t, "COUT", insts[0].Symbol)
+	assert.Equal(t, []uint8{0x20, 0xED, 0xFD}, insts[0].Bytes)
+
+	assert.True(t, insts[1].HasTarget)
+	assert.Equal(t, uint16(0x0800), insts[1].Target)
+
+	assert.Equal(t, "LDA $0300,X", insts[2].String())
+	assert.Equal(t, "BUFFER", insts[2].Symbol)
+
+	assert.NotEmpty(t, insts[3].Comment)
+
+	assert.Equal(t, "BIT #$80", insts[4].String())
+	assert.Equal(t, "LDA ($10)", insts[5].String())
+}
+
+func TestDecodeVariants(t *testing.T) {
+	seg := load(0x0800, 0xA7, 0x10)
+
+	assert.Equal(t, "LAX $10", disasm.New(mos.NMOS6502, nil).Decode(seg, 0x0800).String())
+	assert.Equal(t, "NOP", disasm.New(mos.CMOS65C02, nil).Decode(seg, 0x0800).String())
+	assert.Equal(t, "SMB2 $10", disasm.New(mos.WDC65C02, nil).Decode(seg, 0x0800).String())
+}
+
+func TestWriteListing(t *testing.T) {
+	d := disasm.New(mos.CMOS65C02, nil)
+	seg := load(0x0800,
+		0xA2, 0x05, // LDX #$05
+		0xCA,       // DEX
+		0xD0, 0xFD, // BNE $0802
+		0x60, // RTS
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, disasm.WriteListing(&buf, d.Range(seg, 0x0800, 0x0806)))
+	assert.Equal(t,
+		"0800:A2 05       |          LDX #$05\n"+
+			"0802:CA          | L0802    DEX\n"+
+			"0803:D0 FD       |          BNE L0802\n"+
+			"0805:60          |          RTS\n",
+		buf.String(),
+	)
+}
+
+func TestWriteSourceAssembles(t *testing.T) {
+	src := `
+        .org $0801
+        .cpu w65c02
+start:  LDA #$00
+        STA $0010
+        LDX $10,Y
+loop:   INC A
+        BIT #$80
+        JSR $FDED
+        BBR0 $10,loop
+        BNE loop
+        JMP ($0300)
+        .byte $03, $5C, $00, $00
+        JMP start
+`
+
+	code, origin, err := assembler.AssembleCode([]byte(src), "test.s")
+	require.NoError(t, err)
+
+	var (
+		d     = disasm.New(mos.WDC65C02, nil)
+		seg   = load(int(origin), code...)
+		insts = d.Range(seg, origin, origin+uint16(len(code)))
+		buf   bytes.Buffer
+	)
+
+	require.NoError(t, disasm.WriteSource(&buf, insts))
+
+	again, againOrigin, err := assembler.AssembleCode(buf.Bytes(), "again.s")
+	require.NoError(t, err, buf.String())
+	assert.Equal(t, origin, againOrigin)
+	assert.Equal(t, code, again, buf.String())
+	assert.Contains(t, buf.String(), ".cpu w65c02")
+	assert.Contains(t, buf.String(), "JSR $FDED        ; COUT")
+	assert.Contains(t, buf.String(), ".byte $5C, $00, $00")
+}
+
+func TestWriteSourceUnlabeledBranch(t *testing.T) {
+	d := disasm.New(mos.CMOS65C02, nil)
+
+	// The branch goes somewhere outside of what we disassemble, so there's
+	// no label for it
+	seg := load(0x0800, 0xD0, 0x10)
+
+	var buf bytes.Buffer
+	require.NoError(t, disasm.WriteSource(&buf, d.Range(seg, 0x0800, 0x0802)))
+	assert.Equal(t,
+		"         .org $0800\n"+
+			"         .byte $D0, $10   ; BNE $0812\n",
+		buf.String(),
+	)
+}
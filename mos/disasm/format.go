@@ -0,0 +1,244 @@
+package disasm
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/pevans/erc/assembler"
+	"github.com/pevans/erc/mos"
+)
+
+// identRe matches the names that erc-assembler accepts for labels.
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// OperandString returns the operand of the instruction in the syntax that
+// erc-assembler reads, e.g. "$10,X" or "($1234)". Targets are given as the
+// label for their address, if labels has one.
+func (inst Instruction) OperandString(labels map[uint16]string) string {
+	target := fmt.Sprintf("$%04X", inst.Target)
+	if label, ok := labels[inst.Target]; ok {
+		target = label
+	}
+
+	switch inst.Mode {
+	case mos.AmIMM:
+		return fmt.Sprintf("#$%02X", inst.Operand)
+	case mos.AmZPG:
+		return fmt.Sprintf("$%02X", inst.Operand)
+	case mos.AmZPX:
+		return fmt.Sprintf("$%02X,X", inst.Operand)
+	case mos.AmZPY:
+		return fmt.Sprintf("$%02X,Y", inst.Operand)
+	case mos.AmZPI:
+		return fmt.Sprintf("($%02X)", inst.Operand)
+	case mos.AmIDX:
+		return fmt.Sprintf("($%02X,X)", inst.Operand)
+	case mos.AmIDY:
+		return fmt.Sprintf("($%02X),Y", inst.Operand)
+	case mos.AmABS:
+		if inst.HasTarget {
+			return target
+		}
+
+		return fmt.Sprintf("$%04X", inst.Operand)
+	case mos.AmABX:
+		return fmt.Sprintf("$%04X,X", inst.Operand)
+	case mos.AmABY:
+		return fmt.Sprintf("$%04X,Y", inst.Operand)
+	case mos.AmIND:
+		return fmt.Sprintf("($%04X)", inst.Operand)
+	case mos.AmREL:
+		return target
+	case mos.AmZPR:
+		return fmt.Sprintf("$%02X,%s", inst.Operand&0xFF, target)
+	}
+
+	return ""
+}
+
+// String returns the instruction and its operand, e.g. "LDA $10,X". Data
+// is returned as a .byte directive.
+func (inst Instruction) String() string {
+	if inst.Data {
+		return byteDirective(inst.Bytes)
+	}
+
+	return strings.TrimSpace(inst.Name + " " + inst.OperandString(nil))
+}
+
+// Assembles returns true if erc-assembler would assemble the instruction
+// back into the same bytes, given the labels we would write out. That isn't
+// so for undocumented opcodes, nor for branches to somewhere that has no
+// label.
+func (inst Instruction) Assembles(labels map[uint16]string) bool {
+	if inst.Data {
+		return false
+	}
+
+	opcode, ok := assembler.Encode(inst.Name, inst.Mode)
+	if !ok || opcode != inst.Opcode {
+		return false
+	}
+
+	switch inst.Mode {
+	case mos.AmREL, mos.AmZPR:
+		_, ok := labels[inst.Target]
+		return ok
+	}
+
+	return true
+}
+
+// Labels returns a label for each address that an instruction goes to, so
+// long as some instruction begins at that address. We use the name of the
+// symbol for the address if it has one, or failing that, a name like
+// L0801.
+func Labels(insts []Instruction) map[uint16]string {
+	starts := make(map[uint16]bool, len(insts))
+	for _, inst := range insts {
+		starts[inst.Addr] = true
+	}
+
+	var (
+		labels = make(map[uint16]string)
+		used   = make(map[string]bool)
+	)
+
+	for _, inst := range insts {
+		if !inst.HasTarget || !starts[inst.Target] {
+			continue
+		}
+
+		if _, ok := labels[inst.Target]; ok {
+			continue
+		}
+
+		name := inst.Symbol
+		if !identRe.MatchString(name) || used[name] {
+			name = fmt.Sprintf("L%04X", inst.Target)
+		}
+
+		labels[inst.Target] = name
+		used[name] = true
+	}
+
+	return labels
+}
+
+// WriteListing writes out the instructions with their address and bytes,
+// one per line, along with any labels, symbols and comments we have for
+// them.
+func WriteListing(w io.Writer, insts []Instruction) error {
+	labels := Labels(insts)
+
+	for _, inst := range insts {
+		var hex []string
+		for _, b := range inst.Bytes {
+			hex = append(hex, fmt.Sprintf("%02X", b))
+		}
+
+		code := inst.Name + " " + inst.OperandString(labels)
+		if inst.Data {
+			code = inst.String()
+		}
+
+		line := fmt.Sprintf(
+			"%04X:%-11s | %-8s %-16s",
+			inst.Addr, strings.Join(hex, " "), labels[inst.Addr], code,
+		)
+
+		if note := inst.note(); note != "" {
+			line += " ; " + note
+		}
+
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteSource writes out the instructions as source that erc-assembler can
+// assemble back into the same bytes. Anything it wouldn't be able to
+// assemble is written as bytes, with the instruction in a comment. If any
+// of the instructions are those of the Rockwell/WDC 65C02, the source
+// begins with a .cpu directive so that it can assemble them.
+func WriteSource(w io.Writer, insts []Instruction) error {
+	if len(insts) == 0 {
+		return nil
+	}
+
+	labels := Labels(insts)
+
+	if _, err := fmt.Fprintf(w, "%-8s .org $%04X\n", "", insts[0].Addr); err != nil {
+		return err
+	}
+
+	if needsWDC(insts, labels) {
+		if _, err := fmt.Fprintf(w, "%-8s .cpu w65c02\n", ""); err != nil {
+			return err
+		}
+	}
+
+	for _, inst := range insts {
+		label := labels[inst.Addr]
+		if label != "" {
+			label += ":"
+		}
+
+		var (
+			code = strings.TrimSpace(inst.Name + " " + inst.OperandString(labels))
+			note = inst.note()
+		)
+
+		if !inst.Assembles(labels) {
+			if !inst.Data {
+				note = strings.TrimSpace(inst.String() + " " + note)
+			}
+
+			code = byteDirective(inst.Bytes)
+		}
+
+		line := fmt.Sprintf("%-8s %-16s", label, code)
+		if note != "" {
+			line += " ; " + note
+		}
+
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// needsWDC returns true if any of the instructions that we'd write as
+// source are those of the Rockwell/WDC 65C02.
+func needsWDC(insts []Instruction, labels map[uint16]string) bool {
+	for _, inst := range insts {
+		if inst.Assembles(labels) && assembler.NeedsWDC(inst.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// note returns what we'd write in a comment for the instruction, which is
+// its symbol and comment (if it has them).
+func (inst Instruction) note() string {
+	return strings.TrimSpace(inst.Symbol + " " + inst.Comment)
+}
+
+// byteDirective returns a .byte directive for the given bytes.
+func byteDirective(bytes []uint8) string {
+	args := make([]string, len(bytes))
+	for i, b := range bytes {
+		args[i] = fmt.Sprintf("$%02X", b)
+	}
+
+	return ".byte " + strings.Join(args, ", ")
+}
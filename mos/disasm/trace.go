@@ -0,0 +1,140 @@
+package disasm
+
+import (
+	"slices"
+
+	"github.com/pevans/erc/memory"
+)
+
+// dataLineLen is the most bytes of data that we put in one instruction (and
+// so, on one line).
+const dataLineLen = 4
+
+// Trace disassembles the memory from start up to (but not including) end by
+// following the flow of control from each of the given entry points. Where
+// there's a branch, we follow both ways; where there's a JSR, we follow the
+// routine and then what comes after it. We stop following a path when it
+// leaves the range, returns, jumps somewhere we can't know ahead of time
+// (as with JMP ($1234)), or runs into an opcode that code wouldn't use.
+//
+// The bytes we never reach, we take to be data. Those are returned as
+// instructions with Data set, so that what we return covers the whole
+// range, in order of address. Since end is just past the last byte, it's an
+// int, so that a range can run up to $FFFF.
+func (d *Disassembler) Trace(mem memory.Getter, start uint16, end int, entries []uint16) []Instruction {
+	var (
+		code    = make(map[uint16]Instruction)
+		claimed = make(map[uint16]bool)
+		work    = slices.Clone(entries)
+	)
+
+	inRange := func(addr, size int) bool {
+		return addr >= int(start) && addr+size <= end
+	}
+
+	for len(work) > 0 {
+		addr := work[len(work)-1]
+		work = work[:len(work)-1]
+
+		for {
+			if _, seen := code[addr]; seen || !inRange(int(addr), 1) {
+				break
+			}
+
+			inst := d.Decode(mem, addr)
+			if !inRange(int(addr), len(inst.Bytes)) || !inst.plausible() {
+				break
+			}
+
+			// If the instruction would overlap one that we've already
+			// decoded, then one of us is wrong, and we'd rather stick with
+			// what we have
+			if overlaps(claimed, inst) {
+				break
+			}
+
+			code[addr] = inst
+			for i := range inst.Bytes {
+				claimed[addr+uint16(i)] = true
+			}
+
+			if inst.HasTarget {
+				work = append(work, inst.Target)
+			}
+
+			if inst.endsFlow() {
+				break
+			}
+
+			addr += uint16(len(inst.Bytes))
+		}
+	}
+
+	return fill(mem, start, end, code)
+}
+
+// fill returns the instructions in code, in order of address, with the
+// bytes in between them as data.
+func fill(mem memory.Getter, start uint16, end int, code map[uint16]Instruction) []Instruction {
+	var (
+		insts []Instruction
+		data  *Instruction
+	)
+
+	for addr := int(start); addr < end; {
+		if inst, ok := code[uint16(addr)]; ok {
+			insts = append(insts, inst)
+			data = nil
+			addr += len(inst.Bytes)
+
+			continue
+		}
+
+		if data == nil || len(data.Bytes) == dataLineLen {
+			insts = append(insts, Instruction{Addr: uint16(addr), Data: true})
+			data = &insts[len(insts)-1]
+		}
+
+		data.Bytes = append(data.Bytes, mem.Get(addr))
+		addr++
+	}
+
+	return insts
+}
+
+// overlaps returns true if any of the bytes of the instruction have been
+// claimed by another.
+func overlaps(claimed map[uint16]bool, inst Instruction) bool {
+	for i := range inst.Bytes {
+		if claimed[inst.Addr+uint16(i)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// plausible returns true if the instruction is something that we'd expect
+// to see in code. The opcodes that do nothing, other than the real NOP, are
+// far more likely to be data that we've wandered into.
+func (inst Instruction) plausible() bool {
+	switch inst.Name {
+	case "NP2", "NP3":
+		return false
+	case "NOP":
+		return inst.Opcode == 0xEA
+	}
+
+	return true
+}
+
+// endsFlow returns true if the instruction doesn't continue on to whatever
+// follows it.
+func (inst Instruction) endsFlow() bool {
+	switch inst.Name {
+	case "JMP", "RTS", "RTI", "BRK", "BRA", "JAM", "STP":
+		return true
+	}
+
+	return false
+}
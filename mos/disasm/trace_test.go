@@ -0,0 +1,79 @@
+package disasm_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pevans/erc/mos"
+	"github.com/pevans/erc/mos/disasm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	d := disasm.New(mos.CMOS65C02, nil)
+	seg := load(0x0800,
+		0x20, 0x0A, 0x08, // 0800: JSR $080A
+		0xF0, 0x03, // 0803: BEQ $0808
+		0x4C, 0x00, 0x08, // 0805: JMP $0800
+		0x60,       // 0808: RTS
+		0xFF,       // 0809: (data)
+		0xA9, 0x01, // 080A: LDA #$01
+		0x60,                         // 080C: RTS
+		0x01, 0x02, 0x03, 0x04, 0x05, // 080D: (data)
+	)
+
+	insts := d.Trace(seg, 0x0800, 0x0812, []uint16{0x0800})
+
+	var buf bytes.Buffer
+	require.NoError(t, disasm.WriteListing(&buf, insts))
+	assert.Equal(t,
+		"0800:20 0A 08    | L0800    JSR L080A\n"+
+			"0803:F0 03       |          BEQ L0808\n"+
+			"0805:4C 00 08    |          JMP L0800\n"+
+			"0808:60          | L0808    RTS\n"+
+			"0809:FF          |          .byte $FF\n"+
+			"080A:A9 01       | L080A    LDA #$01\n"+
+			"080C:60          |          RTS\n"+
+			"080D:01 02 03 04 |          .byte $01, $02, $03, $04\n"+
+			"0811:05          |          .byte $05\n",
+		buf.String(),
+	)
+}
+
+func TestTraceStopsAtData(t *testing.T) {
+	d := disasm.New(mos.CMOS65C02, nil)
+
+	// The branch is never taken, but we can't know that; what it goes to
+	// isn't plausible code, so it's left as data. Likewise, we don't follow
+	// anything out of the range we're given.
+	seg := load(0x0800,
+		0xB0, 0x01, // 0800: BCS $0803
+		0x60,             // 0802: RTS
+		0x02,             // 0803: (NP2)
+		0x20, 0xED, 0xFD, // 0804: JSR $FDED
+	)
+
+	insts := d.Trace(seg, 0x0800, 0x0804, []uint16{0x0800})
+	require.Len(t, insts, 3)
+	assert.Equal(t, "BCS $0803", insts[0].String())
+	assert.Equal(t, "RTS", insts[1].String())
+	assert.True(t, insts[2].Data)
+	assert.Equal(t, []uint8{0x02}, insts[2].Bytes)
+}
+
+func TestTraceToEndOfMemory(t *testing.T) {
+	d := disasm.New(mos.CMOS65C02, nil)
+	seg := load(0xFFFC,
+		0xA9, 0x01, // FFFC: LDA #$01
+		0x60, // FFFE: RTS
+		0xFF, // FFFF: (data)
+	)
+
+	insts := d.Trace(seg, 0xFFFC, 0x10000, []uint16{0xFFFC})
+	require.Len(t, insts, 3)
+	assert.Equal(t, "LDA #$01", insts[0].String())
+	assert.Equal(t, "RTS", insts[1].String())
+	assert.True(t, insts[2].Data)
+	assert.Equal(t, uint16(0xFFFF), insts[2].Addr)
+}
@@ -78,16 +78,17 @@ func OpcodeReadsMemory(opcode uint8) bool {
 // boundaries for memory access, including when branching; if we're performing
 // some decimal operation.
 func (c *CPU) OpcodeCycles() int {
-	cyc := int(cycles[c.opcode])
+	op := c.Variant.op(c.opcode)
+	cyc := int(op.cycles)
 	effPage := c.EffAddr & 0xFF00
 
-	switch addrModes[c.opcode] {
+	switch op.addrMode {
 	case AmABX, AmABY:
 		// We may be crossing page boundaries; if so, we need to add a 1-cycle
 		// penalty
 		basePage := c.Operand & 0xFF00
 
-		if basePage != effPage {
+		if basePage != effPage && op.pagePenalty {
 			cyc++
 		}
 
@@ -98,15 +99,28 @@ func (c *CPU) OpcodeCycles() int {
 		baseAddr := c.EffAddr - uint16(c.Y)
 		basePage := baseAddr & 0xFF00
 
-		if basePage != effPage {
+		if basePage != effPage && op.pagePenalty {
 			cyc++
 		}
 
+	case AmZPR:
+		// BBR and BBS work like branches, but are three bytes long.
+		nextPC := c.LastPC + 3
+		if c.branched {
+			cyc++
+
+			if (nextPC & 0xFF00) != (c.EffAddr & 0xFF00) {
+				cyc++
+			}
+		}
+
 	case AmREL:
 		// The number of cycles consumed by a branch are variable based on its
-		// outcomes, which may also factor in a page-cross penalty.
+		// outcomes, which may also factor in a page-cross penalty. (Note that
+		// a branch to the next instruction still costs a cycle, so we can't
+		// tell if we took the branch just by where it goes.)
 		nextPC := c.LastPC + 2
-		if c.EffAddr != nextPC {
+		if c.branched {
 			cyc++
 
 			if (nextPC & 0xFF00) != (c.EffAddr & 0xFF00) {
@@ -115,13 +129,9 @@ func (c *CPU) OpcodeCycles() int {
 		}
 	}
 
-	// Setting the D flag causes instructions to take one cycle longer than
-	// normal.
-	//
-	// NOTE: I'm not sure if this applies universally or only for arithmetic
-	// operations. In practice, code typically sets decimal only for
-	// arithmetic operations, then unsets.
-	if c.P&DECIMAL > 0 {
+	// Setting the D flag causes ADC and SBC to take one cycle longer than
+	// normal on the 65C02. The NMOS 6502 doesn't do this at all.
+	if c.P&DECIMAL > 0 && op.decimalPenalty {
 		cyc++
 	}
 
@@ -137,25 +147,22 @@ func (c *CPU) OpcodeCycles() int {
 // incremented beyond the 0xFFFF address, it would simply overflow back to the
 // zero page.
 func (c *CPU) Execute() error {
-	var (
-		inst Instruction
-		mode AddrMode
-	)
-
 	metrics.Increment("instructions", 1)
 
 	// We want to record the current PC before it might change as the result
 	// of any instruction we execute
 	c.LastPC = c.PC
+	c.recordStart()
+
+	// Each access we make from here on takes up a cycle of the instruction
+	c.executing = true
+	c.busCycle = 0
+	c.branched = false
 
 	c.opcode = c.Get(c.PC)
-	mode = addrModeFuncs[c.opcode]
-	inst = instructions[c.opcode]
+	op := c.Variant.op(c.opcode)
 
-	c.State.SetBool(
-		a2state.InstructionReadOp,
-		OpcodeReadsMemory(c.opcode),
-	)
+	c.ReadOp = op.reads
 
 	// NOTE: neither the address mode resolver nor the instruction handler
 	// have any error conditions. This is by design: they DO NOT error out.
@@ -163,30 +170,39 @@ func (c *CPU) Execute() error {
 
 	// Resolve the values of EffAddr and EffVal by executing the address mode
 	// handler.
-	mode(c)
+	op.mode(c)
 
 	// Now execute the instruction
-	inst(c)
+	op.inst(c)
 
 	// Adjust the program counter to beyond the expected instruction sequence
 	// (1 byte for the opcode, + N bytes for the operand, based on address
 	// mode).
-	c.PC += offsets[c.opcode]
+	c.PC += op.offset
 
 	// We always apply BREAK and UNUSED after each execution, mostly in
 	// observance for how other emulators have handled this step.
 	c.P |= UNUSED | BREAK
 
-	if c.State.Bool(a2state.DebugImage) && c.InstructionChannel != nil {
+	c.executing = false
+	c.recordEnd()
+	c.trackCall()
+
+	cycles := c.OpcodeCycles()
+
+	// We only build a line for the instruction if someone is listening for
+	// it, since that's a lot of work to do for every instruction
+	if c.InstructionChannel != nil && c.State.Bool(a2state.DebugImage) {
 		select {
-		case c.InstructionChannel <- c.LastInstructionLine(int(c.OpcodeCycles())):
+		case c.InstructionChannel <- c.LastInstructionLine(cycles):
 		// Sent successfully
 		default:
 			// Channel full, drop this instruction log
 		}
 	}
 
-	c.cycleCounter += uint64(c.OpcodeCycles())
+	c.cycleCounter += uint64(cycles)
+	c.busCycle = 0
 
 	return nil
 }
@@ -3,6 +3,7 @@ package mos_test
 import (
 	"testing"
 
+	"github.com/pevans/erc/internal/metrics"
 	"github.com/pevans/erc/memory"
 	"github.com/pevans/erc/mos"
 	"github.com/stretchr/testify/assert"
@@ -23,3 +24,52 @@ func TestExecute(t *testing.T) {
 	// In just a blank default template, this should error out.
 	assert.NoError(t, c.Execute())
 }
+
+// benchProgram is a loop of common instructions that we execute in
+// BenchmarkExecute. It stores to, reads from and modifies memory, and
+// branches, but doesn't touch any soft switches.
+var benchProgram = []uint8{
+	0xA9, 0x00, // LDA #$00
+	0x85, 0x10, // STA $10
+	0xA2, 0x08, // LDX #$08
+	0xE6, 0x10, // INC $10
+	0x65, 0x10, // ADC $10
+	0x9D, 0x00, 0x20, // STA $2000,X
+	0xBD, 0xFF, 0x20, // LDA $20FF,X
+	0xCA,       // DEX
+	0xD0, 0xF3, // BNE $0806
+	0x4C, 0x00, 0x08, // JMP $0800
+}
+
+// BenchmarkExecute runs benchProgram on each variant, and reports how fast
+// that is as the clock speed (in MHz) that the CPU would need to keep up.
+// The Apple //e runs at about 1.023 MHz.
+func BenchmarkExecute(b *testing.B) {
+	// Without anyone to read them, we wouldn't collect metrics
+	metrics.Enable(false)
+	defer metrics.Enable(true)
+
+	for _, v := range []mos.Variant{mos.CMOS65C02, mos.NMOS6502} {
+		b.Run(v.String(), func(b *testing.B) {
+			c := new(mos.CPU)
+			seg := memory.NewSegment(0x10000)
+			c.RMem = seg
+			c.WMem = seg
+			c.State = new(memory.StateMap)
+			c.Variant = v
+
+			for i, byt := range benchProgram {
+				seg.Set(0x0800+i, byt)
+			}
+
+			c.PC = 0x0800
+
+			for b.Loop() {
+				_ = c.Execute()
+			}
+
+			mhz := float64(c.CycleCounter()) / b.Elapsed().Seconds() / 1e6
+			b.ReportMetric(mhz, "MHz")
+		})
+	}
+}
@@ -25,12 +25,12 @@ func (c *CPU) CurrentInstructionShort() string {
 
 	line := &elog.Instruction{
 		Address:     &pc,
-		Instruction: instructions[c.opcode].String(),
+		Instruction: c.Variant.InstructionName(c.opcode),
 		Operand:     c.Operand,
 		Opcode:      c.opcode,
 	}
 
-	PrepareOperand(line, c.PC)
+	c.Variant.PrepareOperand(line, c.PC)
 
 	return line.ShortString()
 }
@@ -41,14 +41,14 @@ func (c *CPU) LastInstructionLine(cycles int) *elog.Instruction {
 	lastPC := int(c.LastPC)
 	line := &elog.Instruction{
 		Address:     &lastPC,
-		Instruction: instructions[c.opcode].String(),
+		Instruction: c.Variant.InstructionName(c.opcode),
 		Opcode:      c.opcode,
 		Operand:     c.Operand,
 		Cycles:      cycles,
 	}
 
-	PrepareOperand(line, c.LastPC)
-	ExplainInstruction(line, c.LastPC, c.EffAddr)
+	c.Variant.PrepareOperand(line, c.LastPC)
+	c.Variant.ExplainInstruction(line, c.LastPC, c.EffAddr)
 
 	return line
 }
@@ -67,7 +67,7 @@ func (c *CPU) LastInstruction() string {
 // will look like.
 func (c *CPU) NextInstruction() string {
 	opcode := c.Get(c.PC)
-	mode := addrModeFuncs[opcode]
+	mode := c.Variant.op(opcode).mode
 
 	// Copy the CPU so we don't alter our own operand, effective address, etc.
 	// Note that this won't copy memory segments, etc. Notably the statemap
@@ -85,12 +85,12 @@ func (c *CPU) NextInstruction() string {
 	pc := int(c.PC)
 	ln := &elog.Instruction{
 		Address:     &pc,
-		Instruction: instructions[opcode].String(),
+		Instruction: c.Variant.InstructionName(opcode),
 		Operand:     c.Operand,
 	}
 
-	PrepareOperand(ln, c.PC)
-	ExplainInstruction(ln, c.PC, c.EffAddr)
+	c.Variant.PrepareOperand(ln, c.PC)
+	c.Variant.ExplainInstruction(ln, c.PC, c.EffAddr)
 
 	return ln.String()
 }
@@ -101,7 +101,10 @@ func (c *CPU) NextInstruction() string {
 // calculate the branch address, given that branch operands are relative
 // values.
 func PrepareOperand(line *elog.Instruction, pc uint16) {
-	addrMode := OpcodeAddrMode(line.Opcode)
+	CMOS65C02.PrepareOperand(line, pc)
+}
+
+func prepareOperand(line *elog.Instruction, pc uint16, addrMode int) {
 	lsb := uint8(line.Operand & 0xFF)
 	msb := uint8(line.Operand >> 8)
 
@@ -144,6 +147,16 @@ func PrepareOperand(line *elog.Instruction, pc uint16) {
 
 		line.PreparedOperand = fmt.Sprintf("$%04X", newAddr)
 		line.OperandLSB = &lsb
+	case AmZPR:
+		// The MSB is the branch offset, which works as it does in AmREL
+		newAddr := pc + uint16(msb) + 3
+		if msb >= 0x80 {
+			newAddr -= 256
+		}
+
+		line.PreparedOperand = fmt.Sprintf("$%02X,$%04X", lsb, newAddr)
+		line.OperandLSB = &lsb
+		line.OperandMSB = &msb
 	case AmZPG:
 		line.PreparedOperand = fmt.Sprintf("$%02X", line.Operand)
 		line.OperandLSB = &lsb
@@ -174,5 +187,5 @@ func formatStatus(p uint8) string {
 
 // OpcodeInstruction returns the string label of an opcode's instruction.
 func OpcodeInstruction(opcode uint8) string {
-	return instructions[opcode].String()
+	return CMOS65C02.InstructionName(opcode)
 }
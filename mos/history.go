@@ -0,0 +1,252 @@
+package mos
+
+import (
+	"github.com/pevans/erc/elog"
+	"github.com/pevans/erc/memory"
+)
+
+// A DirectMemory is memory that we can read and write without setting off
+// any soft switches along the way.
+type DirectMemory interface {
+	DirectGet(int) uint8
+	DirectSet(int, uint8)
+}
+
+// A LocatedMemory is DirectMemory that can also tell us where a write to an
+// address would land: the segment, and the offset within it. With bank
+// switching, that can be somewhere different from one instruction to the
+// next.
+type LocatedMemory interface {
+	DirectMemory
+	WriteLocation(int) (*memory.Segment, int)
+}
+
+// A HistoryWrite records a byte that an instruction overwrote, and what the
+// byte was before it did.
+type HistoryWrite struct {
+	Addr uint16
+	Old  uint8
+
+	// segment and offset are where the write landed, if the memory could
+	// tell us. If segment is nil, we restore the byte to addr instead.
+	segment *memory.Segment
+	offset  int
+}
+
+// A HistoryEntry is the record of one executed instruction. The registers
+// are those from before the instruction ran, so that we can go back to them;
+// the effective address and value are those the instruction worked with.
+type HistoryEntry struct {
+	PC           uint16
+	A, X, Y, S   uint8
+	P            uint8
+	CycleCounter uint64
+
+	Opcode  uint8
+	Operand uint16
+	EffAddr uint16
+	EffVal  uint8
+
+	// Writes are the bytes overwritten by the instruction, in the order it
+	// wrote them.
+	Writes []HistoryWrite
+}
+
+// A History is a ring buffer of the instructions that the CPU most recently
+// executed. Once it's full, each new entry takes the place of the oldest.
+type History struct {
+	entries []HistoryEntry
+
+	// next is the index of the slot that the next entry will use, and size
+	// is the number of slots in use.
+	next, size int
+}
+
+// NewHistory returns a history that holds up to capacity entries.
+func NewHistory(capacity int) *History {
+	return &History{
+		entries: make([]HistoryEntry, capacity),
+	}
+}
+
+// Len returns the number of entries in the history.
+func (h *History) Len() int {
+	return h.size
+}
+
+// Cap returns the number of entries that the history can hold.
+func (h *History) Cap() int {
+	return len(h.entries)
+}
+
+// push returns the slot for a new entry, which will hold whatever was there
+// before (if anything). We reuse the slot's write slice so that we don't
+// allocate on every instruction.
+func (h *History) push() *HistoryEntry {
+	entry := &h.entries[h.next]
+	entry.Writes = entry.Writes[:0]
+
+	h.next = (h.next + 1) % len(h.entries)
+	if h.size < len(h.entries) {
+		h.size++
+	}
+
+	return entry
+}
+
+// pop removes the newest entry from the history and returns it.
+func (h *History) pop() (HistoryEntry, bool) {
+	if h.size == 0 {
+		return HistoryEntry{}, false
+	}
+
+	h.next = (h.next - 1 + len(h.entries)) % len(h.entries)
+	h.size--
+
+	return h.entries[h.next], true
+}
+
+// Recent returns up to n of the newest entries, from oldest to newest.
+func (h *History) Recent(n int) []HistoryEntry {
+	n = min(n, h.size)
+	recent := make([]HistoryEntry, 0, n)
+
+	for i := n; i > 0; i-- {
+		idx := (h.next - i + len(h.entries)) % len(h.entries)
+		recent = append(recent, h.entries[idx])
+	}
+
+	return recent
+}
+
+// recordStart begins a history entry for the instruction we're about to
+// execute, if we're keeping a history.
+func (c *CPU) recordStart() {
+	if c.History == nil || c.History.Cap() == 0 {
+		c.historyEntry = nil
+		return
+	}
+
+	entry := c.History.push()
+	entry.PC = c.PC
+	entry.A = c.A
+	entry.X = c.X
+	entry.Y = c.Y
+	entry.S = c.S
+	entry.P = c.P
+	entry.CycleCounter = c.cycleCounter
+
+	c.historyEntry = entry
+}
+
+// recordWrite notes the byte at addr before the current instruction
+// overwrites it. We can only do this if we can read memory without
+// disturbing it.
+func (c *CPU) recordWrite(addr uint16) {
+	if c.historyEntry == nil {
+		return
+	}
+
+	if mem, ok := c.WMem.(LocatedMemory); ok {
+		seg, offset := mem.WriteLocation(int(addr))
+
+		// A write that doesn't land in memory has nothing to restore
+		if seg == nil {
+			return
+		}
+
+		c.historyEntry.Writes = append(c.historyEntry.Writes, HistoryWrite{
+			Addr:    addr,
+			Old:     seg.DirectGet(offset),
+			segment: seg,
+			offset:  offset,
+		})
+
+		return
+	}
+
+	if mem, ok := c.WMem.(DirectMemory); ok {
+		c.historyEntry.Writes = append(c.historyEntry.Writes, HistoryWrite{
+			Addr: addr,
+			Old:  mem.DirectGet(int(addr)),
+		})
+	}
+}
+
+// recordEnd finishes the history entry for the instruction we just executed.
+func (c *CPU) recordEnd() {
+	if c.historyEntry == nil {
+		return
+	}
+
+	c.historyEntry.Opcode = c.opcode
+	c.historyEntry.Operand = c.Operand
+	c.historyEntry.EffAddr = c.EffAddr
+	c.historyEntry.EffVal = c.EffVal
+	c.historyEntry = nil
+}
+
+// Back undoes up to n of the most recently executed instructions, restoring
+// the registers and memory to how they were before each ran, and returns the
+// number of instructions that it undid. Soft switches that the instructions
+// set off are not rewound, but each byte is restored to wherever it was
+// written at the time -- unless the memory couldn't tell us where that was,
+// in which case it goes wherever the switches currently say a write would go.
+func (c *CPU) Back(n int) int {
+	if c.History == nil || n <= 0 {
+		return 0
+	}
+
+	mem, _ := c.WMem.(DirectMemory)
+
+	for i := range n {
+		entry, ok := c.History.pop()
+		if !ok {
+			return i
+		}
+
+		for j := len(entry.Writes) - 1; j >= 0; j-- {
+			write := entry.Writes[j]
+
+			switch {
+			case write.segment != nil:
+				write.segment.DirectSet(write.offset, write.Old)
+			case mem != nil:
+				mem.DirectSet(int(write.Addr), write.Old)
+			}
+		}
+
+		c.PC = entry.PC
+		c.A = entry.A
+		c.X = entry.X
+		c.Y = entry.Y
+		c.S = entry.S
+		c.P = entry.P
+		c.cycleCounter = entry.CycleCounter
+
+		// We can forget the calls that we went back to before, but not
+		// remember the ones that we went back into
+		if c.Calls != nil {
+			c.Calls.unwind(c.S)
+		}
+	}
+
+	return n
+}
+
+// HistoryLine returns the instruction recorded by a history entry in the
+// form of an elog.Instruction object.
+func (c *CPU) HistoryLine(entry HistoryEntry) *elog.Instruction {
+	pc := int(entry.PC)
+	line := &elog.Instruction{
+		Address:     &pc,
+		Instruction: c.Variant.InstructionName(entry.Opcode),
+		Opcode:      entry.Opcode,
+		Operand:     entry.Operand,
+	}
+
+	c.Variant.PrepareOperand(line, entry.PC)
+	c.Variant.ExplainInstruction(line, entry.PC, entry.EffAddr)
+
+	return line
+}
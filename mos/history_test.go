@@ -0,0 +1,77 @@
+package mos_test
+
+import (
+	"testing"
+
+	"github.com/pevans/erc/mos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryRing(t *testing.T) {
+	c, seg := newVariantCPU(mos.CMOS65C02)
+	c.History = mos.NewHistory(3)
+
+	// Five NOPs, of which we should only remember the last three
+	for i := range 5 {
+		seg.Set(0x300+i, 0xEA)
+	}
+
+	c.PC = 0x300
+	for range 5 {
+		assert.NoError(t, c.Execute())
+	}
+
+	assert.Equal(t, 3, c.History.Len())
+
+	recent := c.History.Recent(10)
+	assert.Len(t, recent, 3)
+	assert.Equal(t, uint16(0x302), recent[0].PC)
+	assert.Equal(t, uint16(0x304), recent[2].PC)
+
+	assert.Equal(t, 3, c.Back(5))
+	assert.Equal(t, uint16(0x302), c.PC)
+	assert.Equal(t, 0, c.History.Len())
+}
+
+func TestHistoryBack(t *testing.T) {
+	c, seg := newVariantCPU(mos.NMOS6502)
+	c.History = mos.NewHistory(10)
+
+	seg.Set(0x300, 0xA9) // LDA #$42
+	seg.Set(0x301, 0x42)
+	seg.Set(0x302, 0x8D) // STA $2000
+	seg.Set(0x303, 0x00)
+	seg.Set(0x304, 0x20)
+	seg.Set(0x305, 0xEE) // INC $2000
+	seg.Set(0x306, 0x00)
+	seg.Set(0x307, 0x20)
+	seg.Set(0x2000, 0x11)
+	c.PC = 0x300
+
+	for range 3 {
+		assert.NoError(t, c.Execute())
+	}
+
+	assert.Equal(t, uint8(0x43), seg.Get(0x2000))
+	assert.Equal(t, uint64(2+4+6), c.CycleCounter())
+
+	// INC on the NMOS 6502 writes twice, and we should undo both
+	assert.Equal(t, 1, c.Back(1))
+	assert.Equal(t, uint8(0x42), seg.Get(0x2000))
+	assert.Equal(t, uint16(0x305), c.PC)
+	assert.Equal(t, uint64(2+4), c.CycleCounter())
+
+	assert.Equal(t, 2, c.Back(2))
+	assert.Equal(t, uint8(0x11), seg.Get(0x2000))
+	assert.Equal(t, uint16(0x300), c.PC)
+	assert.Equal(t, uint8(0), c.A)
+
+	// We can't go back a negative number of instructions
+	assert.NoError(t, c.Execute())
+	assert.Equal(t, 0, c.Back(-3))
+	assert.Equal(t, uint16(0x302), c.PC)
+
+	line := c.HistoryLine(mos.HistoryEntry{PC: 0x302, Opcode: 0x8D, Operand: 0x2000})
+	assert.Equal(t, "STA", line.Instruction)
+	assert.Equal(t, uint16(0x2000), line.Operand)
+}
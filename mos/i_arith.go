@@ -4,6 +4,11 @@ package mos
 // integers to the accumulator; if the carry flag is set, then the result is
 // further incremented by one.
 func Adc(c *CPU) {
+	if c.P&DECIMAL > 0 && c.Variant == NMOS6502 {
+		c.adcDecimalNMOS()
+		return
+	}
+
 	accum := c.A
 
 	// It's useful to make an accounting of how the result looks in a 16-bit
@@ -27,6 +32,8 @@ func Adc(c *CPU) {
 	)
 
 	if c.P&DECIMAL > 0 {
+		c.decimalDummyRead()
+
 		decimalAccumulator := NewDecimal(int(c.A))
 		decimalAccumulator.Add(
 			NewDecimal(int(c.EffVal)),
@@ -46,6 +53,14 @@ func Adc(c *CPU) {
 	c.A = res8
 }
 
+// decimalDummyRead makes the dummy read of the cycle that the 65C02 spends
+// to correct the result of ADC or SBC in decimal mode, which it reads from
+// the address of the next instruction. (The NMOS 6502 doesn't spend the
+// cycle, which is why its decimal results are a bit odd.)
+func (c *CPU) decimalDummyRead() {
+	c.dummyRead(c.PC + c.Variant.InstructionSize(c.opcode))
+}
+
 // Cmp implements the CMP (compare A) instruction, and compares with the A
 // register. See the Compare method for more details.
 func Cmp(c *CPU) {
@@ -76,7 +91,7 @@ func Dec(c *CPU) {
 		return
 	}
 
-	c.Set(c.EffAddr, effVal)
+	c.modify(effVal)
 }
 
 // Dex implements the DEX (decrement X) instruction. DEX decrements only from
@@ -105,7 +120,7 @@ func Inc(c *CPU) {
 		return
 	}
 
-	c.Set(c.EffAddr, effVal)
+	c.modify(effVal)
 }
 
 // Inx implements the INX (increment X) instruction. INX can only increment
@@ -126,6 +141,11 @@ func Iny(c *CPU) {
 // from the A register. If the carry flag is NOT set, then an additional one
 // is subtracted from the result.
 func Sbc(c *CPU) {
+	if c.P&DECIMAL > 0 && c.Variant == NMOS6502 {
+		c.sbcDecimalNMOS()
+		return
+	}
+
 	accum := c.A
 
 	res := int(c.A)
@@ -144,6 +164,8 @@ func Sbc(c *CPU) {
 	)
 
 	if c.P&DECIMAL > 0 {
+		c.decimalDummyRead()
+
 		decimalAccumulator := NewDecimal(int(c.A))
 		decimalAccumulator.Subtract(
 			NewDecimal(int(c.EffVal)),
@@ -8,10 +8,24 @@ func (c *CPU) saveResult(res uint8) {
 	if c.AddrMode == AmACC {
 		c.A = res
 	} else {
-		c.Set(c.EffAddr, res)
+		c.modify(res)
 	}
 }
 
+// modify saves the result of a read-modify-write instruction at the
+// effective address. The NMOS 6502 writes back the value it read before it
+// writes the result, and that extra write can be noticed by soft switches.
+// (The 65C02 reads the address a second time instead.)
+func (c *CPU) modify(res uint8) {
+	if c.Variant == NMOS6502 {
+		c.Set(c.EffAddr, c.EffVal)
+	} else {
+		c.dummyRead(c.EffAddr)
+	}
+
+	c.Set(c.EffAddr, res)
+}
+
 // And implements the AND instruction, which performs a bitwise-and on A and
 // the effective value and saves the result there.
 func And(c *CPU) {
@@ -125,12 +139,12 @@ func Ror(c *CPU) {
 // is not zero, and also saves result of (A exclusive-or 0xFF) & EffVal.
 func Trb(c *CPU) {
 	c.ApplyStatus(c.A&c.EffVal == 0, ZERO)
-	c.Set(c.EffAddr, (c.A^0xff)&c.EffVal)
+	c.modify((c.A ^ 0xff) & c.EffVal)
 }
 
 // Tsb implements the TSB instruction, which sets the zero flag if A & EffVal
 // is not zero, and also saves the result of A | EffVal.
 func Tsb(c *CPU) {
 	c.ApplyStatus(c.A&c.EffVal == 0, ZERO)
-	c.Set(c.EffAddr, c.A|c.EffVal)
+	c.modify(c.A | c.EffVal)
 }
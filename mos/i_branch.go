@@ -7,7 +7,7 @@ import "github.com/pevans/erc/a2/a2state"
 // that if bits is non-zero then the operation was "successful".
 func (c *CPU) jumpIf(bits uint8) {
 	if bits > 0 {
-		c.PC = c.EffAddr
+		c.branch(c.PC + 2)
 		return
 	}
 
@@ -21,6 +21,22 @@ func (c *CPU) jumpIf(bits uint8) {
 	c.PC += 2
 }
 
+// branch jumps to EffAddr from a branch instruction, where next is the
+// address of the instruction after it. Taking a branch costs a cycle, in
+// which the processor reads from next; if EffAddr is on another page, it
+// costs one more, in which it reads from the address it has before it fixes
+// the page.
+func (c *CPU) branch(next uint16) {
+	c.branched = true
+	c.dummyRead(next)
+
+	if next&0xFF00 != c.EffAddr&0xFF00 {
+		c.dummyRead((next & 0xFF00) | (c.EffAddr & 0x00FF))
+	}
+
+	c.PC = c.EffAddr
+}
+
 // Bcc implements the BCC (branch on carry clear) instruction.
 func Bcc(c *CPU) {
 	c.jumpIf(^c.P & CARRY)
@@ -57,7 +73,7 @@ func Bpl(c *CPU) {
 
 // Bra implements the BRA (branch always) instruction.
 func Bra(c *CPU) {
-	c.PC = c.EffAddr
+	c.branch(c.PC + 2)
 }
 
 // Bvc implements the BVC (branch on overflow clear) instruction.
@@ -21,9 +21,16 @@ func Brk(c *CPU) {
 	// Also hang onto the status
 	c.PushStack(c.P)
 
-	// Always set INTERRUPT, always remove DECIMAL
+	// The processor then reads the IRQ vector. We don't jump through it, but
+	// we spend the cycles reading it all the same.
+	c.Get16(0xFFFE)
+
+	// Always set INTERRUPT. The 65C02 also removes DECIMAL, but the NMOS
+	// 6502 leaves it alone.
 	c.P |= INTERRUPT
-	c.P &^= DECIMAL
+	if c.Variant != NMOS6502 {
+		c.P &^= DECIMAL
+	}
 
 	c.PC += 2
 }
@@ -31,8 +38,6 @@ func Brk(c *CPU) {
 // Jmp implements the JMP instruction, which sets the program counter to the
 // effective address.
 func Jmp(c *CPU) {
-	// FIXME: in opcode 0x7C, there may be inaccurate behavior on the 65C02
-	// processor.
 	c.PC = c.EffAddr
 }
 
@@ -42,11 +47,17 @@ func Jsr(c *CPU) {
 	nextPos := c.PC + 2
 
 	// We have to save the position that we should jump back to after we
-	// return from subroutine (RTS) in the stack.
+	// return from subroutine (RTS) in the stack. The processor spends a
+	// cycle with the stack before it does so.
+	c.stackDummyRead()
 	c.PushStack(uint8(nextPos >> 8))
 	c.PushStack(uint8(nextPos & 0xFF))
 
-	if !c.State.Bool(a2state.BankReadRAM) {
+	// Only now does the processor read the high byte of the address (see
+	// Abj). If the stack was on top of it, it's what we just pushed.
+	c.EffAddr = (c.EffAddr & 0x00FF) | (uint16(c.Get(c.PC+2)) << 8)
+
+	if metrics.Enabled() && !c.State.Bool(a2state.BankReadRAM) {
 		if routine := a2sym.Subroutine(int(c.EffAddr)); routine != "" {
 			metrics.Increment(fmt.Sprintf("jsr_builtin_%s", routine), 1)
 		}
@@ -79,6 +90,7 @@ func Np3(c *CPU) {
 // Rti implements the RTI (return from interrupt) instruction, which recovers
 // the program state from a previous BRK operation.
 func Rti(c *CPU) {
+	c.stackDummyRead()
 	c.P = c.PopStack()
 
 	// These two flags are always set after we return from interrupt
@@ -95,8 +107,13 @@ func Rti(c *CPU) {
 // Rts implements the RTS (return from subroutine) instruction, which sets the
 // program counter to the position saved from a previous JSR.
 func Rts(c *CPU) {
+	c.stackDummyRead()
 	lsb := uint16(c.PopStack())
 	msb := uint16(c.PopStack())
 
-	c.PC = ((msb << 8) | lsb) + 1
+	// The processor spends one last cycle incrementing the address, and
+	// reads from the address before it does
+	c.PC = (msb << 8) | lsb
+	c.dummyRead(c.PC)
+	c.PC++
 }
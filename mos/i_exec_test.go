@@ -39,6 +39,9 @@ func (s *mosSuite) TestJmp() {
 
 func (s *mosSuite) TestJsr() {
 	s.Run("moves PC register to the new location", func() {
+		// JSR reads the high byte of the address last, after it pushes to
+		// the stack
+		s.cpu.Set(execPC+2, uint8(execAddr>>8))
 		s.op(mos.Jsr, with{pc: execPC, addr: execAddr})
 		s.Equal(execAddr, s.cpu.PC)
 	})
@@ -0,0 +1,170 @@
+package mos
+
+import "github.com/pevans/erc/internal/metrics"
+
+// The instructions in this file are the undocumented ones of the NMOS 6502.
+// They fall out of how the chip decodes opcodes: most of them run two
+// documented instructions at once, which is why many are described below in
+// terms of those.
+
+// Alr implements the ALR instruction, which is AND #imm followed by LSR A.
+func Alr(c *CPU) {
+	c.A &= c.EffVal
+	c.ApplyStatus(c.A&0x1 > 0, CARRY)
+	c.A >>= 1
+	c.ApplyNZ(c.A)
+}
+
+// Anc implements the ANC instruction, which is AND #imm, but which also
+// copies the negative flag into the carry flag.
+func Anc(c *CPU) {
+	c.A &= c.EffVal
+	c.ApplyNZ(c.A)
+	c.ApplyStatus(c.A&0x80 > 0, CARRY)
+}
+
+// Arr implements the ARR instruction, which is AND #imm followed by ROR A,
+// except that the carry and overflow flags are set from bits 6 and 5 of the
+// result. In decimal mode, things are a good deal stranger.
+func Arr(c *CPU) {
+	val := c.A & c.EffVal
+	res := val >> 1
+	if c.P&CARRY > 0 {
+		res |= 0x80
+	}
+
+	if c.P&DECIMAL == 0 {
+		c.A = res
+		c.ApplyNZ(res)
+		c.ApplyStatus(res&0x40 > 0, CARRY)
+		c.ApplyStatus((res&0x40 > 0) != (res&0x20 > 0), OVERFLOW)
+		return
+	}
+
+	// The flags here reflect the rotated value, before we fix it up as
+	// though it were a decimal number
+	c.ApplyNZ(res)
+	c.ApplyStatus((res^val)&0x40 > 0, OVERFLOW)
+
+	if (val&0xF)+(val&0x1) > 5 {
+		res = (res & 0xF0) | ((res + 6) & 0x0F)
+	}
+
+	carry := uint16(val&0xF0)+uint16(val&0x10) > 0x50
+	if carry {
+		res += 0x60
+	}
+
+	c.ApplyStatus(carry, CARRY)
+	c.A = res
+}
+
+// Dcp implements the DCP instruction, which is DEC followed by CMP.
+func Dcp(c *CPU) {
+	res := c.EffVal - 1
+	c.modify(res)
+
+	c.EffVal = res
+	Compare(c, c.A)
+}
+
+// Isc implements the ISC instruction, which is INC followed by SBC.
+func Isc(c *CPU) {
+	res := c.EffVal + 1
+	c.modify(res)
+
+	c.EffVal = res
+	Sbc(c)
+}
+
+// Jam implements the JAM instruction, which locks up the processor. Nothing
+// short of a reset will get it going again, so we simply leave the PC where
+// it is.
+func Jam(c *CPU) {
+	metrics.Increment("instruction_jam", 1)
+}
+
+// Las implements the LAS instruction, which ANDs the effective value with
+// the stack pointer and saves the result in A, X and S.
+func Las(c *CPU) {
+	res := c.EffVal & c.S
+
+	c.A = res
+	c.X = res
+	c.S = res
+	c.ApplyNZ(res)
+}
+
+// Lax implements the LAX instruction, which is LDA and LDX at once.
+func Lax(c *CPU) {
+	c.A = c.EffVal
+	c.X = c.EffVal
+	c.ApplyNZ(c.A)
+}
+
+// Rla implements the RLA instruction, which is ROL followed by AND.
+func Rla(c *CPU) {
+	res := c.EffVal << 1
+	if c.P&CARRY > 0 {
+		res |= 0x1
+	}
+
+	c.ApplyStatus(c.EffVal&0x80 > 0, CARRY)
+	c.modify(res)
+
+	c.A &= res
+	c.ApplyNZ(c.A)
+}
+
+// Rra implements the RRA instruction, which is ROR followed by ADC. The
+// carry that ROR shifts out is the carry that ADC adds in.
+func Rra(c *CPU) {
+	res := c.EffVal >> 1
+	if c.P&CARRY > 0 {
+		res |= 0x80
+	}
+
+	c.ApplyStatus(c.EffVal&0x1 > 0, CARRY)
+	c.modify(res)
+
+	c.EffVal = res
+	Adc(c)
+}
+
+// Sax implements the SAX instruction, which stores A AND X at the effective
+// address. No flags are affected.
+func Sax(c *CPU) {
+	c.Set(c.EffAddr, c.A&c.X)
+}
+
+// Sbx implements the SBX instruction, which subtracts the effective value
+// from A AND X (without borrow), and saves the result in X. The flags are set
+// as they would be for CMP.
+func Sbx(c *CPU) {
+	base := c.A & c.X
+
+	Compare(c, base)
+	c.X = base - c.EffVal
+}
+
+// Slo implements the SLO instruction, which is ASL followed by ORA.
+func Slo(c *CPU) {
+	res := c.EffVal << 1
+
+	c.ApplyStatus(c.EffVal&0x80 > 0, CARRY)
+	c.modify(res)
+
+	c.A |= res
+	c.ApplyNZ(c.A)
+}
+
+// Sre implements the SRE instruction, which is LSR followed by EOR.
+func Sre(c *CPU) {
+	res := c.EffVal >> 1
+
+	c.ApplyStatus(c.EffVal&0x1 > 0, CARRY)
+	c.modify(res)
+
+	c.A ^= res
+	c.ApplyNZ(c.A)
+}
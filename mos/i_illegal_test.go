@@ -0,0 +1,173 @@
+package mos_test
+
+import (
+	"github.com/pevans/erc/mos"
+)
+
+func (s *mosSuite) TestAlr() {
+	s.cpu.A = 0xFF
+	s.cpu.EffVal = 0x0F
+	s.cpu.P = 0
+	mos.Alr(s.cpu)
+
+	s.Equal(uint8(0x07), s.cpu.A)
+	s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+	s.Equal(uint8(0), s.cpu.P&mos.NEGATIVE)
+}
+
+func (s *mosSuite) TestAnc() {
+	s.cpu.A = 0xF0
+	s.cpu.EffVal = 0x80
+	s.cpu.P = 0
+	mos.Anc(s.cpu)
+
+	s.Equal(uint8(0x80), s.cpu.A)
+	s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+	s.Equal(mos.NEGATIVE, s.cpu.P&mos.NEGATIVE)
+}
+
+func (s *mosSuite) TestArr() {
+	s.Run("binary mode sets carry and overflow from bits 6 and 5", func() {
+		s.cpu.A = 0xFF
+		s.cpu.EffVal = 0x80
+		s.cpu.P = mos.CARRY
+		mos.Arr(s.cpu)
+
+		// (0xFF & 0x80) >> 1 | 0x80 = 0xC0
+		s.Equal(uint8(0xC0), s.cpu.A)
+		s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+		s.Equal(mos.OVERFLOW, s.cpu.P&mos.OVERFLOW)
+		s.Equal(mos.NEGATIVE, s.cpu.P&mos.NEGATIVE)
+	})
+
+	s.Run("decimal mode adjusts the result", func() {
+		s.cpu.A = 0xFF
+		s.cpu.EffVal = 0xFF
+		s.cpu.P = mos.DECIMAL
+		mos.Arr(s.cpu)
+
+		// 0xFF >> 1 = 0x7F; the low digit becomes (0x7F + 6) & 0xF = 5, and
+		// then we add 0x60 to the high digit.
+		s.Equal(uint8(0xD5), s.cpu.A)
+		s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+	})
+}
+
+func (s *mosSuite) TestDcp() {
+	s.cpu.EffAddr = 0x10
+	s.cpu.EffVal = 0x43
+	s.cpu.A = 0x42
+	s.cpu.P = 0
+	mos.Dcp(s.cpu)
+
+	s.Equal(uint8(0x42), s.cpu.Get(0x10))
+	s.Equal(mos.ZERO, s.cpu.P&mos.ZERO)
+	s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+}
+
+func (s *mosSuite) TestIsc() {
+	s.cpu.EffAddr = 0x10
+	s.cpu.EffVal = 0x0F
+	s.cpu.A = 0x20
+	s.cpu.P = mos.CARRY
+	mos.Isc(s.cpu)
+
+	s.Equal(uint8(0x10), s.cpu.Get(0x10))
+	s.Equal(uint8(0x10), s.cpu.A)
+	s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+}
+
+func (s *mosSuite) TestJam() {
+	s.cpu.PC = 0x300
+	mos.Jam(s.cpu)
+
+	s.Equal(uint16(0x300), s.cpu.PC)
+}
+
+func (s *mosSuite) TestLas() {
+	s.cpu.S = 0xF0
+	s.cpu.EffVal = 0x3C
+	mos.Las(s.cpu)
+
+	s.Equal(uint8(0x30), s.cpu.A)
+	s.Equal(uint8(0x30), s.cpu.X)
+	s.Equal(uint8(0x30), s.cpu.S)
+}
+
+func (s *mosSuite) TestLax() {
+	s.cpu.EffVal = 0x80
+	s.cpu.P = 0
+	mos.Lax(s.cpu)
+
+	s.Equal(uint8(0x80), s.cpu.A)
+	s.Equal(uint8(0x80), s.cpu.X)
+	s.Equal(mos.NEGATIVE, s.cpu.P&mos.NEGATIVE)
+}
+
+func (s *mosSuite) TestRla() {
+	s.cpu.EffAddr = 0x10
+	s.cpu.EffVal = 0x81
+	s.cpu.A = 0xFF
+	s.cpu.P = mos.CARRY
+	mos.Rla(s.cpu)
+
+	s.Equal(uint8(0x03), s.cpu.Get(0x10))
+	s.Equal(uint8(0x03), s.cpu.A)
+	s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+}
+
+func (s *mosSuite) TestRra() {
+	s.cpu.EffAddr = 0x10
+	s.cpu.EffVal = 0x03
+	s.cpu.A = 0x10
+	s.cpu.P = 0
+	mos.Rra(s.cpu)
+
+	// ROR gives us 0x01 with the carry set, and ADC adds both to A
+	s.Equal(uint8(0x01), s.cpu.Get(0x10))
+	s.Equal(uint8(0x12), s.cpu.A)
+}
+
+func (s *mosSuite) TestSax() {
+	s.cpu.EffAddr = 0x10
+	s.cpu.A = 0xF3
+	s.cpu.X = 0x3F
+	mos.Sax(s.cpu)
+
+	s.Equal(uint8(0x33), s.cpu.Get(0x10))
+}
+
+func (s *mosSuite) TestSbx() {
+	s.cpu.A = 0xF0
+	s.cpu.X = 0x3F
+	s.cpu.EffVal = 0x10
+	s.cpu.P = 0
+	mos.Sbx(s.cpu)
+
+	s.Equal(uint8(0x20), s.cpu.X)
+	s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+}
+
+func (s *mosSuite) TestSlo() {
+	s.cpu.EffAddr = 0x10
+	s.cpu.EffVal = 0x81
+	s.cpu.A = 0x10
+	s.cpu.P = 0
+	mos.Slo(s.cpu)
+
+	s.Equal(uint8(0x02), s.cpu.Get(0x10))
+	s.Equal(uint8(0x12), s.cpu.A)
+	s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+}
+
+func (s *mosSuite) TestSre() {
+	s.cpu.EffAddr = 0x10
+	s.cpu.EffVal = 0x81
+	s.cpu.A = 0x01
+	s.cpu.P = 0
+	mos.Sre(s.cpu)
+
+	s.Equal(uint8(0x40), s.cpu.Get(0x10))
+	s.Equal(uint8(0x41), s.cpu.A)
+	s.Equal(mos.CARRY, s.cpu.P&mos.CARRY)
+}
@@ -46,6 +46,7 @@ func Phy(c *CPU) {
 // Pla implements the PLA (pull A) instruction, which pops the top of the
 // stack and saves that value in the A register.
 func Pla(c *CPU) {
+	c.stackDummyRead()
 	c.A = c.PopStack()
 	c.ApplyNZ(c.A)
 }
@@ -53,12 +54,14 @@ func Pla(c *CPU) {
 // Plp implements the PLP (pull P) instruction, which pops the top of the
 // stack and saves that value in the P register.
 func Plp(c *CPU) {
+	c.stackDummyRead()
 	c.P = c.PopStack()
 }
 
 // Plx implements the PLX (pull X) instruction, which pops the top of the
 // stack and saves that value in the X register.
 func Plx(c *CPU) {
+	c.stackDummyRead()
 	c.X = c.PopStack()
 	c.ApplyNZ(c.X)
 }
@@ -66,6 +69,7 @@ func Plx(c *CPU) {
 // Ply implements the PLY (pull Y) instruction, which pops the top of the
 // stack and saves that value in the Y register.
 func Ply(c *CPU) {
+	c.stackDummyRead()
 	c.Y = c.PopStack()
 	c.ApplyNZ(c.Y)
 }
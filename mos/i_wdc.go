@@ -0,0 +1,149 @@
+package mos
+
+import (
+	"github.com/pevans/erc/a2/a2state"
+	"github.com/pevans/erc/internal/metrics"
+)
+
+// The instructions in this file are only found in the Rockwell and WDC
+// versions of the 65C02.
+
+// resetBit clears the given bit of the effective value and saves it at the
+// effective address. Like any other read-modify-write instruction, this
+// takes a dummy access before the write.
+func (c *CPU) resetBit(bit uint8) {
+	c.modify(c.EffVal &^ (1 << bit))
+}
+
+// setBit sets the given bit of the effective value and saves it at the
+// effective address.
+func (c *CPU) setBit(bit uint8) {
+	c.modify(c.EffVal | (1 << bit))
+}
+
+// branchOnBit jumps to EffAddr if the given bit of the effective value is
+// set (or clear, if set is false). This works like jumpIf, except that the
+// instruction is a byte longer.
+func (c *CPU) branchOnBit(bit uint8, set bool) {
+	if (c.EffVal&(1<<bit) > 0) == set {
+		c.branch(c.PC + 3)
+		return
+	}
+
+	if c.State.Bool(a2state.DebugImage) {
+		c.Speculate(c.EffAddr)
+	}
+
+	c.PC += 3
+}
+
+// Rmb0 implements the RMB0 (reset memory bit 0) instruction.
+func Rmb0(c *CPU) { c.resetBit(0) }
+
+// Rmb1 implements the RMB1 (reset memory bit 1) instruction.
+func Rmb1(c *CPU) { c.resetBit(1) }
+
+// Rmb2 implements the RMB2 (reset memory bit 2) instruction.
+func Rmb2(c *CPU) { c.resetBit(2) }
+
+// Rmb3 implements the RMB3 (reset memory bit 3) instruction.
+func Rmb3(c *CPU) { c.resetBit(3) }
+
+// Rmb4 implements the RMB4 (reset memory bit 4) instruction.
+func Rmb4(c *CPU) { c.resetBit(4) }
+
+// Rmb5 implements the RMB5 (reset memory bit 5) instruction.
+func Rmb5(c *CPU) { c.resetBit(5) }
+
+// Rmb6 implements the RMB6 (reset memory bit 6) instruction.
+func Rmb6(c *CPU) { c.resetBit(6) }
+
+// Rmb7 implements the RMB7 (reset memory bit 7) instruction.
+func Rmb7(c *CPU) { c.resetBit(7) }
+
+// Smb0 implements the SMB0 (set memory bit 0) instruction.
+func Smb0(c *CPU) { c.setBit(0) }
+
+// Smb1 implements the SMB1 (set memory bit 1) instruction.
+func Smb1(c *CPU) { c.setBit(1) }
+
+// Smb2 implements the SMB2 (set memory bit 2) instruction.
+func Smb2(c *CPU) { c.setBit(2) }
+
+// Smb3 implements the SMB3 (set memory bit 3) instruction.
+func Smb3(c *CPU) { c.setBit(3) }
+
+// Smb4 implements the SMB4 (set memory bit 4) instruction.
+func Smb4(c *CPU) { c.setBit(4) }
+
+// Smb5 implements the SMB5 (set memory bit 5) instruction.
+func Smb5(c *CPU) { c.setBit(5) }
+
+// Smb6 implements the SMB6 (set memory bit 6) instruction.
+func Smb6(c *CPU) { c.setBit(6) }
+
+// Smb7 implements the SMB7 (set memory bit 7) instruction.
+func Smb7(c *CPU) { c.setBit(7) }
+
+// Bbr0 implements the BBR0 (branch on bit 0 reset) instruction.
+func Bbr0(c *CPU) { c.branchOnBit(0, false) }
+
+// Bbr1 implements the BBR1 (branch on bit 1 reset) instruction.
+func Bbr1(c *CPU) { c.branchOnBit(1, false) }
+
+// Bbr2 implements the BBR2 (branch on bit 2 reset) instruction.
+func Bbr2(c *CPU) { c.branchOnBit(2, false) }
+
+// Bbr3 implements the BBR3 (branch on bit 3 reset) instruction.
+func Bbr3(c *CPU) { c.branchOnBit(3, false) }
+
+// Bbr4 implements the BBR4 (branch on bit 4 reset) instruction.
+func Bbr4(c *CPU) { c.branchOnBit(4, false) }
+
+// Bbr5 implements the BBR5 (branch on bit 5 reset) instruction.
+func Bbr5(c *CPU) { c.branchOnBit(5, false) }
+
+// Bbr6 implements the BBR6 (branch on bit 6 reset) instruction.
+func Bbr6(c *CPU) { c.branchOnBit(6, false) }
+
+// Bbr7 implements the BBR7 (branch on bit 7 reset) instruction.
+func Bbr7(c *CPU) { c.branchOnBit(7, false) }
+
+// Bbs0 implements the BBS0 (branch on bit 0 set) instruction.
+func Bbs0(c *CPU) { c.branchOnBit(0, true) }
+
+// Bbs1 implements the BBS1 (branch on bit 1 set) instruction.
+func Bbs1(c *CPU) { c.branchOnBit(1, true) }
+
+// Bbs2 implements the BBS2 (branch on bit 2 set) instruction.
+func Bbs2(c *CPU) { c.branchOnBit(2, true) }
+
+// Bbs3 implements the BBS3 (branch on bit 3 set) instruction.
+func Bbs3(c *CPU) { c.branchOnBit(3, true) }
+
+// Bbs4 implements the BBS4 (branch on bit 4 set) instruction.
+func Bbs4(c *CPU) { c.branchOnBit(4, true) }
+
+// Bbs5 implements the BBS5 (branch on bit 5 set) instruction.
+func Bbs5(c *CPU) { c.branchOnBit(5, true) }
+
+// Bbs6 implements the BBS6 (branch on bit 6 set) instruction.
+func Bbs6(c *CPU) { c.branchOnBit(6, true) }
+
+// Bbs7 implements the BBS7 (branch on bit 7 set) instruction.
+func Bbs7(c *CPU) { c.branchOnBit(7, true) }
+
+// Stp implements the STP (stop) instruction, which stops the processor until
+// it is reset. We leave the PC where it is, so that we just keep on stopping.
+func Stp(c *CPU) {
+	metrics.Increment("instruction_stp", 1)
+	c.dummyRead(c.PC + 1)
+}
+
+// Wai implements the WAI (wait for interrupt) instruction. We don't emulate
+// interrupts, so like STP, we leave the PC where it is; the processor will
+// wait here until it is reset.
+func Wai(c *CPU) {
+	metrics.Increment("instruction_wai", 1)
+	c.dummyRead(c.PC + 1)
+}
@@ -12,19 +12,25 @@ import (
 // will swap operands with labels (e.g. for subroutines) and variable names
 // (such as those defined with EQU).
 func ExplainInstruction(line *elog.Instruction, pc uint16, effAddr uint16) {
+	CMOS65C02.ExplainInstruction(line, pc, effAddr)
+}
+
+// ExplainInstruction explains the given line as an instruction of this
+// variant. See the ExplainInstruction function for more.
+func (v Variant) ExplainInstruction(line *elog.Instruction, pc uint16, effAddr uint16) {
 	addr := int(effAddr)
-	addrMode := addrModes[line.Opcode]
+	addrMode := v.AddrMode(line.Opcode)
 
-	line.EndOfBlock = endsBlock(line.Opcode)
+	line.EndOfBlock = v.endsBlock(line.Opcode)
 
-	if maybeRoutine(line.Opcode) {
+	if v.maybeRoutine(line.Opcode) {
 		if routine := a2sym.Subroutine(addr); routine != "" {
 			line.PreparedOperand = routine
 			return
 		}
 	}
 
-	if OpcodeReadsMemory(line.Opcode) {
+	if v.ReadsMemory(line.Opcode) {
 		if rs := a2sym.ReadSwitch(addr); rs.Mode != a2sym.ModeNone {
 			line.Comment = rs.String()
 		}
@@ -61,21 +67,23 @@ func ExplainInstruction(line *elog.Instruction, pc uint16, effAddr uint16) {
 }
 
 // maybeRoutine returns true if the opcode represents a jump in control flow
-func maybeRoutine(opcode uint8) bool {
-	return opcode == 0x20 || // JSR
-		opcode == 0x4C || // JMP (ABS)
-		opcode == 0x6C || // JMP (IND)
-		opcode == 0x7C || // JMP (ABX)
-		addrModes[opcode] == AmREL // any branch
+func (v Variant) maybeRoutine(opcode uint8) bool {
+	switch v.InstructionName(opcode) {
+	case "JSR", "JMP":
+		return true
+	}
+
+	return v.AddrMode(opcode) == AmREL // any branch
 }
 
 // endsBlock returns true if we think this instruction represents the logical
 // "end" of some block of code. Branches don't count, but returns do (RTI,
 // RTS), as do JMPs.
-func endsBlock(opcode uint8) bool {
-	return opcode == 0x40 || // RTI
-		opcode == 0x60 || // RTS
-		opcode == 0x4C || // JMP (ABS)
-		opcode == 0x6C || // JMP (IND)
-		opcode == 0x7C // JMP (ABX)
+func (v Variant) endsBlock(opcode uint8) bool {
+	switch v.InstructionName(opcode) {
+	case "RTI", "RTS", "JMP":
+		return true
+	}
+
+	return false
 }
@@ -1,6 +1,10 @@
 package mos
 
-import "github.com/pevans/erc/a2/a2save"
+import (
+	"fmt"
+
+	"github.com/pevans/erc/a2/a2save"
+)
 
 // Snapshot returns a snapshot of the CPU state for serialization.
 func (c *CPU) Snapshot() *a2save.CPUState {
@@ -19,11 +23,19 @@ func (c *CPU) Snapshot() *a2save.CPUState {
 		EffVal:       c.EffVal,
 		AddrMode:     c.AddrMode,
 		ReadOp:       c.ReadOp,
+		Variant:      int(c.Variant),
 	}
 }
 
-// Restore restores the CPU state from a snapshot.
-func (c *CPU) Restore(state *a2save.CPUState) {
+// Restore restores the CPU state from a snapshot. It returns an error (and
+// leaves the CPU as it was) if the snapshot is of a variant we don't know,
+// as might be the case if the snapshot is corrupt or is from a later
+// version.
+func (c *CPU) Restore(state *a2save.CPUState) error {
+	if state.Variant < 0 || state.Variant >= int(numVariants) {
+		return fmt.Errorf("unknown cpu variant: %d", state.Variant)
+	}
+
 	c.PC = state.PC
 	c.LastPC = state.LastPC
 	c.A = state.A
@@ -38,4 +50,12 @@ func (c *CPU) Restore(state *a2save.CPUState) {
 	c.EffVal = state.EffVal
 	c.AddrMode = state.AddrMode
 	c.ReadOp = state.ReadOp
+	c.Variant = Variant(state.Variant)
+
+	// We don't know what calls were made before the snapshot
+	if c.Calls != nil {
+		c.Calls.Reset()
+	}
+
+	return nil
 }
@@ -24,7 +24,7 @@ func (c *CPU) Speculate(addr uint16) {
 
 		// If this is a branch, we want to speculate on what might happen if
 		// the branch is taken, but not if the branch is ignored.
-		if isBranch(line.Opcode) {
+		if c.Variant.AddrMode(line.Opcode) == AmREL {
 			// This is pretty funky. OperandLSB is an 8-bit number that is
 			// meant to be signed, so we convert to int8 to allow the most
 			// significant bit to retain its signed-ness.
@@ -39,8 +39,18 @@ func (c *CPU) Speculate(addr uint16) {
 			return
 		}
 
+		// BBR and BBS are the same, except that the offset is the second
+		// byte of the operand, and the instruction is three bytes long.
+		if c.Variant.AddrMode(line.Opcode) == AmZPR {
+			offset := int8(*line.OperandMSB)
+			branchAddr := int16(addr) + int16(offset) + 3
+
+			c.Speculate(uint16(branchAddr))
+			return
+		}
+
 		// There are several opcodes which signal we should go no further
-		if shouldEndSpeculation(line.Opcode) {
+		if c.Variant.shouldEndSpeculation(line.Opcode) {
 			return
 		}
 
@@ -49,31 +59,29 @@ func (c *CPU) Speculate(addr uint16) {
 	}
 }
 
-func isBranch(opcode uint8) bool {
-	return addrModes[opcode] == AmREL
-}
-
-func shouldEndSpeculation(opcode uint8) bool {
-	switch opcode {
-	case 0x00:
-		// These would be BRK, which is unusual, and _probably_ a bad opcode
+func (v Variant) shouldEndSpeculation(opcode uint8) bool {
+	switch v.InstructionName(opcode) {
+	case "BRK":
+		// This is unusual, and _probably_ a bad opcode
 		return true
 
-	case 0x40, 0x60:
-		// These would return control to something on the stack (RTI, RTS)
+	case "RTI", "RTS":
+		// These return control to something on the stack
 		return true
 
-	case 0x20, 0x4C, 0x6C, 0x7C:
+	case "JMP", "JSR":
 		// Any JMPs or JSRs are calls which we can't know would return control
 		// back to the caller
 		return true
-	}
 
-	// These opcodes aren't used. They are encoded as NOPs but the "true" NOP
-	// opcode is 0xEA.
-	switch opcode & 0xF {
-	case 0x3, 0x7, 0xB, 0xF:
+	case "JAM", "STP":
+		// The processor locks up here, so there's nothing after it
 		return true
+
+	case "NOP":
+		// These opcodes aren't used. They are encoded as NOPs but the "true"
+		// NOP opcode is 0xEA.
+		return opcode != 0xEA
 	}
 
 	return false
@@ -87,9 +95,9 @@ func (c *CPU) SpeculateInstuction(addr uint16) (*elog.Instruction, uint16) {
 		Speculative: true,
 	}
 
-	opcode := c.Get(addr)
+	opcode := c.peek(addr)
 
-	line.EndOfBlock = endsBlock(opcode)
+	line.EndOfBlock = c.Variant.endsBlock(opcode)
 
 	// Since we need to point to an integer, we need to make a copy of addr,
 	// then reference it
@@ -97,23 +105,23 @@ func (c *CPU) SpeculateInstuction(addr uint16) (*elog.Instruction, uint16) {
 	line.Address = &lineAddress
 
 	line.Opcode = opcode
-	line.Instruction = instructionNames[opcode]
+	line.Instruction = c.Variant.InstructionName(opcode)
 
-	width := OperandSize(opcode)
+	width := c.Variant.OperandSize(opcode)
 
 	switch width {
 	case 2:
-		line.Operand = c.Get16(addr + 1)
+		line.Operand = c.peek16(addr + 1)
 		lsb, msb := uint8(line.Operand&0xFF), uint8(line.Operand>>8)
 		line.OperandLSB = &lsb
 		line.OperandMSB = &msb
 	case 1:
-		line.Operand = uint16(c.Get(addr + 1))
+		line.Operand = uint16(c.peek(addr + 1))
 		lsb := uint8(line.Operand)
 		line.OperandLSB = &lsb
 	}
 
-	PrepareOperand(line, addr)
+	c.Variant.PrepareOperand(line, addr)
 	// ExplainInstruction(line, addr, addr)
 
 	return line, width + 1
@@ -0,0 +1,141 @@
+package mos
+
+// These tables describe the NMOS 6502, which is the processor in the Apple II,
+// II+ and the original //e. Opcodes that the 65C02 would go on to define are
+// (mostly) undocumented here, and the stable ones do something useful enough
+// that software came to rely on them. The unstable ones (ANE, LXA, SHA, SHX,
+// SHY and TAS) behave differently from chip to chip, so we treat them as NOPs
+// of the same length.
+
+// nmosInstructions maps NMOS opcodes to instruction functions.
+//
+//	00   01   02   03   04   05   06   07   08   09   0A   0B   0C   0D   0E   0F      gocomments:noformat
+var nmosInstructions = [256]Instruction{
+	Brk, Ora, Jam, Slo, Nop, Ora, Asl, Slo, Php, Ora, Asl, Anc, Nop, Ora, Asl, Slo, // 0x
+	Bpl, Ora, Jam, Slo, Nop, Ora, Asl, Slo, Clc, Ora, Nop, Slo, Nop, Ora, Asl, Slo, // 1x
+	Jsr, And, Jam, Rla, Bit, And, Rol, Rla, Plp, And, Rol, Anc, Bit, And, Rol, Rla, // 2x
+	Bmi, And, Jam, Rla, Nop, And, Rol, Rla, Sec, And, Nop, Rla, Nop, And, Rol, Rla, // 3x
+	Rti, Eor, Jam, Sre, Nop, Eor, Lsr, Sre, Pha, Eor, Lsr, Alr, Jmp, Eor, Lsr, Sre, // 4x
+	Bvc, Eor, Jam, Sre, Nop, Eor, Lsr, Sre, Cli, Eor, Nop, Sre, Nop, Eor, Lsr, Sre, // 5x
+	Rts, Adc, Jam, Rra, Nop, Adc, Ror, Rra, Pla, Adc, Ror, Arr, Jmp, Adc, Ror, Rra, // 6x
+	Bvs, Adc, Jam, Rra, Nop, Adc, Ror, Rra, Sei, Adc, Nop, Rra, Nop, Adc, Ror, Rra, // 7x
+	Nop, Sta, Nop, Sax, Sty, Sta, Stx, Sax, Dey, Nop, Txa, Nop, Sty, Sta, Stx, Sax, // 8x
+	Bcc, Sta, Jam, Nop, Sty, Sta, Stx, Sax, Tya, Sta, Txs, Nop, Nop, Sta, Nop, Nop, // 9x
+	Ldy, Lda, Ldx, Lax, Ldy, Lda, Ldx, Lax, Tay, Lda, Tax, Nop, Ldy, Lda, Ldx, Lax, // Ax
+	Bcs, Lda, Jam, Lax, Ldy, Lda, Ldx, Lax, Clv, Lda, Tsx, Las, Ldy, Lda, Ldx, Lax, // Bx
+	Cpy, Cmp, Nop, Dcp, Cpy, Cmp, Dec, Dcp, Iny, Cmp, Dex, Sbx, Cpy, Cmp, Dec, Dcp, // Cx
+	Bne, Cmp, Jam, Dcp, Nop, Cmp, Dec, Dcp, Cld, Cmp, Nop, Dcp, Nop, Cmp, Dec, Dcp, // Dx
+	Cpx, Sbc, Nop, Isc, Cpx, Sbc, Inc, Isc, Inx, Sbc, Nop, Sbc, Cpx, Sbc, Inc, Isc, // Ex
+	Beq, Sbc, Jam, Isc, Nop, Sbc, Inc, Isc, Sed, Sbc, Nop, Isc, Nop, Sbc, Inc, Isc, // Fx
+}
+
+// nmosInstructionNames maps NMOS opcodes to the names of their instructions.
+var nmosInstructionNames = [256]string{
+	"BRK", "ORA", "JAM", "SLO", "NOP", "ORA", "ASL", "SLO", "PHP", "ORA", "ASL", "ANC", "NOP", "ORA", "ASL", "SLO", // 0x
+	"BPL", "ORA", "JAM", "SLO", "NOP", "ORA", "ASL", "SLO", "CLC", "ORA", "NOP", "SLO", "NOP", "ORA", "ASL", "SLO", // 1x
+	"JSR", "AND", "JAM", "RLA", "BIT", "AND", "ROL", "RLA", "PLP", "AND", "ROL", "ANC", "BIT", "AND", "ROL", "RLA", // 2x
+	"BMI", "AND", "JAM", "RLA", "NOP", "AND", "ROL", "RLA", "SEC", "AND", "NOP", "RLA", "NOP", "AND", "ROL", "RLA", // 3x
+	"RTI", "EOR", "JAM", "SRE", "NOP", "EOR", "LSR", "SRE", "PHA", "EOR", "LSR", "ALR", "JMP", "EOR", "LSR", "SRE", // 4x
+	"BVC", "EOR", "JAM", "SRE", "NOP", "EOR", "LSR", "SRE", "CLI", "EOR", "NOP", "SRE", "NOP", "EOR", "LSR", "SRE", // 5x
+	"RTS", "ADC", "JAM", "RRA", "NOP", "ADC", "ROR", "RRA", "PLA", "ADC", "ROR", "ARR", "JMP", "ADC", "ROR", "RRA", // 6x
+	"BVS", "ADC", "JAM", "RRA", "NOP", "ADC", "ROR", "RRA", "SEI", "ADC", "NOP", "RRA", "NOP", "ADC", "ROR", "RRA", // 7x
+	"NOP", "STA", "NOP", "SAX", "STY", "STA", "STX", "SAX", "DEY", "NOP", "TXA", "NOP", "STY", "STA", "STX", "SAX", // 8x
+	"BCC", "STA", "JAM", "NOP", "STY", "STA", "STX", "SAX", "TYA", "STA", "TXS", "NOP", "NOP", "STA", "NOP", "NOP", // 9x
+	"LDY", "LDA", "LDX", "LAX", "LDY", "LDA", "LDX", "LAX", "TAY", "LDA", "TAX", "NOP", "LDY", "LDA", "LDX", "LAX", // Ax
+	"BCS", "LDA", "JAM", "LAX", "LDY", "LDA", "LDX", "LAX", "CLV", "LDA", "TSX", "LAS", "LDY", "LDA", "LDX", "LAX", // Bx
+	"CPY", "CMP", "NOP", "DCP", "CPY", "CMP", "DEC", "DCP", "INY", "CMP", "DEX", "SBX", "CPY", "CMP", "DEC", "DCP", // Cx
+	"BNE", "CMP", "JAM", "DCP", "NOP", "CMP", "DEC", "DCP", "CLD", "CMP", "NOP", "DCP", "NOP", "CMP", "DEC", "DCP", // Dx
+	"CPX", "SBC", "NOP", "ISC", "CPX", "SBC", "INC", "ISC", "INX", "SBC", "NOP", "SBC", "CPX", "SBC", "INC", "ISC", // Ex
+	"BEQ", "SBC", "JAM", "ISC", "NOP", "SBC", "INC", "ISC", "SED", "SBC", "NOP", "ISC", "NOP", "SBC", "INC", "ISC", // Fx
+}
+
+// nmosCycles maps NMOS opcodes to the cycles they consume, not counting any
+// penalty for crossing a page boundary.
+//
+//	0  1  2  3  4  5  6  7  8  9  A  B  C  D  E  F     gocomments:noformat
+var nmosCycles = [256]uint8{
+	7, 6, 2, 8, 3, 3, 5, 5, 3, 2, 2, 2, 4, 4, 6, 6, // 0x
+	2, 5, 2, 8, 4, 4, 6, 6, 2, 4, 2, 7, 4, 4, 7, 7, // 1x
+	6, 6, 2, 8, 3, 3, 5, 5, 4, 2, 2, 2, 4, 4, 6, 6, // 2x
+	2, 5, 2, 8, 4, 4, 6, 6, 2, 4, 2, 7, 4, 4, 7, 7, // 3x
+	6, 6, 2, 8, 3, 3, 5, 5, 3, 2, 2, 2, 3, 4, 6, 6, // 4x
+	2, 5, 2, 8, 4, 4, 6, 6, 2, 4, 2, 7, 4, 4, 7, 7, // 5x
+	6, 6, 2, 8, 3, 3, 5, 5, 4, 2, 2, 2, 5, 4, 6, 6, // 6x
+	2, 5, 2, 8, 4, 4, 6, 6, 2, 4, 2, 7, 4, 4, 7, 7, // 7x
+	2, 6, 2, 6, 3, 3, 3, 3, 2, 2, 2, 2, 4, 4, 4, 4, // 8x
+	2, 6, 2, 6, 4, 4, 4, 4, 2, 5, 2, 5, 5, 5, 5, 5, // 9x
+	2, 6, 2, 6, 3, 3, 3, 3, 2, 2, 2, 2, 4, 4, 4, 4, // Ax
+	2, 5, 2, 5, 4, 4, 4, 4, 2, 4, 2, 4, 4, 4, 4, 4, // Bx
+	2, 6, 2, 8, 3, 3, 5, 5, 2, 2, 2, 2, 4, 4, 6, 6, // Cx
+	2, 5, 2, 8, 4, 4, 6, 6, 2, 4, 2, 7, 4, 4, 7, 7, // Dx
+	2, 6, 2, 8, 3, 3, 5, 5, 2, 2, 2, 2, 4, 4, 6, 6, // Ex
+	2, 5, 2, 8, 4, 4, 6, 6, 2, 4, 2, 7, 4, 4, 7, 7, // Fx
+}
+
+// nmosAddrModeFuncs maps NMOS opcodes to address mode functions.
+//
+//	00   01   02   03   04   05   06   07   08   09   0A   0B   0C   0D   0E   0F      gocomments:noformat
+var nmosAddrModeFuncs = [256]AddrMode{
+	Imp, Idx, Imp, Idx, Zpg, Zpg, Zpg, Zpg, Imp, Imm, Acc, Imm, Abs, Abs, Abs, Abs, // 0x
+	Rel, Idy, Imp, Idy, Zpx, Zpx, Zpx, Zpx, Imp, Aby, Imp, Aby, Abx, Abx, Abx, Abx, // 1x
+	Abj, Idx, Imp, Idx, Zpg, Zpg, Zpg, Zpg, Imp, Imm, Acc, Imm, Abs, Abs, Abs, Abs, // 2x
+	Rel, Idy, Imp, Idy, Zpx, Zpx, Zpx, Zpx, Imp, Aby, Imp, Aby, Abx, Abx, Abx, Abx, // 3x
+	Imp, Idx, Imp, Idx, Zpg, Zpg, Zpg, Zpg, Imp, Imm, Acc, Imm, Abs, Abs, Abs, Abs, // 4x
+	Rel, Idy, Imp, Idy, Zpx, Zpx, Zpx, Zpx, Imp, Aby, Imp, Aby, Abx, Abx, Abx, Abx, // 5x
+	Imp, Idx, Imp, Idx, Zpg, Zpg, Zpg, Zpg, Imp, Imm, Acc, Imm, Inw, Abs, Abs, Abs, // 6x
+	Rel, Idy, Imp, Idy, Zpx, Zpx, Zpx, Zpx, Imp, Aby, Imp, Aby, Abx, Abx, Abx, Abx, // 7x
+	Imm, Idx, Imm, Idx, Zpg, Zpg, Zpg, Zpg, Imp, Imm, Imp, Imm, Abs, Abs, Abs, Abs, // 8x
+	Rel, Idy, Imp, Idy, Zpx, Zpx, Zpy, Zpy, Imp, Aby, Imp, Aby, Abx, Abx, Aby, Aby, // 9x
+	Imm, Idx, Imm, Idx, Zpg, Zpg, Zpg, Zpg, Imp, Imm, Imp, Imm, Abs, Abs, Abs, Abs, // Ax
+	Rel, Idy, Imp, Idy, Zpx, Zpx, Zpy, Zpy, Imp, Aby, Imp, Aby, Abx, Abx, Aby, Aby, // Bx
+	Imm, Idx, Imm, Idx, Zpg, Zpg, Zpg, Zpg, Imp, Imm, Imp, Imm, Abs, Abs, Abs, Abs, // Cx
+	Rel, Idy, Imp, Idy, Zpx, Zpx, Zpx, Zpx, Imp, Aby, Imp, Aby, Abx, Abx, Abx, Abx, // Dx
+	Imm, Idx, Imm, Idx, Zpg, Zpg, Zpg, Zpg, Imp, Imm, Imp, Imm, Abs, Abs, Abs, Abs, // Ex
+	Rel, Idy, Imp, Idy, Zpx, Zpx, Zpx, Zpx, Imp, Aby, Imp, Aby, Abx, Abx, Abx, Abx, // Fx
+}
+
+// nmosAddrModes maps NMOS opcodes to address mode constants.
+//
+//	00     01     02     03     04     05     06     07     08     09     0A     0B     0C     0D     0E     0F        gocomments:noformat
+var nmosAddrModes = [256]int{
+	AmIMP, AmIDX, AmIMP, AmIDX, AmZPG, AmZPG, AmZPG, AmZPG, AmIMP, AmIMM, AmACC, AmIMM, AmABS, AmABS, AmABS, AmABS, // 0x
+	AmREL, AmIDY, AmIMP, AmIDY, AmZPX, AmZPX, AmZPX, AmZPX, AmIMP, AmABY, AmIMP, AmABY, AmABX, AmABX, AmABX, AmABX, // 1x
+	AmABS, AmIDX, AmIMP, AmIDX, AmZPG, AmZPG, AmZPG, AmZPG, AmIMP, AmIMM, AmACC, AmIMM, AmABS, AmABS, AmABS, AmABS, // 2x
+	AmREL, AmIDY, AmIMP, AmIDY, AmZPX, AmZPX, AmZPX, AmZPX, AmIMP, AmABY, AmIMP, AmABY, AmABX, AmABX, AmABX, AmABX, // 3x
+	AmIMP, AmIDX, AmIMP, AmIDX, AmZPG, AmZPG, AmZPG, AmZPG, AmIMP, AmIMM, AmACC, AmIMM, AmABS, AmABS, AmABS, AmABS, // 4x
+	AmREL, AmIDY, AmIMP, AmIDY, AmZPX, AmZPX, AmZPX, AmZPX, AmIMP, AmABY, AmIMP, AmABY, AmABX, AmABX, AmABX, AmABX, // 5x
+	AmIMP, AmIDX, AmIMP, AmIDX, AmZPG, AmZPG, AmZPG, AmZPG, AmIMP, AmIMM, AmACC, AmIMM, AmIND, AmABS, AmABS, AmABS, // 6x
+	AmREL, AmIDY, AmIMP, AmIDY, AmZPX, AmZPX, AmZPX, AmZPX, AmIMP, AmABY, AmIMP, AmABY, AmABX, AmABX, AmABX, AmABX, // 7x
+	AmIMM, AmIDX, AmIMM, AmIDX, AmZPG, AmZPG, AmZPG, AmZPG, AmIMP, AmIMM, AmIMP, AmIMM, AmABS, AmABS, AmABS, AmABS, // 8x
+	AmREL, AmIDY, AmIMP, AmIDY, AmZPX, AmZPX, AmZPY, AmZPY, AmIMP, AmABY, AmIMP, AmABY, AmABX, AmABX, AmABY, AmABY, // 9x
+	AmIMM, AmIDX, AmIMM, AmIDX, AmZPG, AmZPG, AmZPG, AmZPG, AmIMP, AmIMM, AmIMP, AmIMM, AmABS, AmABS, AmABS, AmABS, // Ax
+	AmREL, AmIDY, AmIMP, AmIDY, AmZPX, AmZPX, AmZPY, AmZPY, AmIMP, AmABY, AmIMP, AmABY, AmABX, AmABX, AmABY, AmABY, // Bx
+	AmIMM, AmIDX, AmIMM, AmIDX, AmZPG, AmZPG, AmZPG, AmZPG, AmIMP, AmIMM, AmIMP, AmIMM, AmABS, AmABS, AmABS, AmABS, // Cx
+	AmREL, AmIDY, AmIMP, AmIDY, AmZPX, AmZPX, AmZPX, AmZPX, AmIMP, AmABY, AmIMP, AmABY, AmABX, AmABX, AmABX, AmABX, // Dx
+	AmIMM, AmIDX, AmIMM, AmIDX, AmZPG, AmZPG, AmZPG, AmZPG, AmIMP, AmIMM, AmIMP, AmIMM, AmABS, AmABS, AmABS, AmABS, // Ex
+	AmREL, AmIDY, AmIMP, AmIDY, AmZPX, AmZPX, AmZPX, AmZPX, AmIMP, AmABY, AmIMP, AmABY, AmABX, AmABX, AmABX, AmABX, // Fx
+}
+
+// nmosOffsets maps NMOS opcodes to the number of bytes by which we advance
+// the PC register after executing them. JAM has an offset of zero because it
+// locks up the processor.
+//
+//	0  1  2  3  4  5  6  7  8  9  A  B  C  D  E  F     gocomments:noformat
+var nmosOffsets = [256]uint16{
+	1, 2, 0, 2, 2, 2, 2, 2, 1, 2, 1, 2, 3, 3, 3, 3, // 0x
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 3, 1, 3, 3, 3, 3, 3, // 1x
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 2, 1, 2, 3, 3, 3, 3, // 2x
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 3, 1, 3, 3, 3, 3, 3, // 3x
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 2, 1, 2, 0, 3, 3, 3, // 4x
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 3, 1, 3, 3, 3, 3, 3, // 5x
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 2, 1, 2, 0, 3, 3, 3, // 6x
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 3, 1, 3, 3, 3, 3, 3, // 7x
+	2, 2, 2, 2, 2, 2, 2, 2, 1, 2, 1, 2, 3, 3, 3, 3, // 8x
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 3, 1, 3, 3, 3, 3, 3, // 9x
+	2, 2, 2, 2, 2, 2, 2, 2, 1, 2, 1, 2, 3, 3, 3, 3, // Ax
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 3, 1, 3, 3, 3, 3, 3, // Bx
+	2, 2, 2, 2, 2, 2, 2, 2, 1, 2, 1, 2, 3, 3, 3, 3, // Cx
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 3, 1, 3, 3, 3, 3, 3, // Dx
+	2, 2, 2, 2, 2, 2, 2, 2, 1, 2, 1, 2, 3, 3, 3, 3, // Ex
+	0, 2, 0, 2, 2, 2, 2, 2, 1, 3, 1, 3, 3, 3, 3, 3, // Fx
+}
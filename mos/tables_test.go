@@ -0,0 +1,29 @@
+package mos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTablesAgree(t *testing.T) {
+	for v := range numVariants {
+		table := v.table()
+
+		for op := range 256 {
+			name := table.names[op]
+
+			// Each name should match the function that implements it, except
+			// for the unstable NMOS opcodes, which we run as NOPs
+			if table.instructions[op].String() != "NOP" || name == "NOP" {
+				assert.Equal(t, table.instructions[op].String(), name, "%v opcode $%02X", v, op)
+			}
+
+			// (The 65C02 NP2 and NP3 opcodes have operands that OperandSize
+			// doesn't count, so we only look at the 6502 here)
+			if v == NMOS6502 && table.offsets[op] > 1 {
+				assert.Equal(t, table.offsets[op], v.OperandSize(uint8(op))+1, "%v opcode $%02X", v, op)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package mos
+
+import "fmt"
+
+// The Rockwell and WDC versions of the 65C02 are the same as the 65C02 we
+// emulate by default, except that they fill in the $x7 and $xF columns (which
+// are otherwise NOPs) with bit instructions, and they define WAI ($CB) and STP
+// ($DB). Rather than repeat every table, we start from a copy of the 65C02's
+// and fill in the difference.
+var (
+	wdcInstructions     = instructions
+	wdcInstructionNames = instructionNames
+	wdcCycles           = cycles
+	wdcAddrModeFuncs    = addrModeFuncs
+	wdcAddrModes        = addrModes
+	wdcOffsets          = offsets
+)
+
+// These are the bit instructions, ordered by the bit they work with.
+var (
+	rmbInstructions = [8]Instruction{Rmb0, Rmb1, Rmb2, Rmb3, Rmb4, Rmb5, Rmb6, Rmb7}
+	smbInstructions = [8]Instruction{Smb0, Smb1, Smb2, Smb3, Smb4, Smb5, Smb6, Smb7}
+	bbrInstructions = [8]Instruction{Bbr0, Bbr1, Bbr2, Bbr3, Bbr4, Bbr5, Bbr6, Bbr7}
+	bbsInstructions = [8]Instruction{Bbs0, Bbs1, Bbs2, Bbs3, Bbs4, Bbs5, Bbs6, Bbs7}
+)
+
+func init() {
+	for bit := range 8 {
+		// RMB0-7 are $07-$77, and SMB0-7 are $87-$F7. BBR and BBS are laid
+		// out the same way in the $xF column.
+		rmb := uint8(bit<<4) | 0x07
+		smb := rmb | 0x80
+		bbr := uint8(bit<<4) | 0x0F
+		bbs := bbr | 0x80
+
+		setWDCOpcode(rmb, rmbInstructions[bit], fmt.Sprintf("RMB%d", bit), Zpg, AmZPG, 5, 2)
+		setWDCOpcode(smb, smbInstructions[bit], fmt.Sprintf("SMB%d", bit), Zpg, AmZPG, 5, 2)
+		setWDCOpcode(bbr, bbrInstructions[bit], fmt.Sprintf("BBR%d", bit), Zpr, AmZPR, 5, 0)
+		setWDCOpcode(bbs, bbsInstructions[bit], fmt.Sprintf("BBS%d", bit), Zpr, AmZPR, 5, 0)
+	}
+
+	// Both of these stop the processor, and so have no offset
+	setWDCOpcode(0xCB, Wai, "WAI", Imp, AmIMP, 3, 0)
+	setWDCOpcode(0xDB, Stp, "STP", Imp, AmIMP, 3, 0)
+}
+
+func setWDCOpcode(
+	opcode uint8,
+	inst Instruction,
+	name string,
+	mode AddrMode,
+	am int,
+	cyc uint8,
+	offset uint16,
+) {
+	wdcInstructions[opcode] = inst
+	wdcInstructionNames[opcode] = name
+	wdcAddrModeFuncs[opcode] = mode
+	wdcAddrModes[opcode] = am
+	wdcCycles[opcode] = cyc
+	wdcOffsets[opcode] = offset
+}
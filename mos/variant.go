@@ -0,0 +1,282 @@
+package mos
+
+import "github.com/pevans/erc/elog"
+
+// A Variant is a member of the 6502 family that the CPU can behave as. The
+// variants share most of their instruction set, but differ in what the
+// undefined opcodes do, how many cycles some instructions take, and in a
+// handful of quirks.
+type Variant int
+
+const (
+	// CMOS65C02 is the 65C02, which is the processor in the enhanced //e.
+	// This is the zero value, and so the default for any CPU.
+	CMOS65C02 Variant = iota
+
+	// NMOS6502 is the original 6502, which is the processor in the Apple
+	// II, II+ and the unenhanced //e.
+	NMOS6502
+
+	// WDC65C02 is the 65C02 as made by Rockwell and WDC, which adds the
+	// bit instructions (RMB, SMB, BBR and BBS) along with WAI and STP. Some
+	// later IIc and IIe upgrades came with one.
+	WDC65C02
+
+	// numVariants is the number of variants that we know about.
+	numVariants
+)
+
+// opcodeTable is the set of tables that describe how a variant executes
+// each of its opcodes.
+type opcodeTable struct {
+	instructions  *[256]Instruction
+	names         *[256]string
+	cycles        *[256]uint8
+	addrModeFuncs *[256]AddrMode
+	addrModes     *[256]int
+	offsets       *[256]uint16
+
+	// ops describes each opcode with what the tables above say about it, so
+	// that we can find all of it in one place when we execute the opcode.
+	ops [256]opcodeInfo
+}
+
+// An opcodeInfo is everything we know about an opcode before we execute it.
+// We work these out once, so that Execute doesn't have to (and doesn't have
+// to build any strings to do so).
+type opcodeInfo struct {
+	inst     Instruction
+	mode     AddrMode
+	name     string
+	addrMode int
+	cycles   uint8
+	offset   uint16
+
+	// reads is true if the opcode reads the data at its effective address
+	// (see ReadsMemory), and pagePenalty is true if the opcode takes an
+	// extra cycle when its indexed address crosses a page boundary.
+	// decimalPenalty is true if it takes an extra cycle in decimal mode.
+	reads          bool
+	pagePenalty    bool
+	decimalPenalty bool
+}
+
+// opcodeTables holds the tables for each variant. We can't fill these in
+// when they're declared, since some instructions (by way of Speculate) end
+// up looking in them, and Go would see that as an initialization cycle.
+var opcodeTables [numVariants]opcodeTable
+
+func init() {
+	opcodeTables[CMOS65C02] = opcodeTable{
+		instructions:  &instructions,
+		names:         &instructionNames,
+		cycles:        &cycles,
+		addrModeFuncs: &addrModeFuncs,
+		addrModes:     &addrModes,
+		offsets:       &offsets,
+	}
+
+	opcodeTables[NMOS6502] = opcodeTable{
+		instructions:  &nmosInstructions,
+		names:         &nmosInstructionNames,
+		cycles:        &nmosCycles,
+		addrModeFuncs: &nmosAddrModeFuncs,
+		addrModes:     &nmosAddrModes,
+		offsets:       &nmosOffsets,
+	}
+
+	opcodeTables[WDC65C02] = opcodeTable{
+		instructions:  &wdcInstructions,
+		names:         &wdcInstructionNames,
+		cycles:        &wdcCycles,
+		addrModeFuncs: &wdcAddrModeFuncs,
+		addrModes:     &wdcAddrModes,
+		offsets:       &wdcOffsets,
+	}
+
+	for v := range numVariants {
+		v.table().describe(v)
+	}
+}
+
+// describe fills in the descriptions of each opcode in the table, which
+// belongs to the given variant.
+func (t *opcodeTable) describe(v Variant) {
+	for op := range 256 {
+		opcode := uint8(op)
+
+		t.ops[op] = opcodeInfo{
+			inst:           t.instructions[op],
+			mode:           t.addrModeFuncs[op],
+			name:           t.names[op],
+			addrMode:       t.addrModes[op],
+			cycles:         t.cycles[op],
+			offset:         t.offsets[op],
+			reads:          v.readsMemory(opcode),
+			pagePenalty:    v.hasPagePenalty(opcode),
+			decimalPenalty: v.hasDecimalPenalty(opcode),
+		}
+	}
+}
+
+// String returns the name of the chip for the variant.
+func (v Variant) String() string {
+	switch v {
+	case CMOS65C02:
+		return "65C02"
+	case NMOS6502:
+		return "6502"
+	case WDC65C02:
+		return "W65C02"
+	}
+
+	return "unknown"
+}
+
+func (v Variant) table() *opcodeTable {
+	return &opcodeTables[v]
+}
+
+// op returns the description of a given opcode.
+func (v Variant) op(opcode uint8) *opcodeInfo {
+	return &opcodeTables[v].ops[opcode]
+}
+
+// InstructionName returns the mnemonic for a given opcode.
+func (v Variant) InstructionName(opcode uint8) string {
+	return v.op(opcode).name
+}
+
+// AddrMode returns the address mode constant for a given opcode.
+func (v Variant) AddrMode(opcode uint8) int {
+	return v.op(opcode).addrMode
+}
+
+// OperandSize returns the number of bytes that follow a given opcode.
+func (v Variant) OperandSize(opcode uint8) uint16 {
+	switch v.AddrMode(opcode) {
+	case AmABS, AmABX, AmABY, AmIND, AmZPR:
+		return 2
+	case AmACC, AmBY2, AmBY3, AmIMP:
+		return 0
+	}
+
+	return 1
+}
+
+// InstructionSize returns the number of bytes that a given opcode takes up
+// in memory, counting the opcode and whatever operand follows it.
+func (v Variant) InstructionSize(opcode uint8) uint16 {
+	// The placeholder modes don't resolve an operand, but they do still
+	// have one
+	switch v.AddrMode(opcode) {
+	case AmBY2:
+		return 2
+	case AmBY3:
+		return 3
+	}
+
+	return v.OperandSize(opcode) + 1
+}
+
+// ReadsMemory returns true if a given opcode reads the data at its effective
+// address. See OpcodeReadsMemory for more about why that matters.
+func (v Variant) ReadsMemory(opcode uint8) bool {
+	return v.op(opcode).reads
+}
+
+// readsMemory works out what ReadsMemory returns for a given opcode.
+func (v Variant) readsMemory(opcode uint8) bool {
+	switch v {
+	case NMOS6502:
+		return nmosReadsMemory(opcode)
+	case WDC65C02:
+		// The bit instructions in the $x7 and $xF columns all read from the
+		// zero page
+		if opcode&0x7 == 0x7 {
+			return true
+		}
+	}
+
+	return OpcodeReadsMemory(opcode)
+}
+
+// PrepareOperand formats the operand of the given line according to the
+// address mode its opcode has in this variant. See the PrepareOperand
+// function for more.
+func (v Variant) PrepareOperand(line *elog.Instruction, pc uint16) {
+	prepareOperand(line, pc, v.AddrMode(line.Opcode))
+}
+
+// nmosReadsMemory returns true if the NMOS opcode reads the data at its
+// effective address. This includes the undocumented read-modify-write
+// instructions, and the NOPs that take an address (which really do read
+// it).
+func nmosReadsMemory(opcode uint8) bool {
+	switch nmosAddrModes[opcode] {
+	case AmACC, AmIMM, AmIMP, AmREL:
+		return false
+	}
+
+	switch opcode {
+	case 0x93, 0x9B, 0x9C, 0x9E, 0x9F:
+		// These are the unstable stores (SHA, TAS, SHY, SHX), which we
+		// treat as NOPs, but which would write rather than read
+		return false
+	}
+
+	switch nmosInstructionNames[opcode] {
+	case "LDA", "LDX", "LDY", "BIT", "ORA", "AND", "EOR", "ADC", "SBC",
+		"CMP", "CPX", "CPY", "INC", "DEC", "ASL", "LSR", "ROL", "ROR",
+		"NOP", "LAX", "LAS", "SLO", "RLA", "SRE", "RRA", "DCP", "ISC":
+		return true
+	}
+
+	return false
+}
+
+// pagePenalty returns true if the opcode takes an extra cycle when its
+// indexed address crosses a page boundary.
+func (v Variant) pagePenalty(opcode uint8) bool {
+	return v.op(opcode).pagePenalty
+}
+
+// hasPagePenalty works out what pagePenalty returns for a given opcode. Only
+// instructions that read take the penalty. Writes and read-modify-write
+// instructions always take the extra cycle, and it's counted in their base
+// cycles. The 65C02 is the same, except that its shifts and rotates (but not
+// INC and DEC) went back to taking the penalty only when they cross a page.
+func (v Variant) hasPagePenalty(opcode uint8) bool {
+	if v != NMOS6502 {
+		switch v.table().names[opcode] {
+		case "STA", "STZ", "INC", "DEC", "JMP":
+			return false
+		}
+
+		return true
+	}
+
+	switch nmosInstructionNames[opcode] {
+	case "LDA", "LDX", "LDY", "ORA", "AND", "EOR", "ADC", "SBC", "CMP",
+		"NOP", "LAX", "LAS":
+		return true
+	}
+
+	return false
+}
+
+// hasDecimalPenalty returns true if the opcode takes an extra cycle when the
+// decimal flag is set. That's only ADC and SBC, and only on the 65C02, which
+// spends the cycle to get the flags right.
+func (v Variant) hasDecimalPenalty(opcode uint8) bool {
+	if v == NMOS6502 {
+		return false
+	}
+
+	switch v.table().names[opcode] {
+	case "ADC", "SBC":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,291 @@
+package mos_test
+
+import (
+	"testing"
+
+	"github.com/pevans/erc/memory"
+	"github.com/pevans/erc/mos"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeLog is a memory segment that remembers every write made to it.
+type writeLog struct {
+	*memory.Segment
+	writes []uint8
+}
+
+func (w *writeLog) Set(addr int, val uint8) {
+	w.writes = append(w.writes, val)
+	w.Segment.Set(addr, val)
+}
+
+func newVariantCPU(v mos.Variant) (*mos.CPU, *writeLog) {
+	seg := &writeLog{Segment: memory.NewSegment(0x10000)}
+
+	c := new(mos.CPU)
+	c.Variant = v
+	c.RMem = seg
+	c.WMem = seg
+	c.State = memory.NewStateMap()
+
+	return c, seg
+}
+
+func TestVariantString(t *testing.T) {
+	assert.Equal(t, "65C02", mos.CMOS65C02.String())
+	assert.Equal(t, "6502", mos.NMOS6502.String())
+	assert.Equal(t, "W65C02", mos.WDC65C02.String())
+}
+
+func TestVariantInstructionNames(t *testing.T) {
+	assert.Equal(t, "NOP", mos.CMOS65C02.InstructionName(0xA7))
+	assert.Equal(t, "LAX", mos.NMOS6502.InstructionName(0xA7))
+	assert.Equal(t, "STZ", mos.OpcodeInstruction(0x9C))
+	assert.Equal(t, "JAM", mos.NMOS6502.InstructionName(0x02))
+
+	// The NMOS $x3 opcodes take an operand, where on the 65C02 they're
+	// single-byte NOPs
+	assert.Equal(t, uint16(0), mos.CMOS65C02.OperandSize(0x03))
+	assert.Equal(t, uint16(1), mos.NMOS6502.OperandSize(0x03))
+}
+
+func TestVariantInstructionSize(t *testing.T) {
+	assert.Equal(t, uint16(2), mos.CMOS65C02.InstructionSize(0xA9)) // LDA #
+	assert.Equal(t, uint16(3), mos.CMOS65C02.InstructionSize(0x20)) // JSR
+	assert.Equal(t, uint16(1), mos.CMOS65C02.InstructionSize(0x60)) // RTS
+	assert.Equal(t, uint16(2), mos.CMOS65C02.InstructionSize(0xD0)) // BNE
+	assert.Equal(t, uint16(3), mos.CMOS65C02.InstructionSize(0x6C)) // JMP ()
+	assert.Equal(t, uint16(1), mos.CMOS65C02.InstructionSize(0x03))
+	assert.Equal(t, uint16(2), mos.NMOS6502.InstructionSize(0x03))
+	assert.Equal(t, uint16(3), mos.CMOS65C02.InstructionSize(0x5C)) // NP3
+	assert.Equal(t, uint16(3), mos.WDC65C02.InstructionSize(0x0F))  // BBR0
+}
+
+func TestNMOSExecute(t *testing.T) {
+	t.Run("LAX loads both A and X", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.NMOS6502)
+		seg.Set(0x300, 0xA7) // LAX $10
+		seg.Set(0x301, 0x10)
+		seg.Set(0x10, 0x55)
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, uint8(0x55), c.A)
+		assert.Equal(t, uint8(0x55), c.X)
+		assert.Equal(t, uint16(0x302), c.PC)
+		assert.Equal(t, uint64(3), c.CycleCounter())
+	})
+
+	t.Run("JMP indirect wraps within the page", func(t *testing.T) {
+		for _, v := range []mos.Variant{mos.NMOS6502, mos.CMOS65C02} {
+			c, seg := newVariantCPU(v)
+			seg.Set(0x300, 0x6C) // JMP ($12FF)
+			seg.Set(0x301, 0xFF)
+			seg.Set(0x302, 0x12)
+			seg.Set(0x12FF, 0x34)
+			seg.Set(0x1200, 0x56)
+			seg.Set(0x1300, 0x78)
+			c.PC = 0x300
+
+			assert.NoError(t, c.Execute())
+
+			if v == mos.NMOS6502 {
+				assert.Equal(t, uint16(0x5634), c.PC)
+			} else {
+				assert.Equal(t, uint16(0x7834), c.PC)
+			}
+		}
+	})
+
+	t.Run("read-modify-write instructions write twice", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.NMOS6502)
+		seg.Set(0x300, 0xEE) // INC $2000
+		seg.Set(0x301, 0x00)
+		seg.Set(0x302, 0x20)
+		seg.Set(0x2000, 0x41)
+		seg.writes = nil
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, []uint8{0x41, 0x42}, seg.writes)
+
+		c, seg = newVariantCPU(mos.CMOS65C02)
+		seg.Set(0x300, 0xEE)
+		seg.Set(0x301, 0x00)
+		seg.Set(0x302, 0x20)
+		seg.Set(0x2000, 0x41)
+		seg.writes = nil
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, []uint8{0x42}, seg.writes)
+	})
+
+	t.Run("JAM does not advance", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.NMOS6502)
+		seg.Set(0x300, 0x02)
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, uint16(0x300), c.PC)
+	})
+
+	t.Run("stores don't take a page-cross penalty", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.NMOS6502)
+		seg.Set(0x300, 0x9D) // STA $20FF,X
+		seg.Set(0x301, 0xFF)
+		seg.Set(0x302, 0x20)
+		c.X = 1
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, uint64(5), c.CycleCounter())
+	})
+
+	t.Run("BRK leaves the decimal flag alone", func(t *testing.T) {
+		c, _ := newVariantCPU(mos.NMOS6502)
+		c.S = 0xFF
+		c.P = mos.DECIMAL
+
+		mos.Brk(c)
+		assert.Equal(t, mos.DECIMAL, c.P&mos.DECIMAL)
+	})
+}
+
+func TestNMOSDecimal(t *testing.T) {
+	t.Run("ADC sets Z from the binary result", func(t *testing.T) {
+		c, _ := newVariantCPU(mos.NMOS6502)
+		c.A = 0x99
+		c.EffVal = 0x01
+		c.P = mos.DECIMAL
+		mos.Adc(c)
+
+		// The decimal result is $00, but the binary sum is $9A, so Z is clear
+		assert.Equal(t, uint8(0x00), c.A)
+		assert.Equal(t, mos.CARRY, c.P&mos.CARRY)
+		assert.Equal(t, uint8(0), c.P&mos.ZERO)
+		assert.Equal(t, mos.NEGATIVE, c.P&mos.NEGATIVE)
+	})
+
+	t.Run("SBC sets its flags from the binary result", func(t *testing.T) {
+		c, _ := newVariantCPU(mos.NMOS6502)
+		c.A = 0x10
+		c.EffVal = 0x01
+		c.P = mos.DECIMAL | mos.CARRY
+		mos.Sbc(c)
+
+		assert.Equal(t, uint8(0x09), c.A)
+		assert.Equal(t, mos.CARRY, c.P&mos.CARRY)
+		assert.Equal(t, uint8(0), c.P&mos.NEGATIVE)
+		assert.Equal(t, uint8(0), c.P&mos.ZERO)
+	})
+
+	t.Run("decimal mode doesn't cost a cycle", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.NMOS6502)
+		seg.Set(0x300, 0x69) // ADC #$01
+		seg.Set(0x301, 0x01)
+		c.P = mos.DECIMAL
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, uint64(2), c.CycleCounter())
+	})
+}
+
+func TestWDCExecute(t *testing.T) {
+	t.Run("RMB and SMB change one bit", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.WDC65C02)
+		seg.Set(0x300, 0x37) // RMB3 $10
+		seg.Set(0x301, 0x10)
+		seg.Set(0x302, 0xC7) // SMB4 $10
+		seg.Set(0x303, 0x10)
+		seg.Set(0x10, 0x0F)
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, uint8(0x07), seg.Get(0x10))
+		assert.Equal(t, uint64(5), c.CycleCounter())
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, uint8(0x17), seg.Get(0x10))
+		assert.Equal(t, uint16(0x304), c.PC)
+	})
+
+	t.Run("BBR branches when the bit is clear", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.WDC65C02)
+		seg.Set(0x300, 0x0F) // BBR0 $10,$0310
+		seg.Set(0x301, 0x10)
+		seg.Set(0x302, 0x0D)
+		seg.Set(0x10, 0xFE)
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, uint16(0x310), c.PC)
+		assert.Equal(t, uint64(6), c.CycleCounter())
+	})
+
+	t.Run("BBS falls through when the bit is clear", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.WDC65C02)
+		seg.Set(0x300, 0x8F) // BBS0 $10,$0310
+		seg.Set(0x301, 0x10)
+		seg.Set(0x302, 0x0D)
+		seg.Set(0x10, 0xFE)
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, uint16(0x303), c.PC)
+		assert.Equal(t, uint64(5), c.CycleCounter())
+	})
+
+	t.Run("STP and WAI stay put", func(t *testing.T) {
+		for _, op := range []uint8{0xCB, 0xDB} {
+			c, seg := newVariantCPU(mos.WDC65C02)
+			seg.Set(0x300, op)
+			c.PC = 0x300
+
+			assert.NoError(t, c.Execute())
+			assert.Equal(t, uint16(0x300), c.PC)
+			assert.Equal(t, uint64(3), c.CycleCounter())
+		}
+	})
+
+	t.Run("the 65C02 still treats these as NOPs", func(t *testing.T) {
+		c, seg := newVariantCPU(mos.CMOS65C02)
+		seg.Set(0x300, 0x37)
+		seg.Set(0x10, 0x0F)
+		c.PC = 0x300
+
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, uint8(0x0F), seg.Get(0x10))
+		assert.Equal(t, uint16(0x301), c.PC)
+	})
+}
+
+func TestWDCFormat(t *testing.T) {
+	c, seg := newVariantCPU(mos.WDC65C02)
+	seg.Set(0x300, 0xFF) // BBS7 $12,$02F0
+	seg.Set(0x301, 0x12)
+	seg.Set(0x302, 0xED)
+
+	line, width := c.SpeculateInstuction(0x300)
+	assert.Equal(t, "BBS7", line.Instruction)
+	assert.Equal(t, "$12,$02F0", line.PreparedOperand)
+	assert.Equal(t, uint16(3), width)
+}
+
+func TestVariantRestore(t *testing.T) {
+	c, _ := newVariantCPU(mos.NMOS6502)
+
+	state := c.Snapshot()
+	state.Variant = int(mos.WDC65C02)
+	assert.NoError(t, c.Restore(state))
+	assert.Equal(t, mos.WDC65C02, c.Variant)
+
+	// A variant we don't know about can't be restored, and leaves the CPU
+	// as it was
+	for _, v := range []int{-1, 3, 99} {
+		state.Variant = v
+		assert.Error(t, c.Restore(state))
+		assert.Equal(t, mos.WDC65C02, c.Variant)
+	}
+}
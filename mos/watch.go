@@ -0,0 +1,32 @@
+package mos
+
+import "github.com/pevans/erc/a2/a2state"
+
+// watching returns true if we should tell Watch about an access to addr.
+// That's only so for the accesses that an instruction makes to data, and
+// not for those that fetch the instruction itself, nor for those we make
+// when the debugger is only looking ahead.
+func (c *CPU) watching(addr uint16) bool {
+	if c.Watch == nil || !c.executing || !c.Watch.Watched(int(addr)) {
+		return false
+	}
+
+	// While we fetch the opcode, c.opcode is still that of the last
+	// instruction; but the opcode is at LastPC, so it's covered either way
+	if addr-c.LastPC < c.Variant.InstructionSize(c.opcode) {
+		return false
+	}
+
+	return !c.State.Bool(a2state.DebuggerLookAhead)
+}
+
+// directGet returns the byte at addr in the memory we would write to,
+// without setting off any soft switch. If we can't read memory that way, we
+// return zero.
+func (c *CPU) directGet(addr uint16) uint8 {
+	if mem, ok := c.WMem.(DirectMemory); ok {
+		return mem.DirectGet(int(addr))
+	}
+
+	return 0
+}
@@ -0,0 +1,59 @@
+package mos_test
+
+import (
+	"testing"
+
+	"github.com/pevans/erc/memory"
+	"github.com/pevans/erc/mos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	c, seg := newVariantCPU(mos.CMOS65C02)
+	c.Watch = memory.NewWatchpoints()
+
+	require.NoError(t, c.Watch.Add(memory.Watchpoint{Kind: memory.WatchChange, From: 0x2000, To: 0x20FF}))
+	require.NoError(t, c.Watch.Add(memory.Watchpoint{Kind: memory.WatchRead, From: 0x0300, To: 0x0310}))
+
+	seg.Set(0x300, 0xA9) // LDA #$42
+	seg.Set(0x301, 0x42)
+	seg.Set(0x302, 0x8D) // STA $2010
+	seg.Set(0x303, 0x10)
+	seg.Set(0x304, 0x20)
+	seg.Set(0x305, 0x8D) // STA $2010
+	seg.Set(0x306, 0x10)
+	seg.Set(0x307, 0x20)
+	seg.Set(0x308, 0xAD) // LDA $0310
+	seg.Set(0x309, 0x10)
+	seg.Set(0x30A, 0x03)
+	seg.Set(0x2010, 0x11)
+	c.PC = 0x300
+
+	// Fetching the instructions is not a read that we watch for
+	assert.NoError(t, c.Execute())
+	assert.Empty(t, c.Watch.Hits())
+
+	assert.NoError(t, c.Execute())
+	hits := c.Watch.Hits()
+	assert.Len(t, hits, 1)
+	assert.Equal(t, memory.WatchHit{
+		Watchpoint: memory.Watchpoint{Kind: memory.WatchChange, From: 0x2000, To: 0x20FF},
+		PC:         0x302,
+		Addr:       0x2010,
+		Old:        0x11,
+		New:        0x42,
+		Write:      true,
+	}, hits[0])
+
+	// Writing the same value again is no change
+	assert.NoError(t, c.Execute())
+	assert.Empty(t, c.Watch.Hits())
+
+	assert.NoError(t, c.Execute())
+	hits = c.Watch.Hits()
+	assert.Len(t, hits, 1)
+	assert.Equal(t, 0x310, hits[0].Addr)
+	assert.Equal(t, 0x308, hits[0].PC)
+	assert.False(t, hits[0].Write)
+}
@@ -331,3 +331,26 @@ teardown()   { load assembler_helper; teardown; }
 	msg=$("$ASSEMBLER" -o /dev/null "$src" 2>&1 || true)
 	[[ "$msg" =~ "range" ]]
 }
+
+# ---------------------------------------------------------------------------
+# Symbol files
+# ---------------------------------------------------------------------------
+
+@test "-sym writes the labels as a VICE label file" {
+	local src="$TMP/test.s"
+	printf '%s\n' 'START: NOP' 'LOOP: JMP LOOP' >"$src"
+	run "$ASSEMBLER" -o /dev/null -sym "$TMP/test.sym" "$src"
+	[[ $status -eq 0 ]]
+	[[ "$(cat "$TMP/test.sym")" == $'al C:0801 .START\nal C:0802 .LOOP' ]]
+}
+
+@test "-dbg writes which lines are at which addresses" {
+	local src="$TMP/test.s"
+	printf '%s\n' '; a test' 'START: NOP' 'LOOP: JMP LOOP' >"$src"
+	run "$ASSEMBLER" -o /dev/null -dbg "$TMP/test.dbg" "$src"
+	[[ $status -eq 0 ]]
+	local dbg
+	dbg=$(tr -d ' \n' <"$TMP/test.dbg")
+	[[ "$dbg" == *'{"line":2,"addr":2049,"size":1,"code":true},{"line":3,"addr":2050,"size":3,"code":true}]'* ]]
+	[[ "$dbg" == *'"LOOP":2050'* ]]
+}
@@ -233,3 +233,434 @@ load debugger_helper
 	capture
 	[[ "$PANE" == *"write protect on drive 1 is OFF"* ]]
 }
+
+# 5.17 history and back
+@test "history shows executed instructions" {
+	send_cmd "step 3"
+	send_cmd "history"
+	capture
+	[[ "$PANE" == *"showing 3 of 3 instructions"* ]]
+}
+
+@test "back undoes executed instructions" {
+	send_cmd "status"
+	capture
+	local before
+	before=$(grep -o 'PC:\$[0-9A-F]*' <<<"$PANE" | tail -1)
+	send_cmd "step 2"
+	send_cmd "back 2"
+	capture
+	[[ "$PANE" == *"went back 2 instructions"* ]]
+	[[ "$(grep -o 'PC:\$[0-9A-F]*' <<<"$PANE" | tail -1)" == "$before" ]]
+}
+
+@test "back past the start of history shows how far it went" {
+	send_cmd "step 1"
+	send_cmd "back 5"
+	capture
+	[[ "$PANE" == *"history only went back 1 instructions"* ]]
+}
+
+@test "back rejects a negative count" {
+	send_cmd "back -3"
+	capture
+	[[ "$PANE" == *"invalid command: invalid integer"* ]]
+}
+
+# 5.18 watch and unwatch
+@test "watch lists watchpoints" {
+	send_cmd "watch"
+	capture
+	[[ "$PANE" == *"no watchpoints are set"* ]]
+	send_cmd "watch change 0300-03FF"
+	send_cmd "watch"
+	capture
+	[[ "$PANE" == *"watching change 0300-03FF"* ]]
+	send_cmd "unwatch 0300-03FF"
+	capture
+	[[ "$PANE" == *"removed 1 watchpoints"* ]]
+}
+
+@test "step stops at a watchpoint" {
+	send_cmd "watch write 0000-00FF"
+	send_cmd "step 1000"
+	capture
+	[[ "$PANE" == *"watchpoint write 0000-00FF: \$00"*" written by \$"* ]]
+	[[ "$PANE" != *"executed 1000 times"* ]]
+}
+
+@test "watch with an unknown kind shows error" {
+	send_cmd "watch poke 0300"
+	capture
+	[[ "$PANE" == *"unknown watchpoint kind"* ]]
+}
+
+@test "--debug-watch enters the debugger on a watchpoint" {
+	tmux kill-session -t "$SESSION" 2>/dev/null || true
+	SESSION="erc-dbg-watch-$$-$BATS_TEST_NUMBER"
+	export SESSION
+	tmux new-session -d -s "$SESSION" \
+		"$ERC" headless --debug-watch write:0000-00FF --steps 10000000 "$DISK"
+	wait_for_prompt
+
+	capture
+	[[ "$PANE" == *"watchpoint write 0000-00FF"* ]]
+	[[ "$PANE" == *"accessed by"* ]]
+}
+
+# 5.19 conditional breakpoints and print
+@test "print evaluates an expression" {
+	send_cmd 'print word[$FFFC]'
+	capture
+	[[ "$PANE" == *'word[$FFFC] = $FA62 (64098)'* ]]
+	send_cmd 'print A == A && !0'
+	capture
+	[[ "$PANE" == *'A == A && !0 = $01 (1)'* ]]
+}
+
+@test "print with an invalid expression shows error" {
+	send_cmd "print 1 +"
+	capture
+	[[ "$PANE" == *"invalid expression"* ]]
+}
+
+@test "break with a condition stops when it is true" {
+	send_cmd 'break FA63 if PC == $FA63 && hits == 1'
+	capture
+	[[ "$PANE" == *'breakpoint 1 set at FA63 if PC == $FA63 && hits == 1'* ]]
+	send_cmd "resume"
+	capture
+	[[ "$PANE" == *'stopped at breakpoint FA63 if PC == $FA63 && hits == 1 (hit 1 times)'* ]]
+}
+
+@test "break with log shows the message and carries on" {
+	send_cmd 'break FA62 log reset with PC={PC}'
+	send_cmd "break FA63"
+	send_cmd "resume"
+	capture
+	[[ "$PANE" == *'reset with PC=$FA62'* ]]
+	[[ "$PANE" == *"stopped at breakpoint FA63"* ]]
+}
+
+@test "break with an invalid condition shows error" {
+	send_cmd "break FA63 if A =="
+	capture
+	[[ "$PANE" == *"invalid breakpoint"* ]]
+}
+
+# 5.20 breakpoint management and persistence
+@test "info breakpoints lists breakpoints" {
+	send_cmd "info breakpoints"
+	capture
+	[[ "$PANE" == *"no breakpoints are set"* ]]
+	send_cmd "break FA62"
+	send_cmd 'break FA63 if A == $C1'
+	send_cmd "info breakpoints"
+	capture
+	[[ "$PANE" == *"num  enabled  hits  breakpoint"* ]]
+	[[ "$PANE" == *"  1  y           0  FA62"* ]]
+	[[ "$PANE" == *'  2  y           0  FA63 if A == $C1'* ]]
+}
+
+@test "disable and enable a breakpoint" {
+	send_cmd "break FA63"
+	send_cmd "break FA66"
+	send_cmd "disable 1"
+	capture
+	[[ "$PANE" == *"disabled breakpoint 1 at FA63"* ]]
+	send_cmd "resume"
+	capture
+	[[ "$PANE" != *"stopped at breakpoint FA63"* ]]
+	[[ "$PANE" == *"stopped at breakpoint FA66"* ]]
+	send_cmd "enable"
+	send_cmd "info breakpoints"
+	capture
+	[[ "$PANE" == *"enabled breakpoint 1 at FA63"* ]]
+	[[ "$PANE" == *"  1  y           0  FA63"* ]]
+}
+
+@test "delete removes breakpoints" {
+	send_cmd "break FA62"
+	send_cmd "break FA63"
+	send_cmd "delete 1"
+	capture
+	[[ "$PANE" == *"deleted breakpoint 1 at FA62"* ]]
+	send_cmd "delete 7"
+	capture
+	[[ "$PANE" == *"no breakpoint number 7"* ]]
+	send_cmd "delete"
+	send_cmd "info breakpoints"
+	capture
+	[[ "$PANE" == *"deleted all breakpoints"* ]]
+	[[ "$PANE" == *"no breakpoints are set"* ]]
+}
+
+@test "breakpoints are saved and loaded with the disk" {
+	send_cmd 'break FA63 if A == A'
+	send_cmd "break FA66"
+	send_cmd "disable 2"
+	[[ -f "$DISK.breakpoints" ]]
+
+	tmux kill-session -t "$SESSION" 2>/dev/null || true
+	SESSION="erc-dbg-bpfile-$$-$BATS_TEST_NUMBER"
+	export SESSION
+	tmux new-session -d -s "$SESSION" \
+		"$ERC" headless --load-breakpoints --steps 10000000 "$DISK"
+	wait_for_prompt
+
+	capture
+	[[ "$PANE" == *"loaded 2 breakpoints from"* ]]
+	[[ "$PANE" == *"stopped at breakpoint FA63 if A == A"* ]]
+	send_cmd "info breakpoints"
+	capture
+	[[ "$PANE" == *"  2  n"* ]]
+}
+
+@test "breakpoints are only loaded when asked" {
+	send_cmd "break FA66"
+	[[ -f "$DISK.breakpoints" ]]
+
+	tmux kill-session -t "$SESSION" 2>/dev/null || true
+	SESSION="erc-dbg-bpskip-$$-$BATS_TEST_NUMBER"
+	export SESSION
+	tmux new-session -d -s "$SESSION" \
+		"$ERC" headless --start-in-debugger --steps 10000000 "$DISK"
+	wait_for_prompt
+
+	send_cmd "info breakpoints"
+	capture
+	[[ "$PANE" != *"loaded 1 breakpoints from"* ]]
+	[[ "$PANE" == *"no breakpoints are set"* ]]
+}
+
+@test "breakpoints from --debug-break are not saved" {
+	tmux kill-session -t "$SESSION" 2>/dev/null || true
+	SESSION="erc-dbg-bptransient-$$-$BATS_TEST_NUMBER"
+	export SESSION
+	tmux new-session -d -s "$SESSION" \
+		"$ERC" headless --start-in-debugger --load-breakpoints --debug-break FA62 --steps 10000000 "$DISK"
+	wait_for_prompt
+
+	send_cmd "break FA66"
+	[[ -f "$DISK.breakpoints" ]]
+	grep -q "FA66" "$DISK.breakpoints"
+	! grep -q "FA62" "$DISK.breakpoints"
+}
+
+@test "breakpoints keep their ignore count after they pass" {
+	send_cmd "break FA63 ignore 1"
+	send_cmd "break FA66"
+	send_cmd "resume"
+	capture
+	[[ "$PANE" == *"stopped at breakpoint FA66"* ]]
+	send_cmd "info breakpoints"
+	capture
+	[[ "$PANE" == *"  1  y           1  FA63 ignore 1"* ]]
+	grep -q "FA63 ignore 1" "$DISK.breakpoints"
+}
+
+# 5.21 dump, dis, find, fill and copy
+@test "dump shows hex and text" {
+	send_cmd "dump rom:FFF0 FFFF"
+	capture
+	[[ "$PANE" == *'rom:FFF0    83 7F 5D CC B5 FC 17 17 F5 03 FB 03 62 FA FA C3 |..]L5|..u.{.bzzC|'* ]]
+}
+
+@test "dump with an unknown bank shows error" {
+	send_cmd "dump foo:0400 0410"
+	capture
+	[[ "$PANE" == *"unknown bank"* ]]
+}
+
+@test "dis disassembles from an address" {
+	send_cmd "dis rom:FA62 3"
+	capture
+	[[ "$PANE" == *"FA62:D8"*"CLD"* ]]
+	[[ "$PANE" == *"FA63:20 84 FE"*"JSR"* ]]
+	[[ "$PANE" == *"FA66:20 2F FB"*"JSR"* ]]
+}
+
+@test "dis stops at the end of memory" {
+	send_cmd "dis rom:FFFA 99999999999"
+	capture
+	[[ "$PANE" == *"FFFA:"* ]]
+	[[ "$PANE" != *"0000:"* ]]
+}
+
+@test "find locates text in memory" {
+	send_cmd 'find rom:C000 FFFF "Apple //e"'
+	capture
+	[[ "$PANE" == *"found at rom:FF0A"* ]]
+	send_cmd "find rom:FFF0 FFFF 62 FA"
+	capture
+	[[ "$PANE" == *"found at rom:FFFC"* ]]
+}
+
+@test "fill and copy write memory" {
+	send_cmd "fill 0300 030F EA 60"
+	capture
+	[[ "$PANE" == *"filled 0300-030F"* ]]
+	send_cmd "copy 0300 030F aux:0400"
+	capture
+	[[ "$PANE" == *"copied 16 bytes from 0300 to aux:0400"* ]]
+	send_cmd "dump aux:0400 040F"
+	capture
+	[[ "$PANE" == *"aux:0400    EA 60 EA 60"* ]]
+	send_cmd "fill rom:F800 F800 00"
+	capture
+	[[ "$PANE" == *"rom can't be written to"* ]]
+}
+
+# 5.22 next, finish and bt
+@test "next steps over a subroutine" {
+	send_cmd "next 2"
+	capture
+	[[ "$PANE" == *"executed 5 instructions"* ]]
+	[[ "$PANE" == *'PC:$FA66'* ]]
+}
+
+@test "bt and finish in a subroutine" {
+	send_cmd "step 2"
+	send_cmd "bt"
+	capture
+	[[ "$PANE" == *"#0  FE84 in SETNORM"* ]]
+	[[ "$PANE" == *"#1  FA63"* ]]
+	send_cmd "finish"
+	capture
+	[[ "$PANE" == *"returned from SETNORM to FA66 after 3 instructions"* ]]
+	[[ "$PANE" == *'PC:$FA66'* ]]
+}
+
+@test "finish outside a subroutine shows error" {
+	send_cmd "finish"
+	capture
+	[[ "$PANE" == *"not in a subroutine"* ]]
+}
+
+# 5.23 asm
+@test "asm assembles lines into memory" {
+	send_cmd "asm 0300"
+	capture
+	[[ "$PANE" == *"assembling into memory"* ]]
+	send_cmd 'LOOP: LDA #$C1'
+	send_cmd "JSR COUT"
+	send_cmd "BNE LOOP"
+	send_cmd ""
+	capture
+	[[ "$PANE" == *'0300:A9 C1       | LOOP: LDA #$C1'* ]]
+	[[ "$PANE" == *"0302:20 ED FD    | JSR COUT"* ]]
+	[[ "$PANE" == *"0305:D0 F9       | BNE LOOP"* ]]
+	[[ "$PANE" == *"stopped assembling"* ]]
+	send_cmd "dump 0300 0306"
+	capture
+	[[ "$PANE" == *"0300        A9 C1 20 ED FD D0 F9"* ]]
+}
+
+@test "asm with an instruction assembles one line" {
+	send_cmd 'asm aux:0400 STA $C000'
+	capture
+	[[ "$PANE" == *'aux:0400:8D 00 C0    | STA $C000'* ]]
+	send_cmd "asm 0300 FOO"
+	capture
+	[[ "$PANE" == *"couldn't assemble: unknown mnemonic"* ]]
+}
+
+# 5.24 symbol files
+@test "--symbols names addresses for break and dis" {
+	printf '%s\n' '; our names' 'FA66 BOOT_INIT' '$FB2F MY_INIT' >"$BATS_TEST_TMPDIR/boot.sym"
+
+	tmux kill-session -t "$SESSION" 2>/dev/null || true
+	SESSION="erc-dbg-sym-$$-$BATS_TEST_NUMBER"
+	export SESSION
+	tmux new-session -d -s "$SESSION" \
+		"$ERC" headless --start-in-debugger --steps 10000000 \
+		--symbols "$BATS_TEST_TMPDIR/boot.sym" "$DISK"
+	wait_for_prompt
+
+	send_cmd "break BOOT_INIT"
+	capture
+	[[ "$PANE" == *"breakpoint 1 set at FA66"* ]]
+	send_cmd "dis BOOT_INIT 1"
+	capture
+	[[ "$PANE" == *"FA66:20 2F FB"*"MY_INIT"* ]]
+	send_cmd "asm 0300 JSR MY_INIT"
+	capture
+	[[ "$PANE" == *"0300:20 2F FB"* ]]
+	send_cmd "break ADD"
+	capture
+	[[ "$PANE" == *"set at FDD1"* ]]
+	send_cmd 'break $ADD'
+	capture
+	[[ "$PANE" == *"set at 0ADD"* ]]
+	send_cmd "break NO_SUCH_NAME"
+	capture
+	[[ "$PANE" == *"not hex or a known symbol"* ]]
+}
+
+# 5.25 scripts and breakpoint commands
+@test "source runs the commands in a file" {
+	printf '%s\n' '# a script' '' 'print 1 + 1' >"$BATS_TEST_TMPDIR/script"
+	send_cmd "source $BATS_TEST_TMPDIR/script"
+	capture
+	[[ "$PANE" == *'= $02 (2)'* ]]
+	send_cmd "source $BATS_TEST_TMPDIR/no-such-file"
+	capture
+	[[ "$PANE" == *"could not source script"* ]]
+}
+
+@test "repeat runs a command several times" {
+	send_cmd "repeat 3 step"
+	capture
+	[[ "$(grep -c "executed 1 times" <<<"$PANE")" -eq 3 ]]
+	send_cmd "repeat x step"
+	capture
+	[[ "$PANE" == *"invalid count"* ]]
+}
+
+@test "break with do runs commands and can continue" {
+	send_cmd "break FA63 do get 0300; continue"
+	send_cmd "break FA66"
+	send_cmd "resume"
+	capture
+	[[ "$PANE" == *"stopped at breakpoint FA63 do get 0300; continue"* ]]
+	[[ "$PANE" == *'address $0300:'* ]]
+	[[ "$PANE" == *"stopped at breakpoint FA66"* ]]
+}
+
+@test "continue if stays stopped when the condition is false" {
+	send_cmd "break FA63 do continue if 1 == 0"
+	send_cmd "resume"
+	send_cmd "status"
+	capture
+	[[ "$PANE" == *"stopped at breakpoint FA63"* ]]
+	[[ "$PANE" == *'PC:$FA63'* ]]
+}
+
+@test "a disk's startup script runs when the emulator starts" {
+	printf '%s\n' 'break FA66' 'print 2 + 2' >"$DISK.ercdbg"
+
+	tmux kill-session -t "$SESSION" 2>/dev/null || true
+	SESSION="erc-dbg-script-$$-$BATS_TEST_NUMBER"
+	export SESSION
+	tmux new-session -d -s "$SESSION" \
+		"$ERC" headless --steps 10000000 "$DISK"
+	wait_for_prompt
+
+	capture
+	[[ "$PANE" == *"running startup script"* ]]
+	[[ "$PANE" == *'= $04 (4)'* ]]
+	[[ "$PANE" == *"stopped at breakpoint FA66"* ]]
+}
+
+@test "a startup script that doesn't stop leaves a headless run alone" {
+	printf '%s\n' 'print 2 + 2' >"$DISK.ercdbg"
+
+	tmux kill-session -t "$SESSION" 2>/dev/null || true
+	SESSION="erc-dbg-script-run-$$-$BATS_TEST_NUMBER"
+	export SESSION
+	tmux new-session -d -s "$SESSION" \
+		"$ERC" headless --steps 1000 "$DISK"
+	sleep 2
+
+	! tmux has-session -t "$SESSION" 2>/dev/null
+}
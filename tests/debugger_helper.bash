@@ -1,4 +1,5 @@
 DISK="$BATS_TEST_DIRNAME/../data/memreg.dsk"
+PROMPT_RE='debug>|asm [[:alnum:]:]+>'
 
 setup_file() {
 	if [[ ! -f "$DISK" ]]; then
@@ -12,11 +13,18 @@ setup_file() {
 
 setup() {
 	ERC="$BATS_FILE_TMPDIR/erc"
+
+	# Each test debugs its own copy of the disk, so that breakpoints saved
+	# alongside it by one test are not loaded by the next.
+	cp "$BATS_TEST_DIRNAME/../data/memreg.dsk" "$BATS_TEST_TMPDIR/memreg.dsk"
+	DISK="$BATS_TEST_TMPDIR/memreg.dsk"
+	export DISK
+
 	SESSION="erc-dbg-$$-$BATS_TEST_NUMBER"
 	export SESSION
 
 	tmux new-session -d -s "$SESSION" \
-		"$ERC" headless --start-in-debugger --steps 10000000 "$DISK"
+		"$ERC" headless --start-in-debugger --load-breakpoints --steps 10000000 "$DISK"
 
 	wait_for_prompt
 }
@@ -44,14 +52,15 @@ send_cmd() {
 	local cmd="$1"
 	local before after max_retries=30 i
 
-	# Count current prompts before sending
-	before=$(tmux capture-pane -p -S - -t "$SESSION" 2>/dev/null | grep -c "debug>" || true)
+	# Count current prompts before sending. While we're assembling lines
+	# with asm, the prompt is "asm <addr>> " instead of "debug> ".
+	before=$(tmux capture-pane -p -S - -t "$SESSION" 2>/dev/null | grep -cE "$PROMPT_RE" || true)
 
 	tmux send-keys -t "$SESSION" "$cmd" Enter
 
 	# Wait until prompt count increases
 	for (( i=0; i<max_retries; i++ )); do
-		after=$(tmux capture-pane -p -S - -t "$SESSION" 2>/dev/null | grep -c "debug>" || true)
+		after=$(tmux capture-pane -p -S - -t "$SESSION" 2>/dev/null | grep -cE "$PROMPT_RE" || true)
 		if (( after > before )); then
 			return 0
 		fi